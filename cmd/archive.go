@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive stops the task and hides it from list",
+	Run:   withApp((*tracker.App).Archive),
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive",
+	Short: "Unarchive brings archived task back to list",
+	Run:   withApp((*tracker.App).Unarchive),
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Trash shows removed tasks",
+	Run:   withApp((*tracker.App).Trash),
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore moves removed task back to list",
+	Run:   withApp((*tracker.App).Restore),
+}
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Purge deletes removed tasks after retention period",
+	Run:   withApp((*tracker.App).Purge),
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	rootCmd.AddCommand(trashCmd)
+
+	trashCmd.AddCommand(restoreCmd)
+	trashCmd.AddCommand(purgeCmd)
+
+	listCmd.Flags().BoolP(
+		flags.Archived.Name,
+		flags.Archived.Shorthand,
+		false,
+		"--archived to show archived tasks")
+
+	purgeCmd.Flags().DurationP(
+		flags.OlderThan.Name,
+		flags.OlderThan.Shorthand,
+		0,
+		"--older-than to purge tasks removed earlier than duration ago")
+
+	purgeCmd.Flags().BoolP(
+		flags.All.Name,
+		flags.All.Shorthand,
+		false,
+		"--all to purge the whole trash")
+}
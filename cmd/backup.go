@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [file]",
+	Short: "Backup writes all workspaces to a versioned JSON file",
+	Run:   withApp((*tracker.App).Backup),
+}
+
+var restoreBackupCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore replaces or merges data with the backup file",
+	Run:   withApp((*tracker.App).RestoreBackup),
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreBackupCmd)
+
+	backupCmd.Flags().BoolP(
+		flags.Gzip.Name,
+		flags.Gzip.Shorthand,
+		false,
+		"--gzip to compress the backup, implied by .gz file extension")
+
+	restoreBackupCmd.Flags().String(
+		flags.Mode.Name,
+		"merge",
+		"--mode replace to make data equal to the backup or merge to add missing tasks and sessions")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var chartCmd = &cobra.Command{
+	Use:   "chart",
+	Short: "Chart draws tracked hours per day or week",
+	Run:   withApp((*tracker.App).Chart),
+}
+
+var heatmapCmd = &cobra.Command{
+	Use:   "heatmap",
+	Short: "Heatmap draws tracked time per day of the year",
+	Run:   withApp((*tracker.App).ChartHeatmap),
+}
+
+func init() {
+	rootCmd.AddCommand(chartCmd)
+	chartCmd.AddCommand(heatmapCmd)
+
+	chartCmd.Flags().String(
+		flags.Period.Name,
+		"day",
+		"--period of a bar, day or week")
+
+	chartCmd.Flags().Bool(
+		flags.Stack.Name,
+		false,
+		"--stack to split bars by the first tag of the tasks")
+
+	chartCmd.Flags().StringP(
+		flags.From.Name,
+		flags.From.Shorthand,
+		"",
+		"--from to draw since the date, 2006-01-02 or RFC3339")
+
+	chartCmd.Flags().StringP(
+		flags.To.Name,
+		flags.To.Shorthand,
+		"",
+		"--to to draw till the date inclusive, 2006-01-02 or RFC3339")
+
+	heatmapCmd.Flags().Int(
+		flags.Year.Name,
+		0,
+		"--year to draw, the last 12 months by default")
+
+	for _, c := range []*cobra.Command{chartCmd, heatmapCmd} {
+		c.Flags().String(
+			flags.Tag.Name,
+			"",
+			"--tag to filter by tag")
+
+		c.Flags().Int(
+			flags.Width.Name,
+			0,
+			"--width of the chart, terminal width by default")
+
+		c.Flags().Bool(
+			flags.NoColor.Name,
+			false,
+			"--no-color to draw with shades only")
+	}
+}
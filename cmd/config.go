@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Config shows and changes settings",
+	Run:   tracker.ConfigShow,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show prints all settings with their sources",
+	Run:   tracker.ConfigShow,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get prints value of the setting",
+	Run:   tracker.ConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set stores the setting in the config file for the profile",
+	Run:   tracker.ConfigSet,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Db maintains the stored data",
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate upgrades stored data to the current schema version",
+	Run:   withApp((*tracker.App).Migrate),
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+
+	dbCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().Bool(
+		flags.DryRun.Name,
+		false,
+		"--dry-run to show pending migrations without saving")
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Doctor checks stored data for broken invariants",
+	Run:   withApp((*tracker.App).Doctor),
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().Bool(
+		flags.Fix.Name,
+		false,
+		"--fix to repair found problems")
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Encryption shows whether stored data is encrypted",
+	Run:   withApp((*tracker.App).Encryption),
+}
+
+var encryptionEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable encrypts stored data with a passphrase or --new-key-file",
+	Run:   withApp((*tracker.App).EncryptionEnable),
+}
+
+var encryptionRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Rotate encrypts stored data with a new passphrase or --new-key-file",
+	Run:   withApp((*tracker.App).EncryptionRotate),
+}
+
+var encryptionDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable decrypts stored data",
+	Run:   withApp((*tracker.App).EncryptionDisable),
+}
+
+var encryptionKeygenCmd = &cobra.Command{
+	Use:   "keygen <file>",
+	Short: "Keygen writes a random key file",
+	Run:   tracker.EncryptionKeygen,
+}
+
+func init() {
+	rootCmd.AddCommand(encryptionCmd)
+
+	encryptionCmd.AddCommand(encryptionEnableCmd)
+	encryptionCmd.AddCommand(encryptionRotateCmd)
+	encryptionCmd.AddCommand(encryptionDisableCmd)
+	encryptionCmd.AddCommand(encryptionKeygenCmd)
+
+	for _, c := range []*cobra.Command{encryptionEnableCmd, encryptionRotateCmd} {
+		c.Flags().String(
+			flags.NewKeyFile.Name,
+			"",
+			"--new-key-file to encrypt with the key file, new passphrase is used otherwise")
+	}
+}
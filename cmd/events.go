@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Events shows stored changes of the workspace or of the given task",
+	Run:   withApp((*tracker.App).Events),
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+
+	eventsCmd.Flags().StringP(
+		flags.Format.Name,
+		flags.Format.Shorthand,
+		"",
+		"--format of the output, table or json with an event per line")
+}
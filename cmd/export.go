@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export writes tasks in the given format",
+	Run:   withApp((*tracker.App).Export),
+}
+
+var worklogCmd = &cobra.Command{
+	Use:   "worklog",
+	Short: "Worklog shows time spent per issue found in task titles",
+	Run:   withApp((*tracker.App).Worklog),
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(worklogCmd)
+
+	exportCmd.Flags().StringP(
+		flags.Format.Name,
+		flags.Format.Shorthand,
+		"",
+		"--format of the export, jira-worklog or ics")
+
+	exportCmd.Flags().StringP(
+		flags.Output.Name,
+		flags.Output.Shorthand,
+		"",
+		"--output file, stdout by default")
+
+	worklogCmd.Flags().StringP(
+		flags.Issue.Name,
+		flags.Issue.Shorthand,
+		"",
+		"--issue to show worklog of a single issue like PROJ-123")
+
+	for _, c := range []*cobra.Command{exportCmd, worklogCmd} {
+		c.Flags().StringP(
+			flags.Tag.Name,
+			flags.Tag.Shorthand,
+			"",
+			"--tag to filter by tag")
+	}
+
+	for _, c := range []*cobra.Command{listCmd, exportCmd, worklogCmd} {
+		c.Flags().StringP(
+			flags.From.Name,
+			flags.From.Shorthand,
+			"",
+			"--from to show sessions since the date, 2006-01-02 or RFC3339")
+
+		c.Flags().StringP(
+			flags.To.Name,
+			flags.To.Shorthand,
+			"",
+			"--to to show sessions till the date inclusive, 2006-01-02 or RFC3339")
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var gitHookCmd = &cobra.Command{
+	Use:   "git-hook",
+	Short: "Git hook records commits on the active session",
+}
+
+var gitHookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install adds post-commit hook to the current repository",
+	Run:   withApp((*tracker.App).GitHookInstall),
+}
+
+var gitHookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Uninstall removes post-commit hook from the current repository",
+	Run:   withApp((*tracker.App).GitHookUninstall),
+}
+
+var gitHookRecordCmd = &cobra.Command{
+	Use:    "record",
+	Short:  "Record attaches commit to the active session, called by the hook",
+	Hidden: true,
+	Run:    withApp((*tracker.App).GitHookRecord),
+}
+
+func init() {
+	rootCmd.AddCommand(gitHookCmd)
+
+	gitHookCmd.AddCommand(gitHookInstallCmd)
+	gitHookCmd.AddCommand(gitHookUninstallCmd)
+	gitHookCmd.AddCommand(gitHookRecordCmd)
+
+	startCmd.Flags().BoolP(
+		flags.Git.Name,
+		flags.Git.Shorthand,
+		false,
+		"--git to start task named after the current branch tagged with the repository")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import adds history tracked with other tools",
+}
+
+var importTimewarriorCmd = &cobra.Command{
+	Use:   "timewarrior",
+	Short: "Timewarrior imports data/*.data files or directories with them",
+	Run:   withApp((*tracker.App).ImportTimewarrior),
+}
+
+var importWatsonCmd = &cobra.Command{
+	Use:   "watson",
+	Short: "Watson imports frames file",
+	Run:   withApp((*tracker.App).ImportWatson),
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.AddCommand(importTimewarriorCmd)
+	importCmd.AddCommand(importWatsonCmd)
+
+	importCmd.PersistentFlags().BoolP(
+		flags.DryRun.Name,
+		flags.DryRun.Shorthand,
+		false,
+		"--dry-run to show what would be imported without saving")
+
+	importTimewarriorCmd.Flags().StringP(
+		flags.Timezone.Name,
+		flags.Timezone.Shorthand,
+		"",
+		"--timezone of timestamps without zone like Europe/Berlin, local by default")
+}
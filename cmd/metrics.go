@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Metrics prints tracked time of all workspaces for Prometheus",
+	Run:   withApp((*tracker.App).Metrics),
+}
+
+var metricsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve exposes metrics on /metrics for Prometheus to scrape",
+	Run:   serveMetrics,
+}
+
+func serveMetrics(cmd *cobra.Command, args []string) {
+	settings := loadSettings(cmd)
+	addr := cmd.Flags().Lookup(flags.Listen.Name).Value.String()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := tracker.ServeMetrics(ctx, func() (*tracker.App, error) { return openApp(settings) }, addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.AddCommand(metricsServeCmd)
+
+	metricsCmd.Flags().StringP(
+		flags.Format.Name,
+		flags.Format.Shorthand,
+		"",
+		"--format of metrics, prometheus or openmetrics")
+
+	metricsCmd.Flags().StringP(
+		flags.Output.Name,
+		flags.Output.Shorthand,
+		"",
+		"--output file for node_exporter textfile collector, stdout by default")
+
+	metricsServeCmd.Flags().String(
+		flags.Listen.Name,
+		"127.0.0.1:9714",
+		"--listen address")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:     "sessions",
+	Aliases: []string{"log"},
+	Short:   "Sessions shows every session of the task with notes",
+	Run:     withApp((*tracker.App).Sessions),
+}
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Annotate adds note to the current or past session",
+	Run:   withApp((*tracker.App).Annotate),
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search finds sessions by notes",
+	Run:   withApp((*tracker.App).Search),
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(annotateCmd)
+	rootCmd.AddCommand(searchCmd)
+
+	for _, c := range []*cobra.Command{startCmd, stopCmd, annotateCmd} {
+		c.Flags().StringP(
+			flags.Note.Name,
+			flags.Note.Shorthand,
+			"",
+			"--note to describe what was done in the session")
+	}
+
+	annotateCmd.Flags().IntP(
+		flags.Session.Name,
+		flags.Session.Shorthand,
+		0,
+		"--session to annotate past session by its number, the last one by default")
+
+	for _, c := range []*cobra.Command{sessionsCmd, searchCmd} {
+		c.Flags().StringP(
+			flags.Tag.Name,
+			flags.Tag.Shorthand,
+			"",
+			"--tag to filter by tag")
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var overtimeCmd = &cobra.Command{
+	Use:   "overtime",
+	Short: "Overtime shows balance of worked and expected hours and break violations",
+	Run:   withApp((*tracker.App).Overtime),
+}
+
+func init() {
+	rootCmd.AddCommand(overtimeCmd)
+
+	overtimeCmd.Flags().StringP(
+		flags.From.Name,
+		flags.From.Shorthand,
+		"",
+		"--from to count since the date, the first day of the month by default")
+
+	overtimeCmd.Flags().StringP(
+		flags.To.Name,
+		flags.To.Shorthand,
+		"",
+		"--to to count till the date inclusive, today by default")
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report shows tracked totals per task including archived ones",
+	Run:   withApp((*tracker.App).Report),
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringP(
+		flags.Tag.Name,
+		flags.Tag.Shorthand,
+		"",
+		"--tag to filter by tag")
+}
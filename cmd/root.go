@@ -1,55 +1,58 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"path"
 
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/Unheilbar/time_tracker/internal/entities"
 	"github.com/Unheilbar/time_tracker/internal/flags"
 	"github.com/Unheilbar/time_tracker/internal/repository"
 	"github.com/Unheilbar/time_tracker/internal/tracker"
 	"github.com/spf13/cobra"
 )
 
-var app *tracker.App = newApp()
-
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "time_tracker",
 	Short: "Time tracker allows you to track time you spend on your activities",
-	Run:   app.Root,
+	Run:   withApp((*tracker.App).Root),
 }
 
 var startCmd = &cobra.Command{
 	Aliases: []string{"add", "new", "create"},
 	Use:     "start",
 	Short:   "Start starts timer for task",
-	Run:     app.Start,
+	Run:     withApp((*tracker.App).Start),
 }
 
 var stopCmd = &cobra.Command{
 	Use:   "stop",
-	Short: "Stop stops timer for active task",
-	Run:   app.Stop,
+	Short: "Stop stops timer for active task or the given one",
+	Run:   withApp((*tracker.App).Stop),
 }
 
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls", "show"},
 	Short:   "List shows all active projects.",
-	Run:     app.List,
+	Run:     withApp((*tracker.App).List),
 }
 
 var resumeCmd = &cobra.Command{
 	Use:   "resume",
 	Short: "Runs last idled task",
-	Run:   app.Resume,
+	Run:   withApp((*tracker.App).Resume),
 }
 
 var removeCmd = &cobra.Command{
 	Use:     "remove",
 	Aliases: []string{"rm", "del", "delete"},
-	Short:   "Stops last activated task",
-	Run:     app.Remove,
+	Short:   "Remove moves task to the trash",
+	Run:     withApp((*tracker.App).Remove),
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -61,47 +64,125 @@ func Execute() {
 	}
 }
 
-var dataDir = ".time_tracker/badger"
-var logDir = ".time_tracker/logs"
+// scheduled are the tracking commands catching up with schedules before they
+// run, the others leave timers as they are. It's filled in init, as the
+// commands refer to withApp
+var scheduled map[*cobra.Command]bool
+
+// withApp resolves config, opens the storage and catches up with schedules
+// for scheduled commands before running the command. Webhooks are delivered
+// after it
+func withApp(run func(*tracker.App, *cobra.Command, []string)) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		app := newApp(cmd)
+		if scheduled[cmd] {
+			app.RunSchedules()
+		}
+		run(app, cmd, args)
+		app.Deliver(context.Background())
+	}
+}
 
-const (
-	envDataDir = "GO_TIME_TRACKER_DATA_PATH"
-	envLogDir  = "GO_TIME_TRACKER_LOG_PATH"
-)
+func loadSettings(cmd *cobra.Command) config.Settings {
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatal(err)
+	}
 
-func newApp() *tracker.App {
-	if val, ok := os.LookupEnv(envDataDir); ok {
-		dataDir = val
+	settings, err := cfg.Settings()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if val, ok := os.LookupEnv(envLogDir); ok {
-		logDir = val
+	if hint := cfg.MigrationHint(); hint != "" {
+		log.Print(hint)
 	}
 
-	db, err := repository.NewBadgerDB(dataDir)
+	return settings
+}
+
+func newApp(cmd *cobra.Command) *tracker.App {
+	app, err := openApp(loadSettings(cmd))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	repo := repository.NewRepo(db)
-	//repoJson := repository.NewFileBackend(path.Join(dataDir, "bd.json"))
+	return app
+}
+
+// openApp opens the storage of the settings
+func openApp(settings config.Settings) (*tracker.App, error) {
+	entities.SetTimeFormat(settings.TimeFormat)
+
+	if err := os.MkdirAll(settings.DataPath, 0774); err != nil {
+		return nil, err
+	}
+
+	var repo tracker.Repository
+	switch settings.Backend {
+	case config.BackendJSON:
+		repo = repository.NewFileBackend(path.Join(settings.DataPath, "bd.json"))
+	default:
+		db, err := repository.NewBadgerDB(settings.DataPath)
+		if err != nil {
+			return nil, err
+		}
+
+		repo = repository.NewRepo(db)
+	}
+
+	app, err := tracker.NewApp(repo, settings)
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
 
-	return tracker.NewApp(repo)
+	return app, nil
 }
 
 func init() {
+	rootCmd.PersistentFlags().String(
+		config.ProfileFlag,
+		"",
+		"--profile to use named settings profile from the config file")
+
+	for _, k := range config.Keys {
+		rootCmd.PersistentFlags().String(k.Flag(), "", fmt.Sprintf("--%s %s", k.Flag(), k.Usage))
+	}
+
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(stopCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(resumeCmd)
 
+	scheduled = map[*cobra.Command]bool{
+		rootCmd:   true,
+		startCmd:  true,
+		stopCmd:   true,
+		listCmd:   true,
+		resumeCmd: true,
+		removeCmd: true,
+	}
+
 	startCmd.Flags().StringP(
 		flags.Tag.Name,
 		flags.Tag.Shorthand,
 		"",
 		"--tag to attach tag to the task")
 
+	startCmd.Flags().BoolP(
+		flags.Parallel.Name,
+		flags.Parallel.Shorthand,
+		false,
+		"--parallel to keep running tasks active")
+
+	stopCmd.Flags().BoolP(
+		flags.All.Name,
+		flags.All.Shorthand,
+		false,
+		"--all to stop all running tasks")
+
 	listCmd.Flags().StringP(
 		flags.Tag.Name,
 		flags.Tag.Shorthand,
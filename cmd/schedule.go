@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Schedule logs or starts recurring tasks like standups",
+	Run:   withApp((*tracker.App).ScheduleList),
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add schedules the task on every occurrence of --cron",
+	Run:   withApp((*tracker.App).ScheduleAdd),
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List shows schedules of the workspace",
+	Run:     withApp((*tracker.App).ScheduleList),
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:     "rm",
+	Aliases: []string{"remove", "delete"},
+	Short:   "Rm deletes schedules by their ids",
+	Run:     withApp((*tracker.App).ScheduleRemove),
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+
+	scheduleAddCmd.Flags().String(
+		flags.Cron.Name,
+		"",
+		"--cron expression of the occurrences like \"0 10 * * 1-5\"")
+
+	scheduleAddCmd.Flags().String(
+		flags.Duration.Name,
+		"",
+		"--duration of the task like 15m")
+
+	scheduleAddCmd.Flags().String(
+		flags.Tag.Name,
+		"",
+		"--tag to attach tag to the task")
+
+	scheduleAddCmd.Flags().Bool(
+		flags.AutoStart.Name,
+		false,
+		"--start to start the task for --duration instead of logging it, unless another task runs")
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Stats shows streaks, session lengths, context switches and deep work",
+	Run:   withApp((*tracker.App).Stats),
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringP(
+		flags.From.Name,
+		flags.From.Shorthand,
+		"",
+		"--from to count sessions started since the date, 2006-01-02 or RFC3339")
+
+	statsCmd.Flags().StringP(
+		flags.To.Name,
+		flags.To.Shorthand,
+		"",
+		"--to to count sessions started till the date inclusive, 2006-01-02 or RFC3339")
+
+	statsCmd.Flags().String(
+		flags.Tag.Name,
+		"",
+		"--tag to filter by tag")
+
+	statsCmd.Flags().StringP(
+		flags.Format.Name,
+		flags.Format.Shorthand,
+		"",
+		"--format of the output, table or json")
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [remote]",
+	Short: "Sync exchanges tasks with other devices through git repository, remote defaults to sync_remote setting",
+	Run:   withApp((*tracker.App).Sync),
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
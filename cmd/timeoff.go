@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var timeOffCmd = &cobra.Command{
+	Use:   "timeoff",
+	Short: "Timeoff keeps personal days off and shows holidays",
+	Run:   withApp((*tracker.App).TimeOffList),
+}
+
+var timeOffAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add takes the date or the dates from the first till the last off",
+	Run:   withApp((*tracker.App).TimeOffAdd),
+}
+
+var timeOffListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List shows days off and holidays",
+	Run:     withApp((*tracker.App).TimeOffList),
+}
+
+var timeOffRemoveCmd = &cobra.Command{
+	Use:     "rm",
+	Aliases: []string{"remove", "delete"},
+	Short:   "Rm deletes days off by their ids",
+	Run:     withApp((*tracker.App).TimeOffRemove),
+}
+
+func init() {
+	rootCmd.AddCommand(timeOffCmd)
+
+	timeOffCmd.AddCommand(timeOffAddCmd)
+	timeOffCmd.AddCommand(timeOffListCmd)
+	timeOffCmd.AddCommand(timeOffRemoveCmd)
+
+	timeOffAddCmd.Flags().String(
+		flags.Kind.Name,
+		string(entities.PTO),
+		"--kind of the day off, pto or sick")
+
+	timeOffAddCmd.Flags().String(
+		flags.Hours.Name,
+		"",
+		"--hours to take a part of the day off, whole scheduled day by default")
+
+	timeOffAddCmd.Flags().String(
+		flags.Note.Name,
+		"",
+		"--note to describe the day off")
+
+	for _, c := range []*cobra.Command{timeOffCmd, timeOffListCmd} {
+		c.Flags().StringP(
+			flags.From.Name,
+			flags.From.Shorthand,
+			"",
+			"--from to show since the date, the current year by default")
+
+		c.Flags().StringP(
+			flags.To.Name,
+			flags.To.Shorthand,
+			"",
+			"--to to show till the date inclusive")
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:     "move",
+	Aliases: []string{"mv"},
+	Short:   "Move puts task with its subtasks under another parent",
+	Run:     withApp((*tracker.App).Move),
+}
+
+var renameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Rename changes title of the task keeping its sessions, tags and subtasks",
+	Run:   withApp((*tracker.App).Rename),
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+	rootCmd.AddCommand(renameCmd)
+
+	renameCmd.Flags().StringP(
+		flags.As.Name,
+		flags.As.Shorthand,
+		"",
+		"--as is the new title of the task")
+
+	for _, c := range []*cobra.Command{startCmd, moveCmd} {
+		c.Flags().StringP(
+			flags.Parent.Name,
+			flags.Parent.Shorthand,
+			"",
+			"--parent to make task a subtask, empty parent makes it a root task")
+	}
+
+	listCmd.Flags().BoolP(
+		flags.Tree.Name,
+		flags.Tree.Shorthand,
+		false,
+		"--tree to show subtasks under parents with rolled up time")
+
+	reportCmd.Flags().IntP(
+		flags.Depth.Name,
+		flags.Depth.Shorthand,
+		-1,
+		"--depth to collapse subtasks deeper than the level into parents, roots are level 0")
+
+	removeCmd.Flags().BoolP(
+		flags.Recursive.Name,
+		flags.Recursive.Shorthand,
+		false,
+		"--recursive to remove task with all its subtasks, otherwise they move to its parent")
+}
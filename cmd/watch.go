@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch reminds about forgotten timers and runs schedules, keep it running as a daemon",
+	Run:   watch,
+}
+
+func watch(cmd *cobra.Command, args []string) {
+	settings := loadSettings(cmd)
+
+	interval, err := cmd.Flags().GetDuration(flags.Interval.Name)
+	if err != nil || interval <= 0 {
+		log.Fatal("Provide positive --interval like 1m")
+	}
+
+	w, err := tracker.NewWatcher(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w.Watch(ctx, func() (*tracker.App, error) { return openApp(settings) }, interval)
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Duration(
+		flags.Interval.Name,
+		time.Minute,
+		"--interval between checks")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Webhooks shows deliveries waiting for retry",
+	Run:   withApp((*tracker.App).WebhooksQueue),
+}
+
+var webhooksTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Test sends a sample event to the given webhooks or to all of them",
+	Run:   withApp((*tracker.App).WebhooksTest),
+}
+
+var webhooksQueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Queue shows deliveries waiting for retry",
+	Run:   withApp((*tracker.App).WebhooksQueue),
+}
+
+func init() {
+	rootCmd.AddCommand(webhooksCmd)
+
+	webhooksCmd.AddCommand(webhooksTestCmd)
+	webhooksCmd.AddCommand(webhooksQueueCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/tracker"
+	"github.com/spf13/cobra"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:     "workspace",
+	Aliases: []string{"ws"},
+	Short:   "Workspace keeps tasks of different contexts apart",
+	Run:     withApp((*tracker.App).Workspaces),
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List shows all workspaces",
+	Run:     withApp((*tracker.App).Workspaces),
+}
+
+var workspaceCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create adds new empty workspace",
+	Run:   withApp((*tracker.App).WorkspaceCreate),
+}
+
+var workspaceUseCmd = &cobra.Command{
+	Use:   "use",
+	Short: "Use makes workspace current",
+	Run:   withApp((*tracker.App).WorkspaceUse),
+}
+
+var workspaceDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Aliases: []string{"rm"},
+	Short:   "Delete removes workspace with all its tasks",
+	Run:     withApp((*tracker.App).WorkspaceDelete),
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceCreateCmd)
+	workspaceCmd.AddCommand(workspaceUseCmd)
+	workspaceCmd.AddCommand(workspaceDeleteCmd)
+
+	reportCmd.Flags().BoolP(
+		flags.AllWorkspaces.Name,
+		flags.AllWorkspaces.Shorthand,
+		false,
+		"--all-workspaces to report tasks of every workspace")
+}
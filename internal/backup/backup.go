@@ -0,0 +1,442 @@
+// Package backup defines the documented JSON document with all tracked data.
+// Unlike the stored lists it keeps sessions instead of state snapshots, so
+// derived values like total durations are recounted on restore.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Version of the document. It's increased on every incompatible change
+const Version = 1
+
+// Document is the root of the backup
+type Document struct {
+	// Version of the document format, see Version
+	Version int `json:"version"`
+	// CreatedAt is the moment backup was taken
+	CreatedAt time.Time `json:"created_at"`
+	// CurrentWorkspace is the workspace used by default
+	CurrentWorkspace string      `json:"current_workspace"`
+	Workspaces       []Workspace `json:"workspaces"`
+}
+
+// Workspace keeps tasks and active state of a single workspace
+type Workspace struct {
+	Name string `json:"name"`
+	// CurrentActive is the title of the running task started without --parallel
+	CurrentActive string `json:"current_active,omitempty"`
+	// LastActive is the task resumed by resume command
+	LastActive string `json:"last_active,omitempty"`
+	// Parallel are titles of tasks running along with the current active one
+	Parallel []string `json:"parallel,omitempty"`
+	Tasks    []Task   `json:"tasks"`
+	// Trash keeps removed tasks which can still be restored
+	Trash []Task `json:"trash,omitempty"`
+}
+
+// Task is a single tracked task
+type Task struct {
+	Title    string    `json:"title"`
+	Created  time.Time `json:"created"`
+	Parent   string    `json:"parent,omitempty"`
+	Archived bool      `json:"archived,omitempty"`
+	// Removed is set for tasks in the trash
+	Removed  *time.Time `json:"removed,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	Issues   []string   `json:"issues,omitempty"`
+	Sessions []Session  `json:"sessions"`
+}
+
+// Session is the tracked interval. Only the last session of a task may
+// miss End, that means the task is running
+type Session struct {
+	Start   time.Time  `json:"start"`
+	End     *time.Time `json:"end,omitempty"`
+	Notes   []string   `json:"notes,omitempty"`
+	Commits []Commit   `json:"commits,omitempty"`
+	// Parallel is set for sessions started along the running ones
+	Parallel bool `json:"parallel,omitempty"`
+}
+
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject,omitempty"`
+	Repo    string `json:"repo,omitempty"`
+}
+
+// NewDocument creates backup of the lists by workspace name
+func NewDocument(current string, workspaces []string, lists map[string]*entities.EntriesLists) *Document {
+	doc := &Document{
+		Version:          Version,
+		CreatedAt:        time.Now(),
+		CurrentWorkspace: current,
+	}
+
+	for _, name := range workspaces {
+		elist := lists[name]
+		ws := Workspace{
+			Name:          name,
+			CurrentActive: string(elist.CurrentActive),
+			LastActive:    string(elist.LastActive),
+			Tasks:         []Task{},
+		}
+		for _, title := range elist.Parallel {
+			ws.Parallel = append(ws.Parallel, string(title))
+		}
+
+		for _, l := range sortedLists(elist.Lists()) {
+			ws.Tasks = append(ws.Tasks, newTask(l))
+		}
+		for _, l := range sortedLists(elist.TrashLists()) {
+			ws.Trash = append(ws.Trash, newTask(l))
+		}
+
+		doc.Workspaces = append(doc.Workspaces, ws)
+	}
+
+	return doc
+}
+
+func newTask(l *entities.List) Task {
+	t := Task{
+		Title:    string(l.Title),
+		Created:  l.Created,
+		Parent:   string(l.Parent),
+		Archived: l.Archived,
+		Issues:   l.Issues,
+		Sessions: []Session{},
+	}
+
+	if !l.Removed.IsZero() {
+		removed := l.Removed
+		t.Removed = &removed
+	}
+
+	for _, tag := range l.Tags {
+		t.Tags = append(t.Tags, string(tag))
+	}
+
+	for _, s := range l.Sessions() {
+		session := Session{Start: s.Start, Notes: s.Notes, Parallel: s.Parallel}
+		if !s.Running() {
+			end := s.End
+			session.End = &end
+		}
+		for _, c := range s.Commits {
+			session.Commits = append(session.Commits, Commit(c))
+		}
+		t.Sessions = append(t.Sessions, session)
+	}
+
+	return t
+}
+
+// Write encodes the document, gzipped if asked
+func (doc *Document) Write(w io.Writer, gzipped bool) error {
+	if !gzipped {
+		return doc.encode(w)
+	}
+
+	// gzip footer is written on close
+	gz := gzip.NewWriter(w)
+	if err := doc.encode(gz); err != nil {
+		gz.Close()
+		return err
+	}
+
+	return gz.Close()
+}
+
+func (doc *Document) encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+// Read decodes the document detecting gzip by its magic bytes. The document
+// is validated
+func Read(r io.Reader) (*Document, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	doc := &Document{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(doc); err != nil {
+		return nil, fmt.Errorf("failed to decode backup: %w", err)
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Validate checks the document is consistent and can be restored
+func (doc *Document) Validate() error {
+	if doc.Version < 1 || doc.Version > Version {
+		return fmt.Errorf("unsupported backup version %d, supported up to %d", doc.Version, Version)
+	}
+
+	registry := entities.InitWorkspaces()
+	registry.Names = nil
+	for _, ws := range doc.Workspaces {
+		if ws.Name != entities.DefaultWorkspace {
+			if err := registry.Create(ws.Name); err != nil {
+				return fmt.Errorf("workspace %q: %w", ws.Name, err)
+			}
+		} else if registry.Has(ws.Name) {
+			return fmt.Errorf("workspace %q: duplicated", ws.Name)
+		} else {
+			registry.Names = append(registry.Names, ws.Name)
+		}
+
+		if err := ws.validate(); err != nil {
+			return fmt.Errorf("workspace %q: %w", ws.Name, err)
+		}
+	}
+
+	if doc.CurrentWorkspace != "" && !registry.Has(doc.CurrentWorkspace) {
+		return fmt.Errorf("current workspace %q isn't in the backup", doc.CurrentWorkspace)
+	}
+
+	return nil
+}
+
+func (ws Workspace) validate() error {
+	titles := make(map[string]bool)
+	running := make(map[string]bool)
+
+	for _, t := range ws.Tasks {
+		if titles[t.Title] {
+			return fmt.Errorf("task %q: duplicated", t.Title)
+		}
+		titles[t.Title] = true
+
+		if t.Removed != nil {
+			return fmt.Errorf("task %q: removed task should be in trash", t.Title)
+		}
+
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("task %q: %w", t.Title, err)
+		}
+
+		if n := len(t.Sessions); n > 0 && t.Sessions[n-1].End == nil {
+			running[t.Title] = true
+		}
+	}
+
+	for _, t := range ws.Tasks {
+		if t.Parent != "" && !titles[t.Parent] {
+			return fmt.Errorf("task %q: parent %q doesn't exist", t.Title, t.Parent)
+		}
+	}
+
+	trashed := make(map[string]bool)
+	for _, t := range ws.Trash {
+		if trashed[t.Title] {
+			return fmt.Errorf("trashed task %q: duplicated", t.Title)
+		}
+		trashed[t.Title] = true
+
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("trashed task %q: %w", t.Title, err)
+		}
+
+		if n := len(t.Sessions); n > 0 && t.Sessions[n-1].End == nil {
+			return fmt.Errorf("trashed task %q: is running", t.Title)
+		}
+	}
+
+	active := make(map[string]bool)
+	for _, title := range append([]string{ws.CurrentActive}, ws.Parallel...) {
+		if title == "" {
+			continue
+		}
+		if !running[title] {
+			return fmt.Errorf("active task %q isn't running", title)
+		}
+		if active[title] {
+			return fmt.Errorf("active task %q: duplicated", title)
+		}
+		active[title] = true
+	}
+
+	for title := range running {
+		if !active[title] {
+			return fmt.Errorf("task %q is running but isn't active", title)
+		}
+	}
+
+	if ws.LastActive != "" && !titles[ws.LastActive] {
+		return fmt.Errorf("last active task %q doesn't exist", ws.LastActive)
+	}
+
+	return nil
+}
+
+func (t Task) validate() error {
+	if strings.TrimSpace(t.Title) == "" {
+		return errors.New("empty title")
+	}
+
+	for _, tag := range t.Tags {
+		if !strings.HasPrefix(tag, "#") || strings.ContainsAny(tag, " \t\n") {
+			return fmt.Errorf("wrong tag %q", tag)
+		}
+	}
+
+	for i, s := range t.Sessions {
+		if s.End == nil && i != len(t.Sessions)-1 {
+			return fmt.Errorf("session %d: only the last session may be running", i+1)
+		}
+
+		if s.End != nil && !s.End.After(s.Start) {
+			return fmt.Errorf("session %d: ends before start", i+1)
+		}
+
+		if i > 0 && s.Start.Before(*t.Sessions[i-1].End) {
+			return fmt.Errorf("session %d: overlaps previous session", i+1)
+		}
+	}
+
+	return nil
+}
+
+// Lists converts the workspace back to the lists
+func (ws Workspace) Lists() *entities.EntriesLists {
+	elist := entities.InitEmptyElist()
+	elist.CurrentActive = entities.ListTitle(ws.CurrentActive)
+	elist.LastActive = entities.ListTitle(ws.LastActive)
+	for _, title := range ws.Parallel {
+		elist.Parallel = append(elist.Parallel, entities.ListTitle(title))
+	}
+
+	for _, t := range ws.Tasks {
+		l := t.list()
+		elist.EntriesListsView[l.Title] = l
+	}
+
+	for _, t := range ws.Trash {
+		l := t.list()
+		elist.Trash[l.Title] = l
+	}
+
+	elist.ReindexTags()
+
+	return elist
+}
+
+func (t Task) list() *entities.List {
+	l := &entities.List{
+		Title:    entities.ListTitle(t.Title),
+		Created:  t.Created,
+		Parent:   entities.ListTitle(t.Parent),
+		Archived: t.Archived,
+		Issues:   t.Issues,
+	}
+
+	if t.Removed != nil {
+		l.Removed = *t.Removed
+	}
+
+	for _, tag := range t.Tags {
+		l.Tags = append(l.Tags, entities.Tag(tag))
+	}
+
+	l.SetSessions(t.sessions(l.Title))
+
+	return l
+}
+
+func (t Task) sessions(title entities.ListTitle) []entities.Session {
+	var sessions []entities.Session
+	for _, s := range t.Sessions {
+		session := entities.Session{Title: title, Start: s.Start, Notes: s.Notes, Parallel: s.Parallel}
+		if s.End != nil {
+			session.End = *s.End
+		}
+		for _, c := range s.Commits {
+			session.Commits = append(session.Commits, entities.Commit(c))
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
+// Merge adds tasks, tags and finished sessions of the workspace to the lists.
+// Sessions overlapping tracked ones are skipped, the active state of the lists
+// is kept. It returns the number of added sessions
+func (ws Workspace) Merge(elist *entities.EntriesLists) int {
+	var added int
+
+	for _, t := range ws.Tasks {
+		title := entities.ListTitle(t.Title)
+
+		l, exists := elist.EntriesListsView[title]
+		if !exists {
+			l = elist.AddList(title)
+			l.Created = t.Created
+			l.Archived = t.Archived
+			l.Issues = t.Issues
+		}
+
+		for _, s := range t.sessions(title) {
+			if s.Running() {
+				continue
+			}
+			if elist.InsertSession(s) == nil {
+				added++
+			}
+		}
+
+		for _, tag := range t.Tags {
+			elist.AddTag(entities.Tag(tag), title)
+		}
+	}
+
+	// parents are set once all the tasks exist, links making a cycle with
+	// local ones are dropped
+	for _, t := range ws.Tasks {
+		l := elist.EntriesListsView[entities.ListTitle(t.Title)]
+		if l.Parent != "" || t.Parent == "" {
+			continue
+		}
+		_ = elist.SetParent(l.Title, entities.ListTitle(t.Parent))
+	}
+
+	return added
+}
+
+func sortedLists(lists []*entities.List) []*entities.List {
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Title < lists[j].Title
+	})
+
+	return lists
+}
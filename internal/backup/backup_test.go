@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLists(t *testing.T) *entities.EntriesLists {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", start, start.Add(time.Hour), []string{"review"}))
+	assert.NoError(t, elist.AddSession("api", start.Add(2*time.Hour), start.Add(3*time.Hour), nil))
+	elist.AddTag("#work", "api")
+	elist.AddList("docs")
+	assert.NoError(t, elist.SetParent("docs", "api"))
+	elist.InsertEntry("docs", entities.StatusActive)
+
+	return elist
+}
+
+func Test_RoundTrip(t *testing.T) {
+	elist := testLists(t)
+	elist.InsertParallel("lint")
+	elist.InsertEntry("lint", entities.StatusStop)
+	elist.InsertParallel("lint")
+	lists := map[string]*entities.EntriesLists{entities.DefaultWorkspace: elist}
+	doc := NewDocument(entities.DefaultWorkspace, []string{entities.DefaultWorkspace}, lists)
+
+	for _, gzipped := range []bool{false, true} {
+		var buf bytes.Buffer
+		assert.NoError(t, doc.Write(&buf, gzipped))
+
+		read, err := Read(&buf)
+		assert.NoError(t, err)
+
+		restored := read.Workspaces[0].Lists()
+		assert.Equal(t, entities.ListTitle("docs"), restored.CurrentActive)
+		assert.Equal(t, 2*time.Hour, restored.EntriesListsView["api"].Total())
+		assert.Equal(t, entities.ListTitle("api"), restored.EntriesListsView["docs"].Parent)
+		assert.Equal(t, []string{"review"}, restored.EntriesListsView["api"].Notes())
+		assert.Equal(t, 1, len(restored.Filter([]entities.Tag{"#work"}, entities.ContainsAll)))
+
+		// past parallel sessions aren't taken for switches
+		assert.Equal(t, []entities.ListTitle{"lint"}, restored.Parallel)
+		lint := restored.EntriesListsView["lint"].Sessions()
+		assert.Equal(t, 2, len(lint))
+		assert.True(t, lint[0].Parallel)
+		assert.True(t, lint[1].Parallel)
+		assert.False(t, restored.EntriesListsView["docs"].Sessions()[0].Parallel)
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	cases := map[string]string{
+		"version":   `{"version": 2, "workspaces": []}`,
+		"workspace": `{"version": 1, "workspaces": [{"name": "Bad Name", "tasks": []}]}`,
+		"overlap": `{"version": 1, "workspaces": [{"name": "default", "tasks": [{"title": "a", "sessions": [
+			{"start": "2024-01-01T10:00:00Z", "end": "2024-01-01T11:00:00Z"},
+			{"start": "2024-01-01T10:30:00Z", "end": "2024-01-01T12:00:00Z"}]}]}]}`,
+		"not active": `{"version": 1, "workspaces": [{"name": "default", "tasks": [{"title": "a", "sessions": [
+			{"start": "2024-01-01T10:00:00Z"}]}]}]}`,
+		"parent":  `{"version": 1, "workspaces": [{"name": "default", "tasks": [{"title": "a", "parent": "b", "sessions": []}]}]}`,
+		"unknown": `{"version": 1, "workspaces": [], "extra": true}`,
+	}
+
+	for name, data := range cases {
+		_, err := Read(strings.NewReader(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_Merge(t *testing.T) {
+	lists := map[string]*entities.EntriesLists{entities.DefaultWorkspace: testLists(t)}
+	doc := NewDocument(entities.DefaultWorkspace, []string{entities.DefaultWorkspace}, lists)
+
+	local := entities.InitEmptyElist()
+	start := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	assert.NoError(t, local.AddSession("api", start, start.Add(time.Hour), nil))
+
+	// the first session overlaps the local one, running session is skipped
+	added := doc.Workspaces[0].Merge(local)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 2*time.Hour, local.EntriesListsView["api"].Total())
+	assert.Equal(t, entities.ListTitle("api"), local.EntriesListsView["docs"].Parent)
+	assert.Equal(t, entities.ListTitle(""), local.CurrentActive)
+}
+
+func Test_MergeRoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	commits := []entities.Commit{{Hash: "4b825dc642cb6eb9a060e54bf8d69288fbee4904", Subject: "fix api", Repo: "api"}}
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.InsertSession(entities.Session{
+		Title: "api", Start: start, End: start.Add(time.Hour), Notes: []string{"review"}, Commits: commits,
+	}))
+	lists := map[string]*entities.EntriesLists{entities.DefaultWorkspace: elist}
+	doc := NewDocument(entities.DefaultWorkspace, []string{entities.DefaultWorkspace}, lists)
+
+	var buf bytes.Buffer
+	assert.NoError(t, doc.Write(&buf, true))
+	read, err := Read(&buf)
+	assert.NoError(t, err)
+
+	local := entities.InitEmptyElist()
+	assert.Equal(t, 1, read.Workspaces[0].Merge(local))
+
+	sessions := local.EntriesListsView["api"].Sessions()
+	assert.Equal(t, 1, len(sessions))
+	assert.Equal(t, []string{"review"}, sessions[0].Notes)
+	assert.Equal(t, commits, sessions[0].Commits)
+	assert.True(t, start.Add(time.Hour).Equal(sessions[0].End))
+}
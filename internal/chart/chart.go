@@ -0,0 +1,158 @@
+// Package chart draws tracked time in the terminal with Unicode blocks
+package chart
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Period is the time covered by a single bar
+type Period string
+
+const (
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+)
+
+// Unstacked is the stack of all the time when bars aren't stacked
+const Unstacked = ""
+
+// Options select time drawn by the chart
+type Options struct {
+	Period Period
+	// From and To bound the chart, To is exclusive
+	From, To  time.Time
+	WeekStart time.Weekday
+	// Stack returns the stack the list belongs to, nil puts all the time in
+	// a single stack
+	Stack func(*entities.List) string
+	// Now ends running sessions, its location sets day boundaries
+	Now time.Time
+}
+
+// Bucket is the time tracked within a day or a week split by stacks
+type Bucket struct {
+	Start  time.Time
+	Stacks map[string]time.Duration
+}
+
+// Total returns time of all the stacks
+func (b Bucket) Total() time.Duration {
+	var total time.Duration
+	for _, d := range b.Stacks {
+		total += d
+	}
+
+	return total
+}
+
+// Bars returns a bucket per period between From and To. Sessions crossing
+// midnight are split between days
+func Bars(lists []*entities.List, opts Options) []Bucket {
+	loc := opts.Now.Location()
+
+	var buckets []Bucket
+	index := make(map[time.Time]int)
+	for start := PeriodStart(opts.From.In(loc), opts.Period, opts.WeekStart); start.Before(opts.To); start = next(start, opts.Period) {
+		index[start] = len(buckets)
+		buckets = append(buckets, Bucket{Start: start, Stacks: make(map[string]time.Duration)})
+	}
+
+	for _, l := range lists {
+		stack := Unstacked
+		if opts.Stack != nil {
+			stack = opts.Stack(l)
+		}
+
+		for _, s := range l.Sessions() {
+			end := s.End
+			if s.Running() {
+				end = opts.Now
+			}
+
+			for day, d := range splitDays(s.Start.In(loc), end.In(loc), opts.From, opts.To) {
+				i, ok := index[PeriodStart(day, opts.Period, opts.WeekStart)]
+				if ok {
+					buckets[i].Stacks[stack] += d
+				}
+			}
+		}
+	}
+
+	return buckets
+}
+
+// Stacks returns names of the stacks ordered by their total time
+func Stacks(buckets []Bucket) []string {
+	totals := make(map[string]time.Duration)
+	for _, b := range buckets {
+		for stack, d := range b.Stacks {
+			totals[stack] += d
+		}
+	}
+
+	var stacks []string
+	for stack := range totals {
+		stacks = append(stacks, stack)
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		if totals[stacks[i]] != totals[stacks[j]] {
+			return totals[stacks[i]] > totals[stacks[j]]
+		}
+		return stacks[i] < stacks[j]
+	})
+
+	return stacks
+}
+
+// splitDays returns time of the interval within [from, to) per day start
+func splitDays(start, end, from, to time.Time) map[time.Time]time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if !to.IsZero() && end.After(to) {
+		end = to
+	}
+
+	res := make(map[time.Time]time.Duration)
+	for day := dayStart(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+		s, e := day, day.AddDate(0, 0, 1)
+		if s.Before(start) {
+			s = start
+		}
+		if e.After(end) {
+			e = end
+		}
+		if e.After(s) {
+			res[day] += e.Sub(s)
+		}
+	}
+
+	return res
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// PeriodStart returns the start of the day or the week of the moment
+func PeriodStart(t time.Time, period Period, weekStart time.Weekday) time.Time {
+	day := dayStart(t)
+	if period != PeriodWeek {
+		return day
+	}
+
+	shift := (int(day.Weekday()) - int(weekStart) + 7) % 7
+
+	return day.AddDate(0, 0, -shift)
+}
+
+func next(t time.Time, period Period) time.Time {
+	if period == PeriodWeek {
+		return t.AddDate(0, 0, 7)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
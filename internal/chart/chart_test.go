@@ -0,0 +1,88 @@
+package chart
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLists(t *testing.T) []*entities.List {
+	start := time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+
+	elist := entities.InitEmptyElist()
+	// crosses midnight of monday
+	assert.NoError(t, elist.AddSession("api", start, start.Add(4*time.Hour), nil))
+	assert.NoError(t, elist.AddSession("docs", start.AddDate(0, 0, 7), start.AddDate(0, 0, 7).Add(time.Hour), nil))
+	elist.AddTag("#work", "api")
+
+	return elist.Lists()
+}
+
+func Test_Bars(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := Options{
+		Period:    PeriodDay,
+		From:      from,
+		To:        from.AddDate(0, 0, 14),
+		WeekStart: time.Monday,
+		Now:       from.AddDate(0, 0, 14),
+	}
+
+	days := Bars(testLists(t), opts)
+	assert.Equal(t, 14, len(days))
+	assert.Equal(t, 2*time.Hour, days[0].Total())
+	assert.Equal(t, 2*time.Hour, days[1].Total())
+	assert.Equal(t, time.Hour, days[7].Total())
+
+	opts.Period = PeriodWeek
+	opts.Stack = func(l *entities.List) string {
+		if len(l.Tags) == 0 {
+			return "untagged"
+		}
+		return string(l.Tags[0])
+	}
+	weeks := Bars(testLists(t), opts)
+	assert.Equal(t, 2, len(weeks))
+	assert.Equal(t, 4*time.Hour, weeks[0].Stacks["#work"])
+	assert.Equal(t, time.Hour, weeks[1].Stacks["untagged"])
+	assert.Equal(t, []string{"#work", "untagged"}, Stacks(weeks))
+
+	// range cuts sessions
+	opts.Period, opts.From = PeriodDay, from.Add(23*time.Hour)
+	assert.Equal(t, 3*time.Hour, Bars(testLists(t), opts)[0].Total()+Bars(testLists(t), opts)[1].Total())
+}
+
+func Test_Render(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := Options{Period: PeriodDay, From: from, To: from.AddDate(0, 0, 3), Now: from.AddDate(0, 0, 3)}
+
+	var buf bytes.Buffer
+	assert.NoError(t, RenderBars(&buf, Bars(testLists(t), opts), PeriodDay, Style{Width: 40}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 4, len(lines))
+	for _, line := range lines[:3] {
+		assert.Equal(t, 40, utf8.RuneCountInString(line))
+	}
+	assert.Contains(t, lines[0], "██")
+	assert.NotContains(t, buf.String(), "\033")
+
+	buf.Reset()
+	opts.To, opts.Now = from.AddDate(1, 0, 0), from.AddDate(1, 0, 0)
+	assert.NoError(t, RenderHeatmap(&buf, Bars(testLists(t), opts), time.Monday, Style{Width: 30}))
+	lines = strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	// month labels, weekdays and legend
+	assert.Equal(t, 9, len(lines))
+	for _, line := range lines[1:8] {
+		assert.LessOrEqual(t, utf8.RuneCountInString(line), 30)
+	}
+	assert.Contains(t, buf.String(), "5.0h in 3 days")
+
+	assert.Equal(t, 0, level(0, time.Hour))
+	assert.Equal(t, 1, level(time.Minute, time.Hour))
+	assert.Equal(t, 4, level(time.Hour, time.Hour))
+}
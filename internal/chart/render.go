@@ -0,0 +1,254 @@
+package chart
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// Style describes the terminal the chart is drawn for
+type Style struct {
+	Width int
+	// Color enables ANSI colors, stacks and levels differ by shades otherwise
+	Color bool
+}
+
+const (
+	reset    = "\033[0m"
+	minWidth = 10
+	full     = '█'
+)
+
+// eighths are partial blocks of a bar end
+var eighths = []rune{' ', '▏', '▎', '▍', '▌', '▋', '▊', '▉'}
+
+// stackColors and stackShades tell stacks apart in color and without it
+var stackColors = []string{"\033[32m", "\033[34m", "\033[33m", "\033[35m", "\033[36m", "\033[31m", "\033[92m", "\033[94m"}
+var stackShades = []rune{'█', '▓', '▒', '░', '#', '=', '+', '*'}
+
+// heatColors and heatShades are levels of the heatmap from no time to most
+var heatColors = []string{"\033[38;5;237m", "\033[38;5;22m", "\033[38;5;28m", "\033[38;5;34m", "\033[38;5;40m"}
+var heatShades = []rune{'·', '░', '▒', '▓', '█'}
+
+// RenderBars draws a horizontal bar per bucket scaled to the longest one
+func RenderBars(w io.Writer, buckets []Bucket, period Period, style Style) error {
+	stacks := Stacks(buckets)
+
+	var max time.Duration
+	for _, b := range buckets {
+		if b.Total() > max {
+			max = b.Total()
+		}
+	}
+
+	layout := "Mon 2006-01-02"
+	if period == PeriodWeek {
+		layout = "Week 2006-01-02"
+	}
+	const valueWidth = 7
+	barWidth := style.Width - len(layout) - valueWidth - 2
+	if barWidth < minWidth {
+		barWidth = minWidth
+	}
+
+	var total time.Duration
+	for _, b := range buckets {
+		var bar string
+		if max > 0 {
+			scale := float64(barWidth) / float64(max)
+			bar = drawBar(b, stacks, scale, style)
+		}
+
+		total += b.Total()
+		cells := barWidth - visible(bar)
+		if _, err := fmt.Fprintf(w, "%s %s%s %*s\n", b.Start.Format(layout), bar, strings.Repeat(" ", cells), valueWidth, hours(b.Total())); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "Total %s\n", hours(total)); err != nil {
+		return err
+	}
+
+	if len(stacks) < 2 {
+		return nil
+	}
+
+	var legend []string
+	for i, stack := range stacks {
+		legend = append(legend, paint(string(shade(i, style)), i, style)+" "+stack)
+	}
+	_, err := fmt.Fprintln(w, strings.Join(legend, "  "))
+
+	return err
+}
+
+// drawBar draws stacks one after another. Single stack ends with a partial
+// block, so short bars still differ
+func drawBar(b Bucket, stacks []string, scale float64, style Style) string {
+	if len(stacks) == 1 {
+		cells := float64(b.Total()) * scale
+		whole := int(cells)
+		bar := strings.Repeat(string(full), whole)
+		if part := int((cells - float64(whole)) * 8); part > 0 {
+			bar += string(eighths[part])
+		}
+		return paint(bar, 0, style)
+	}
+
+	var bar strings.Builder
+	var done time.Duration
+	for i, stack := range stacks {
+		d := b.Stacks[stack]
+		if d == 0 {
+			continue
+		}
+
+		from := int(math.Round(float64(done) * scale))
+		done += d
+		to := int(math.Round(float64(done) * scale))
+		bar.WriteString(paint(strings.Repeat(string(shade(i, style)), to-from), i, style))
+	}
+
+	return bar.String()
+}
+
+func shade(i int, style Style) rune {
+	if style.Color {
+		return full
+	}
+
+	return stackShades[i%len(stackShades)]
+}
+
+func paint(s string, i int, style Style) string {
+	if !style.Color || s == "" {
+		return s
+	}
+
+	return stackColors[i%len(stackColors)] + s + reset
+}
+
+// visible returns the number of cells taken by the string without colors
+func visible(s string) int {
+	var n int
+	escaped := false
+	for _, r := range s {
+		switch {
+		case r == '\033':
+			escaped = true
+		case escaped:
+			escaped = r != 'm'
+		default:
+			n++
+		}
+	}
+
+	return n
+}
+
+// RenderHeatmap draws a column per week and a row per weekday. Weeks which
+// don't fit the width are dropped from the start
+func RenderHeatmap(w io.Writer, days []Bucket, weekStart time.Weekday, style Style) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	var max, total time.Duration
+	var tracked int
+	byDay := make(map[time.Time]time.Duration)
+	for _, day := range days {
+		d := day.Total()
+		byDay[day.Start] = d
+		total += d
+		if d > 0 {
+			tracked++
+		}
+		if d > max {
+			max = d
+		}
+	}
+
+	first := PeriodStart(days[0].Start, PeriodWeek, weekStart)
+	last := days[len(days)-1].Start
+	var weeks int
+	for week := first; !week.After(last); week = week.AddDate(0, 0, 7) {
+		weeks++
+	}
+
+	const labelWidth = 4
+	if fit := (style.Width - labelWidth) / 2; fit > 0 && weeks > fit {
+		first = first.AddDate(0, 0, 7*(weeks-fit))
+		weeks = fit
+	}
+
+	// month names are put over the weeks they start in
+	months := []rune(strings.Repeat(" ", labelWidth+2*weeks))
+	for i := 0; i < weeks; i++ {
+		week := first.AddDate(0, 0, 7*i)
+		for d := 0; d < 7; d++ {
+			day := week.AddDate(0, 0, d)
+			if day.Day() == 1 && labelWidth+2*i+3 <= len(months) {
+				copy(months[labelWidth+2*i:], []rune(day.Format("Jan")))
+			}
+		}
+	}
+	if _, err := fmt.Fprintln(w, strings.TrimRight(string(months), " ")); err != nil {
+		return err
+	}
+
+	for row := 0; row < 7; row++ {
+		weekday := first.AddDate(0, 0, row)
+		label := ""
+		if row%2 == 0 {
+			label = weekday.Format("Mon")
+		}
+
+		var line strings.Builder
+		line.WriteString(fmt.Sprintf("%-*s", labelWidth, label))
+		for i := 0; i < weeks; i++ {
+			day := weekday.AddDate(0, 0, 7*i)
+			d, ok := byDay[day]
+			if !ok {
+				line.WriteString("  ")
+				continue
+			}
+			line.WriteString(cell(level(d, max), style) + " ")
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line.String(), " ")); err != nil {
+			return err
+		}
+	}
+
+	var legend []string
+	for i := range heatShades {
+		legend = append(legend, cell(i, style))
+	}
+	_, err := fmt.Fprintf(w, "%*sLess %s More   %s in %d days\n", labelWidth, "", strings.Join(legend, " "), hours(total), tracked)
+
+	return err
+}
+
+// level splits time up to max into four levels, zero is for no time
+func level(d, max time.Duration) int {
+	if d <= 0 || max <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(4 * float64(d) / float64(max)))
+}
+
+func cell(level int, style Style) string {
+	if !style.Color {
+		return string(heatShades[level])
+	}
+
+	return heatColors[level] + "■" + reset
+}
+
+func hours(d time.Duration) string {
+	return fmt.Sprintf("%.1fh", d.Hours())
+}
@@ -0,0 +1,761 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/hooks"
+	"github.com/Unheilbar/time_tracker/internal/webhook"
+	"github.com/Unheilbar/time_tracker/internal/worktime"
+	"github.com/spf13/pflag"
+)
+
+const (
+	envPrefix  = "GO_TIME_TRACKER_"
+	envConfig  = envPrefix + "CONFIG"
+	envProfile = envPrefix + "PROFILE"
+
+	// PassphraseEnv unlocks encrypted data without a prompt
+	PassphraseEnv = envPrefix + "PASSPHRASE"
+	// NewPassphraseEnv sets passphrase when encryption is enabled or rotated
+	NewPassphraseEnv = envPrefix + "NEW_PASSPHRASE"
+
+	appDir = "time_tracker"
+
+	// DefaultProfile is used when no profile is selected
+	DefaultProfile = "default"
+
+	// ProfileFlag selects profile for a single run
+	ProfileFlag = "profile"
+)
+
+// Key describes a single setting. Setting can be passed with --<flag>,
+// GO_TIME_TRACKER_<NAME> env or in the config file, in order of precedence.
+type Key struct {
+	Name    string
+	Usage   string
+	Default func() string
+}
+
+func (k Key) Env() string {
+	return envPrefix + strings.ToUpper(k.Name)
+}
+
+func (k Key) Flag() string {
+	return strings.ReplaceAll(k.Name, "_", "-")
+}
+
+const (
+	DataPath    = "data_path"
+	Backend     = "backend"
+	TimeFormat  = "time_format"
+	WeekStart   = "week_start"
+	Rounding    = "rounding"
+	DefaultTags = "default_tags"
+	Workspace   = "workspace"
+	Overlap     = "overlap"
+	// IssuePatterns are regular expressions separated by spaces
+	IssuePatterns = "issue_patterns"
+	// KeyFile unlocks encrypted data instead of the passphrase
+	KeyFile = "key_file"
+	// SyncRemote is the git repository shared by devices
+	SyncRemote = "sync_remote"
+	SyncPath   = "sync_path"
+	// Device names the log of this device in the sync repository
+	Device = "device"
+	// DeepWork is the shortest session counted as deep work by stats
+	DeepWork = "deep_work"
+	// RemindIdle and RemindRunning are periods after which watch reminds
+	// that no task runs within work hours or that a task runs too long
+	RemindIdle    = "remind_idle"
+	RemindRunning = "remind_running"
+	// Notifiers are space separated names of reminder notifiers
+	Notifiers     = "notifiers"
+	NotifyCommand = "notify_command"
+	// HooksDir keeps executables run when timers change
+	HooksDir    = "hooks_dir"
+	HookTimeout = "hook_timeout"
+	HookFailure = "hook_failure"
+	// MetricsMaxTasks and MetricsMaxTags limit series of the metrics,
+	// MetricsTags are space separated tags exported by metrics
+	MetricsMaxTasks = "metrics_max_tasks"
+	MetricsMaxTags  = "metrics_max_tags"
+	MetricsTags     = "metrics_tags"
+)
+
+// WorkSection is the config section with the weekly schedule and break
+// rules, see worktime.Policy
+const WorkSection = "work"
+
+// HooksSection is the config section with hook commands, see hooks.Hook
+const HooksSection = "hooks"
+
+// WebhooksSection is the config section with webhook targets, see
+// webhook.Target
+const WebhooksSection = "webhooks"
+
+const (
+	BackendBadger = "badger"
+	BackendJSON   = "json"
+)
+
+const (
+	// OverlapDouble counts time of parallel tasks in full for each of them
+	OverlapDouble = "double"
+	// OverlapSplit divides time of parallel tasks equally between them
+	OverlapSplit = "split"
+)
+
+// Keys lists all the known settings
+var Keys = []Key{
+	{
+		Name:    DataPath,
+		Usage:   "directory with the database",
+		Default: defaultDataPath,
+	},
+	{
+		Name:    Backend,
+		Usage:   "storage backend, badger or json",
+		Default: func() string { return BackendBadger },
+	},
+	{
+		Name:    TimeFormat,
+		Usage:   "go layout used to print timestamps",
+		Default: func() string { return "2006-01-02 \n 15:04:05" },
+	},
+	{
+		Name:    WeekStart,
+		Usage:   "first day of the week",
+		Default: func() string { return "monday" },
+	},
+	{
+		Name:    Rounding,
+		Usage:   "report durations are rounded up to this step, 0s disables rounding",
+		Default: func() string { return "0s" },
+	},
+	{
+		Name:    DefaultTags,
+		Usage:   "tags attached to started task when --tag is omitted",
+		Default: func() string { return "" },
+	},
+	{
+		Name:    Workspace,
+		Usage:   "workspace used instead of the current one",
+		Default: func() string { return "" },
+	},
+	{
+		Name:    Overlap,
+		Usage:   "how reports count time of parallel tasks, double or split",
+		Default: func() string { return OverlapDouble },
+	},
+	{
+		Name:    IssuePatterns,
+		Usage:   "space separated regular expressions of issue keys in task titles",
+		Default: func() string { return `[A-Z][A-Z0-9]+-[0-9]+ #[0-9]+` },
+	},
+	{
+		Name:    KeyFile,
+		Usage:   "file with the key of encrypted data, passphrase is used when empty",
+		Default: func() string { return "" },
+	},
+	{
+		Name:    SyncRemote,
+		Usage:   "git repository used to sync devices",
+		Default: func() string { return "" },
+	},
+	{
+		Name:    SyncPath,
+		Usage:   "directory with the clone of the sync repository",
+		Default: func() string { return filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), appDir, "sync") },
+	},
+	{
+		Name:  Device,
+		Usage: "name of this device in the sync repository",
+		Default: func() string {
+			host, err := os.Hostname()
+			if err != nil || host == "" {
+				return "device"
+			}
+			return host
+		},
+	},
+	{
+		Name:    DeepWork,
+		Usage:   "shortest session counted as deep work by stats",
+		Default: func() string { return "90m" },
+	},
+	{
+		Name:    RemindIdle,
+		Usage:   "remind when no task runs this long within work hours, 0s disables",
+		Default: func() string { return "15m" },
+	},
+	{
+		Name:    RemindRunning,
+		Usage:   "remind when a task runs this long, 0s disables",
+		Default: func() string { return "2h" },
+	},
+	{
+		Name:    Notifiers,
+		Usage:   "space separated reminder notifiers, bell, notify-send or command",
+		Default: func() string { return "bell" },
+	},
+	{
+		Name:    NotifyCommand,
+		Usage:   "shell command run by the command notifier",
+		Default: func() string { return "" },
+	},
+	{
+		Name:    HooksDir,
+		Usage:   "directory with executables run on start, stop, switch, resume and remove",
+		Default: func() string { return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), appDir, "hooks") },
+	},
+	{
+		Name:    HookTimeout,
+		Usage:   "hooks running longer are killed and count as failed",
+		Default: func() string { return "10s" },
+	},
+	{
+		Name:    HookFailure,
+		Usage:   "what to do when a hook fails, ignore, warn or abort the change",
+		Default: func() string { return string(hooks.Warn) },
+	},
+	{
+		Name:    MetricsMaxTasks,
+		Usage:   "tasks with own metrics labels, the others are summed up as other, 0 disables the limit",
+		Default: func() string { return "50" },
+	},
+	{
+		Name:    MetricsMaxTags,
+		Usage:   "tags exported by metrics, the ones tracked less are dropped, 0 disables the limit",
+		Default: func() string { return "20" },
+	},
+	{
+		Name:    MetricsTags,
+		Usage:   "space separated tags exported by metrics, all tags when empty",
+		Default: func() string { return "" },
+	},
+}
+
+// LookupKey returns setting description by its name
+func LookupKey(name string) (Key, bool) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+
+	return Key{}, false
+}
+
+// Profile keeps settings by their names. Values of known keys are strings,
+// other sections are decoded by their consumers.
+type Profile map[string]json.RawMessage
+
+// File is the content of the config file
+type File struct {
+	// Profile used when --profile isn't passed
+	Profile  string             `json:"profile,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// defaultDataPath is the XDG data directory. The database of versions before
+// the config file is used instead while the XDG one doesn't exist
+func defaultDataPath() string {
+	path := filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), appDir, "badger")
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	if legacy := LegacyDataPath(); legacy != "" {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+
+	return path
+}
+
+// LegacyDataPath is the database of versions before the config file in the
+// home directory, empty when home is unknown
+func LegacyDataPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".time_tracker", "badger")
+}
+
+// MigrationHint tells how to move the database of the old version used by
+// default to the XDG data directory, empty when there is nothing to move
+func (c *Config) MigrationHint() string {
+	v := c.values[DataPath]
+	if v.Source != SourceDefault || v.Value != LegacyDataPath() {
+		return ""
+	}
+
+	path := filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), appDir, "badger")
+
+	return fmt.Sprintf("Using the database of the old version at %s. Move it to %s or set %s to keep it there",
+		v.Value, path, DataPath)
+}
+
+// Path returns path of the config file following XDG base directory spec
+func Path() string {
+	if val, ok := os.LookupEnv(envConfig); ok {
+		return val
+	}
+
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), appDir, "config.json")
+}
+
+// ReadFile reads config file. Missing file is treated as empty one
+func ReadFile(path string) (*File, error) {
+	f := &File{Profiles: make(map[string]Profile)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]Profile)
+	}
+
+	return f, nil
+}
+
+// Write saves config file creating its directory
+func (f *File) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0774); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Set stores the value of the key for the profile
+func (f *File) Set(profile, key, value string) error {
+	if _, ok := LookupKey(key); !ok {
+		return fmt.Errorf("unknown key %s", key)
+	}
+
+	if err := Validate(key, value); err != nil {
+		return err
+	}
+
+	enc, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if f.Profiles[profile] == nil {
+		f.Profiles[profile] = make(Profile)
+	}
+	f.Profiles[profile][key] = enc
+
+	return nil
+}
+
+// lookup returns raw value from the profile falling back to the default profile
+func (f *File) lookup(profile, key string) (json.RawMessage, bool) {
+	if raw, ok := f.Profiles[profile][key]; ok {
+		return raw, true
+	}
+
+	raw, ok := f.Profiles[DefaultProfile][key]
+	return raw, ok
+}
+
+type Source string
+
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Value is the resolved setting
+type Value struct {
+	Key    string
+	Value  string
+	Source Source
+}
+
+// Config is the set of settings resolved for the selected profile
+type Config struct {
+	Profile string
+	Path    string
+	File    *File
+	values  map[string]Value
+}
+
+// Load resolves settings with precedence flag > env > file > default.
+// Flags are looked up by Key.Flag names in fs, fs may be nil.
+func Load(fs *pflag.FlagSet) (*Config, error) {
+	path := Path()
+	f, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{
+		Path:    path,
+		File:    f,
+		Profile: DefaultProfile,
+		values:  make(map[string]Value),
+	}
+
+	if f.Profile != "" {
+		c.Profile = f.Profile
+	}
+	if val, ok := os.LookupEnv(envProfile); ok {
+		c.Profile = val
+	}
+	if flag := lookupFlag(fs, ProfileFlag); flag != nil {
+		c.Profile = flag.Value.String()
+	}
+
+	for _, k := range Keys {
+		v := Value{Key: k.Name, Value: k.Default(), Source: SourceDefault}
+
+		if raw, ok := f.lookup(c.Profile, k.Name); ok {
+			if err := json.Unmarshal(raw, &v.Value); err != nil {
+				return nil, fmt.Errorf("wrong value of %s in config: %w", k.Name, err)
+			}
+			v.Source = SourceFile
+		}
+
+		if val, ok := os.LookupEnv(k.Env()); ok {
+			v.Value, v.Source = val, SourceEnv
+		}
+
+		if flag := lookupFlag(fs, k.Flag()); flag != nil {
+			v.Value, v.Source = flag.Value.String(), SourceFlag
+		}
+
+		c.values[k.Name] = v
+	}
+
+	return c, nil
+}
+
+// Get returns resolved value of the key
+func (c *Config) Get(key string) (Value, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// Values returns all resolved settings in order of Keys
+func (c *Config) Values() []Value {
+	var res []Value
+	for _, k := range Keys {
+		res = append(res, c.values[k.Name])
+	}
+
+	return res
+}
+
+// Decode reads section which isn't a plain setting from the file into v.
+// It reports false if the section is missing.
+func (c *Config) Decode(section string, v interface{}) (bool, error) {
+	raw, ok := c.File.lookup(c.Profile, section)
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("wrong section %s in config: %w", section, err)
+	}
+
+	return true, nil
+}
+
+// Settings are typed values used by the app
+type Settings struct {
+	Profile     string
+	DataPath    string
+	Backend     string
+	TimeFormat  string
+	WeekStart   time.Weekday
+	Rounding    time.Duration
+	DefaultTags string
+	Workspace   string
+	Overlap     string
+	KeyFile     string
+	SyncRemote  string
+	SyncPath    string
+	Device      string
+	DeepWork    time.Duration
+
+	RemindIdle    time.Duration
+	RemindRunning time.Duration
+	Notifiers     []string
+	NotifyCommand string
+
+	HooksDir    string
+	HookTimeout time.Duration
+	HookFailure hooks.Policy
+	Hooks       []hooks.Hook
+	Webhooks    []webhook.Target
+
+	MetricsMaxTasks int
+	MetricsMaxTags  int
+	MetricsTags     []string
+
+	IssuePatterns []*regexp.Regexp
+	Work          worktime.Policy
+}
+
+// Settings parses resolved values
+func (c *Config) Settings() (Settings, error) {
+	s := Settings{
+		Profile:     c.Profile,
+		DataPath:    c.values[DataPath].Value,
+		Backend:     c.values[Backend].Value,
+		TimeFormat:  c.values[TimeFormat].Value,
+		DefaultTags: c.values[DefaultTags].Value,
+		Workspace:   c.values[Workspace].Value,
+		Overlap:     c.values[Overlap].Value,
+		KeyFile:     c.values[KeyFile].Value,
+		SyncRemote:  c.values[SyncRemote].Value,
+		SyncPath:    c.values[SyncPath].Value,
+		Device:      c.values[Device].Value,
+
+		Notifiers:     strings.Fields(c.values[Notifiers].Value),
+		NotifyCommand: c.values[NotifyCommand].Value,
+
+		HooksDir:    c.values[HooksDir].Value,
+		HookFailure: hooks.Policy(c.values[HookFailure].Value),
+
+		MetricsTags: strings.Fields(c.values[MetricsTags].Value),
+	}
+
+	for _, v := range c.values {
+		if err := Validate(v.Key, v.Value); err != nil {
+			return s, fmt.Errorf("%s from %s: %w", v.Key, v.Source, err)
+		}
+	}
+
+	weekStart, err := ParseWeekday(c.values[WeekStart].Value)
+	if err != nil {
+		return s, err
+	}
+	s.WeekStart = weekStart
+
+	rounding, err := time.ParseDuration(c.values[Rounding].Value)
+	if err != nil {
+		return s, err
+	}
+	s.Rounding = rounding
+
+	deepWork, err := time.ParseDuration(c.values[DeepWork].Value)
+	if err != nil {
+		return s, err
+	}
+	s.DeepWork = deepWork
+
+	if s.RemindIdle, err = time.ParseDuration(c.values[RemindIdle].Value); err != nil {
+		return s, err
+	}
+	if s.RemindRunning, err = time.ParseDuration(c.values[RemindRunning].Value); err != nil {
+		return s, err
+	}
+	if s.HookTimeout, err = time.ParseDuration(c.values[HookTimeout].Value); err != nil {
+		return s, err
+	}
+	if s.MetricsMaxTasks, err = strconv.Atoi(c.values[MetricsMaxTasks].Value); err != nil {
+		return s, err
+	}
+	if s.MetricsMaxTags, err = strconv.Atoi(c.values[MetricsMaxTags].Value); err != nil {
+		return s, err
+	}
+
+	s.IssuePatterns, err = parsePatterns(c.values[IssuePatterns].Value)
+	if err != nil {
+		return s, err
+	}
+
+	s.Work = worktime.DefaultPolicy()
+	var work worktime.Policy
+	ok, err := c.Decode(WorkSection, &work)
+	if err != nil {
+		return s, err
+	}
+	if ok {
+		// configured schedule replaces the default one
+		if work.Schedule == nil {
+			work.Schedule = s.Work.Schedule
+		}
+		if work.DayStart == "" {
+			work.DayStart = s.Work.DayStart
+		}
+		if work.DayEnd == "" {
+			work.DayEnd = s.Work.DayEnd
+		}
+		if err := work.Validate(); err != nil {
+			return s, fmt.Errorf("%s section: %w", WorkSection, err)
+		}
+		s.Work = work
+	}
+
+	if _, err := c.Decode(HooksSection, &s.Hooks); err != nil {
+		return s, err
+	}
+	for _, h := range s.Hooks {
+		if err := h.Validate(); err != nil {
+			return s, fmt.Errorf("%s section: %w", HooksSection, err)
+		}
+	}
+
+	if _, err := c.Decode(WebhooksSection, &s.Webhooks); err != nil {
+		return s, err
+	}
+	names := make(map[string]bool)
+	for _, t := range s.Webhooks {
+		if err := t.Validate(); err != nil {
+			return s, fmt.Errorf("%s section: %w", WebhooksSection, err)
+		}
+		if names[t.Name] {
+			return s, fmt.Errorf("%s section: webhook %s is set twice", WebhooksSection, t.Name)
+		}
+		names[t.Name] = true
+	}
+
+	return s, nil
+}
+
+func parsePatterns(value string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range strings.Fields(value) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+
+	return res, nil
+}
+
+// Validate checks that the value can be used for the key
+func Validate(key, value string) error {
+	switch key {
+	case Backend:
+		if value != BackendBadger && value != BackendJSON {
+			return fmt.Errorf("unknown backend %s", value)
+		}
+	case Overlap:
+		if value != OverlapDouble && value != OverlapSplit {
+			return fmt.Errorf("unknown overlap mode %s", value)
+		}
+	case WeekStart:
+		_, err := ParseWeekday(value)
+		return err
+	case Rounding, RemindIdle, RemindRunning:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if d < 0 {
+			return fmt.Errorf("%s can't be negative", key)
+		}
+	case HookTimeout:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return errors.New("hook timeout must be positive")
+		}
+	case MetricsMaxTasks, MetricsMaxTags:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("%s can't be negative", key)
+		}
+	case HookFailure:
+		_, err := hooks.ParsePolicy(value)
+		return err
+	case DeepWork:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return errors.New("deep work threshold must be positive")
+		}
+	case IssuePatterns:
+		_, err := parsePatterns(value)
+		return err
+	case Device:
+		if value == "" || strings.ContainsAny(value, `/\`) || strings.HasPrefix(value, ".") {
+			return fmt.Errorf("device %q can't be used as a file name", value)
+		}
+	case DataPath, TimeFormat, SyncPath:
+		if value == "" {
+			return fmt.Errorf("%s can't be empty", key)
+		}
+	}
+
+	return nil
+}
+
+// ParseWeekday parses english day name
+func ParseWeekday(day string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), day) {
+			return d, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("unknown weekday %s", day)
+}
+
+func lookupFlag(fs *pflag.FlagSet, name string) *pflag.Flag {
+	if fs == nil {
+		return nil
+	}
+
+	flag := fs.Lookup(name)
+	if flag == nil || !flag.Changed {
+		return nil
+	}
+
+	return flag
+}
+
+func xdgDir(env, fallback string) string {
+	if val, ok := os.LookupEnv(env); ok && val != "" {
+		return val
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+
+	return filepath.Join(home, fallback)
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/hooks"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(envConfig, path)
+
+	f, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Set(DefaultProfile, Rounding, "15m"))
+	assert.NoError(t, f.Set("work", WeekStart, "sunday"))
+	assert.NoError(t, f.Set("work", Backend, BackendJSON))
+	assert.Error(t, f.Set("work", Backend, "sqlite"))
+	assert.Error(t, f.Set("work", "unknown", "value"))
+	assert.NoError(t, f.Write(path))
+
+	t.Run("default profile reads file and defaults", func(t *testing.T) {
+		cfg, err := Load(nil)
+		assert.NoError(t, err)
+
+		s, err := cfg.Settings()
+		assert.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, s.Rounding)
+		assert.Equal(t, time.Monday, s.WeekStart)
+		assert.Equal(t, BackendBadger, s.Backend)
+	})
+
+	t.Run("flag beats env beats file", func(t *testing.T) {
+		t.Setenv(envProfile, "work")
+		t.Setenv(Key{Name: Backend}.Env(), BackendBadger)
+
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		fs.String(Key{Name: WeekStart}.Flag(), "", "")
+		assert.NoError(t, fs.Parse([]string{"--week-start", "friday"}))
+
+		cfg, err := Load(fs)
+		assert.NoError(t, err)
+		assert.Equal(t, "work", cfg.Profile)
+
+		s, err := cfg.Settings()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Friday, s.WeekStart)
+		assert.Equal(t, BackendBadger, s.Backend)
+		assert.Equal(t, 15*time.Minute, s.Rounding)
+
+		v, _ := cfg.Get(Backend)
+		assert.Equal(t, SourceEnv, v.Source)
+	})
+}
+
+func Test_LegacyDataPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv(envConfig, filepath.Join(home, "config.json"))
+	xdg := filepath.Join(home, "data", appDir, "badger")
+
+	cfg, err := Load(nil)
+	assert.NoError(t, err)
+	v, _ := cfg.Get(DataPath)
+	assert.Equal(t, xdg, v.Value)
+	assert.Empty(t, cfg.MigrationHint())
+
+	// the old database is kept in use till it's moved
+	assert.NoError(t, os.MkdirAll(LegacyDataPath(), 0774))
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	v, _ = cfg.Get(DataPath)
+	assert.Equal(t, filepath.Join(home, ".time_tracker", "badger"), v.Value)
+	assert.Contains(t, cfg.MigrationHint(), xdg)
+
+	t.Setenv(Key{Name: DataPath}.Env(), LegacyDataPath())
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.MigrationHint())
+
+	assert.NoError(t, os.MkdirAll(xdg, 0774))
+	os.Unsetenv(Key{Name: DataPath}.Env())
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	v, _ = cfg.Get(DataPath)
+	assert.Equal(t, xdg, v.Value)
+}
+
+func Test_WorkSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(envConfig, path)
+
+	cfg, err := Load(nil)
+	assert.NoError(t, err)
+	s, err := cfg.Settings()
+	assert.NoError(t, err)
+	assert.Equal(t, 8*time.Hour, s.Work.Expected(time.Monday))
+	assert.Equal(t, time.Duration(0), s.Work.Expected(time.Saturday))
+
+	f, err := ReadFile(path)
+	assert.NoError(t, err)
+	f.Profiles[DefaultProfile] = Profile{WorkSection: []byte(`{"schedule": {"Saturday": "4h"}, "breaks": [{"after": "6h", "min": "30m"}]}`)}
+	assert.NoError(t, f.Write(path))
+
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	s, err = cfg.Settings()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), s.Work.Expected(time.Monday))
+	assert.Equal(t, 4*time.Hour, s.Work.Expected(time.Saturday))
+	assert.Equal(t, 1, len(s.Work.Breaks))
+
+	f.Profiles[DefaultProfile] = Profile{WorkSection: []byte(`{"schedule": {"someday": "4h"}}`)}
+	assert.NoError(t, f.Write(path))
+
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	_, err = cfg.Settings()
+	assert.Error(t, err)
+}
+
+func Test_HooksSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(envConfig, path)
+
+	f, err := ReadFile(path)
+	assert.NoError(t, err)
+	f.Profiles[DefaultProfile] = Profile{HooksSection: []byte(`[{"events": ["start"], "command": "true", "on_failure": "abort"}]`)}
+	assert.NoError(t, f.Write(path))
+
+	cfg, err := Load(nil)
+	assert.NoError(t, err)
+	s, err := cfg.Settings()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(s.Hooks))
+	assert.Equal(t, hooks.Warn, s.HookFailure)
+	assert.Equal(t, 10*time.Second, s.HookTimeout)
+
+	f.Profiles[DefaultProfile] = Profile{HooksSection: []byte(`[{"events": ["pause"], "command": "true"}]`)}
+	assert.NoError(t, f.Write(path))
+
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	_, err = cfg.Settings()
+	assert.Error(t, err)
+
+	assert.Error(t, Validate(HookFailure, "retry"))
+	assert.Error(t, Validate(HookTimeout, "0s"))
+}
+
+func Test_WebhooksSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(envConfig, path)
+
+	f, err := ReadFile(path)
+	assert.NoError(t, err)
+	f.Profiles[DefaultProfile] = Profile{WebhooksSection: []byte(`[{"name": "team", "url": "https://example.com/hook", "secret": "s"}]`)}
+	assert.NoError(t, f.Write(path))
+
+	cfg, err := Load(nil)
+	assert.NoError(t, err)
+	s, err := cfg.Settings()
+	assert.NoError(t, err)
+	assert.Equal(t, "team", s.Webhooks[0].Name)
+
+	f.Profiles[DefaultProfile] = Profile{WebhooksSection: []byte(`[{"name": "team", "url": "https://a"}, {"name": "team", "url": "https://b"}]`)}
+	assert.NoError(t, f.Write(path))
+
+	cfg, err = Load(nil)
+	assert.NoError(t, err)
+	_, err = cfg.Settings()
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Next(t *testing.T) {
+	// 2024-01-05 is friday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	s, err := Parse("0 10 * * 1-5")
+	assert.NoError(t, err)
+	assert.Equal(t, at(5, 10, 0), s.Next(at(5, 9, 0)))
+	// weekend is skipped
+	assert.Equal(t, at(8, 10, 0), s.Next(at(5, 10, 0)))
+
+	s, err = Parse("*/15 9-10 * * mon,WED")
+	assert.NoError(t, err)
+	assert.Equal(t, at(8, 9, 0), s.Next(at(5, 12, 0)))
+	assert.Equal(t, at(8, 9, 15), s.Next(at(8, 9, 0)))
+	assert.Equal(t, at(10, 9, 0), s.Next(at(8, 10, 45)))
+
+	// either day matches when both are restricted
+	s, err = Parse("30 8 1 * 0")
+	assert.NoError(t, err)
+	assert.Equal(t, at(7, 8, 30), s.Next(at(2, 0, 0)))
+	assert.Equal(t, time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC), s.Next(at(28, 9, 0)))
+
+	s, err = Parse("0 0 29 feb 7")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC), s.Next(at(31, 0, 0)))
+
+	s, err = Parse("0 0 30 2 *")
+	assert.NoError(t, err)
+	assert.True(t, s.Next(at(1, 0, 0)).IsZero())
+
+	for _, spec := range []string{"0 10 * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "0 10 * foo *"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
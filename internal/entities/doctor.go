@@ -0,0 +1,352 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	CheckLists  = "lists"
+	CheckStates = "states"
+	CheckActive = "active"
+	CheckTags   = "tags"
+	CheckTree   = "tree"
+)
+
+// Problem is a broken invariant of the lists found by Doctor
+type Problem struct {
+	Check   string
+	Title   ListTitle
+	Message string
+	Fixed   bool
+}
+
+func (p Problem) AggregateRow() []interface{} {
+	var fixed string
+	if p.Fixed {
+		fixed = "yes"
+	}
+
+	return []interface{}{p.Check, titleAggregate(p.Title), p.Message, fixed}
+}
+
+// Doctor checks invariants the other methods rely on: every list has
+// alternating states with monotonic timestamps and durations, active titles
+// point to running lists, tag index matches tags of the lists and parents
+// exist. With fix the lists are repaired keeping as much tracked time as
+// possible, found problems are returned marked as fixed.
+func (elist *EntriesLists) Doctor(fix bool) []Problem {
+	d := &doctor{elist: elist, fix: fix}
+
+	d.checkLists()
+	d.checkStates()
+	d.checkActive()
+	d.checkTags()
+	d.checkTree()
+
+	return d.problems
+}
+
+type doctor struct {
+	elist    *EntriesLists
+	fix      bool
+	problems []Problem
+}
+
+func (d *doctor) report(check string, title ListTitle, format string, args ...interface{}) {
+	d.problems = append(d.problems, Problem{
+		Check:   check,
+		Title:   title,
+		Message: fmt.Sprintf(format, args...),
+		Fixed:   d.fix,
+	})
+}
+
+func (d *doctor) checkLists() {
+	for _, lists := range []map[ListTitle]*List{d.elist.EntriesListsView, d.elist.Trash} {
+		for _, title := range sortedTitles(lists) {
+			l := lists[title]
+			if l == nil {
+				d.report(CheckLists, title, "task is empty")
+				if d.fix {
+					delete(lists, title)
+				}
+				continue
+			}
+
+			if l.Title != title {
+				d.report(CheckLists, title, "task is stored as %q", l.Title)
+				if d.fix {
+					l.Title = title
+				}
+			}
+		}
+	}
+}
+
+func (d *doctor) checkStates() {
+	for _, lists := range []map[ListTitle]*List{d.elist.EntriesListsView, d.elist.Trash} {
+		for _, title := range sortedTitles(lists) {
+			if l := lists[title]; l != nil {
+				d.checkList(l)
+			}
+		}
+	}
+}
+
+// checkList walks states as safeAppend would have written them. Repeated
+// start continues the open session, stop without start is dropped as well
+// as sessions going back in time
+func (d *doctor) checkList(l *List) {
+	var (
+		sessions []Session
+		open     *Session
+		lastEnd  time.Time
+		total    time.Duration
+		dropping bool
+		broken   bool
+		totals   bool
+	)
+
+	problem := func(format string, args ...interface{}) {
+		broken = true
+		d.report(CheckStates, l.Title, format, args...)
+	}
+
+	checkTotal := func(i int, s *ListState) {
+		if s.TotalDuration != total && !totals {
+			totals = true
+			problem("state %d has total %s, tracked %s", i+1, s.TotalDuration, total)
+		}
+	}
+
+	for i, s := range l.States {
+		switch {
+		case s == nil:
+			problem("state %d is empty", i+1)
+
+		case s.Status == StatusActive && open != nil:
+			problem("state %d starts session which is already running", i+1)
+			open.Notes = append(open.Notes, s.Notes...)
+			open.Commits = append(open.Commits, s.Commits...)
+
+		case s.Status == StatusActive && s.Timestamp.Before(lastEnd):
+			problem("state %d starts before the previous session ends", i+1)
+			dropping = true
+
+		case s.Status == StatusActive:
+			checkTotal(i, s)
+			open = &Session{Title: l.Title, Start: s.Timestamp, Notes: s.Notes, Commits: s.Commits}
+			dropping = false
+
+		case s.Status != StatusStop:
+			problem("state %d has unknown status %d", i+1, s.Status)
+
+		case dropping:
+			dropping = false
+
+		case open == nil:
+			problem("state %d stops session which isn't running", i+1)
+
+		case s.Timestamp.Before(open.Start):
+			problem("state %d stops before the session starts", i+1)
+			open = nil
+
+		default:
+			open.End = s.Timestamp
+			total += open.Duration()
+			checkTotal(i, s)
+			sessions = append(sessions, *open)
+			lastEnd, open = s.Timestamp, nil
+		}
+	}
+
+	if open != nil {
+		sessions = append(sessions, *open)
+	}
+
+	if broken && d.fix {
+		l.SetSessions(sessions)
+	}
+}
+
+func (d *doctor) checkActive() {
+	elist := d.elist
+
+	running := func(title ListTitle) bool {
+		l, ok := elist.EntriesListsView[title]
+		return ok && l != nil && l.last().Status == StatusActive
+	}
+
+	if title := elist.CurrentActive; title != emptyTitle && !running(title) {
+		d.report(CheckActive, title, "current active task isn't running")
+		if d.fix {
+			elist.CurrentActive = emptyTitle
+		}
+	}
+
+	seen := map[ListTitle]bool{elist.CurrentActive: true}
+	var parallel []ListTitle
+	for _, title := range elist.Parallel {
+		switch {
+		case seen[title]:
+			d.report(CheckActive, title, "parallel task is already active")
+		case !running(title):
+			d.report(CheckActive, title, "parallel task isn't running")
+		default:
+			parallel = append(parallel, title)
+		}
+		seen[title] = true
+	}
+	if d.fix {
+		elist.Parallel = parallel
+	}
+
+	if title := elist.LastActive; title != emptyTitle {
+		if _, ok := elist.EntriesListsView[title]; !ok {
+			d.report(CheckActive, title, "last active task doesn't exist")
+			if d.fix {
+				elist.LastActive = emptyTitle
+			}
+		}
+	}
+
+	// running lists nobody points to keep running, nothing can stop them otherwise
+	for _, title := range sortedTitles(elist.EntriesListsView) {
+		if !running(title) || elist.IsActive(title) {
+			continue
+		}
+
+		d.report(CheckActive, title, "task is running but isn't active")
+		if !d.fix {
+			continue
+		}
+		if elist.CurrentActive == emptyTitle {
+			elist.CurrentActive = title
+		} else {
+			elist.Parallel = append(elist.Parallel, title)
+		}
+	}
+}
+
+func (d *doctor) checkTags() {
+	elist := d.elist
+	broken := false
+
+	for _, title := range sortedTitles(elist.EntriesListsView) {
+		l := elist.EntriesListsView[title]
+		if l == nil {
+			continue
+		}
+
+		seen := make(map[Tag]bool)
+		var tags []Tag
+		for _, tag := range l.Tags {
+			if seen[tag] {
+				d.report(CheckTags, title, "tag %s is repeated", tag)
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+
+			if !containsTitle(elist.Tags.View[tag], title) {
+				broken = true
+				d.report(CheckTags, title, "tag %s isn't indexed", tag)
+			}
+		}
+		if d.fix {
+			l.Tags = tags
+		}
+	}
+
+	var tags []Tag
+	for tag := range elist.Tags.View {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	for _, tag := range tags {
+		seen := make(map[ListTitle]bool)
+		for _, title := range elist.Tags.View[tag] {
+			l, ok := elist.EntriesListsView[title]
+			switch {
+			case seen[title]:
+				d.report(CheckTags, title, "tag %s is indexed twice", tag)
+			case !ok || l == nil:
+				d.report(CheckTags, title, "tag %s is indexed for missing task", tag)
+			case !containsTag(l.Tags, tag):
+				d.report(CheckTags, title, "tag %s is indexed but task doesn't have it", tag)
+			default:
+				seen[title] = true
+				continue
+			}
+			broken = true
+			seen[title] = true
+		}
+	}
+
+	if broken && d.fix {
+		elist.ReindexTags()
+	}
+}
+
+func (d *doctor) checkTree() {
+	elist := d.elist
+
+	for _, title := range sortedTitles(elist.EntriesListsView) {
+		l := elist.EntriesListsView[title]
+		if l == nil || l.Parent == emptyTitle {
+			continue
+		}
+
+		if _, ok := elist.EntriesListsView[l.Parent]; !ok {
+			d.report(CheckTree, title, "parent %s doesn't exist", l.Parent)
+			if d.fix {
+				l.Parent = emptyTitle
+			}
+			continue
+		}
+
+		// cycles above the list are reported by their members
+		for p, depth := l.Parent, 0; p != emptyTitle && depth <= len(elist.EntriesListsView); p, depth = elist.parentOf(p), depth+1 {
+			if p == title {
+				d.report(CheckTree, title, "parent %s makes a cycle", l.Parent)
+				if d.fix {
+					l.Parent = emptyTitle
+				}
+				break
+			}
+		}
+	}
+}
+
+func containsTitle(titles []ListTitle, title ListTitle) bool {
+	for _, t := range titles {
+		if t == title {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsTag(tags []Tag, tag Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedTitles(lists map[ListTitle]*List) []ListTitle {
+	var titles []ListTitle
+	for title := range lists {
+		titles = append(titles, title)
+	}
+	sort.Slice(titles, func(i, j int) bool { return titles[i] < titles[j] })
+
+	return titles
+}
@@ -3,6 +3,7 @@ package entities
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -13,6 +14,14 @@ type EntriesLists struct {
 	LastActive       ListTitle
 	EntriesListsView map[ListTitle]*List
 	Tags             TagsView
+	// Lists moved away by remove. They can be restored until purged
+	Trash map[ListTitle]*List
+	// Lists running along with the current active one. Switching the
+	// current active list doesn't stop them
+	Parallel []ListTitle `json:",omitempty"`
+
+	// renamed maps new titles to the old ones till the lists are stored
+	renamed map[ListTitle]ListTitle
 }
 
 func (elist *EntriesLists) AddTag(tag Tag, title ListTitle) error {
@@ -21,6 +30,12 @@ func (elist *EntriesLists) AddTag(tag Tag, title ListTitle) error {
 		return errors.New("title doesn't exist")
 	}
 
+	for _, t := range list.Tags {
+		if t == tag {
+			return nil
+		}
+	}
+
 	list.Tags = append(list.Tags, tag)
 	elist.Tags.View[tag] = append(elist.Tags.View[tag], title)
 
@@ -53,12 +68,17 @@ func (elist *EntriesLists) Filter(tags []Tag, ok Filter) []*List {
 		return elist.Lists()
 	}
 	var lists []*List
+	seen := make(map[ListTitle]bool)
 
 	for _, tag := range tags {
 		for _, title := range elist.Tags.View[tag] {
-			list := elist.EntriesListsView[title]
+			list, exists := elist.EntriesListsView[title]
+			if !exists || seen[title] {
+				continue
+			}
 			if ok(list, tags) {
 				lists = append(lists, list)
+				seen[title] = true
 			}
 		}
 	}
@@ -140,19 +160,92 @@ func (es entryStatus) String() string {
 	return ""
 }
 
+// MarshalText stores status by name, so the order of constants doesn't matter
+func (es entryStatus) MarshalText() ([]byte, error) {
+	switch es {
+	case StatusActive:
+		return []byte("active"), nil
+	case StatusStop:
+		return []byte("stopped"), nil
+	}
+
+	return nil, fmt.Errorf("unknown status %d", es)
+}
+
+func (es *entryStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "active":
+		*es = StatusActive
+	case "stopped":
+		*es = StatusStop
+	default:
+		return fmt.Errorf("unknown status %q", text)
+	}
+
+	return nil
+}
+
 // This list state keep snapshots on the moment new entry appeared
 type List struct {
-	Id      uint64
-	Title   ListTitle
-	Created time.Time
-	Tags    []Tag
-	States  []*ListState
+	Id       uint64
+	Title    ListTitle
+	Created  time.Time
+	Tags     []Tag
+	States   []*ListState
+	Archived bool
+	// Removed is set when list is moved to the trash
+	Removed time.Time
+	// Parent is the title of the list this one is a part of
+	Parent ListTitle `json:",omitempty"`
+	// Issues are tracker keys like PROJ-123 detected in the title
+	Issues []string `json:",omitempty"`
+}
+
+// Total returns tracked duration including the running session
+func (l *List) Total() time.Duration {
+	last := l.last()
+	if last.Status == StatusActive {
+		return last.TotalDuration + time.Since(last.Timestamp)
+	}
+
+	return last.TotalDuration
 }
 
 var timeShortFormat = "2006-01-02 \n 15:04:05"
 
+// SetTimeFormat changes layout used to print timestamps
+func SetTimeFormat(layout string) {
+	if layout != "" {
+		timeShortFormat = layout
+	}
+}
+
 // t.AppendHeader(table.Row{"#","Title", "Created",  "Started","Stopped", "Total Duration", "Session Duration", "Status"})
 func (l *List) AggregateAllRows() []interface{} {
+	return l.aggregateRow(titleAggregate(l.Title), l.last().TotalDuration)
+}
+
+// AggregateTreeRow is AggregateAllRows with the title indented by depth
+// and total duration rolled up from children
+func (l *List) AggregateTreeRow(depth int, total time.Duration) []interface{} {
+	return l.aggregateRow(treeTitleAggregate(l.Title, depth), total)
+}
+
+func (l *List) aggregateRow(title string, total time.Duration) []interface{} {
+	// list created as a parent may have no sessions yet
+	if len(l.States) == 0 {
+		return []interface{}{
+			title,
+			l.Created.Format(timeShortFormat),
+			"",
+			"",
+			total.Truncate(time.Second).String(),
+			"",
+			"",
+			tagsAggregate(l.Tags),
+		}
+	}
+
 	last := l.States[len(l.States)-1]
 	var stopped, started, currentSession string
 	var prev *ListState
@@ -166,17 +259,54 @@ func (l *List) AggregateAllRows() []interface{} {
 	}
 
 	return []interface{}{
-		titleAggregate(l.Title),
+		title,
 		l.Created.Format(timeShortFormat),
 		started,
 		stopped,
-		last.TotalDuration.Truncate(time.Second).String(),
+		total.Truncate(time.Second).String(),
 		currentSession,
 		last.Status,
 		tagsAggregate(l.Tags),
 	}
 }
 
+// t.AppendHeader(table.Row{"Title", "Sessions", "Total Duration", "Tags", "Archived", "Notes"})
+func (l *List) AggregateReportRow(depth int, total time.Duration) []interface{} {
+	var archived string
+	if l.Archived {
+		archived = "yes"
+	}
+
+	return []interface{}{
+		treeTitleAggregate(l.Title, depth),
+		len(l.Sessions()),
+		total.Truncate(time.Second).String(),
+		tagsAggregate(l.Tags),
+		archived,
+		notesAggregate(l.Notes()),
+	}
+}
+
+// t.AppendHeader(table.Row{"Title", "Removed", "Purge After", "Total Duration", "Tags"})
+func (l *List) AggregateTrashRow(retention time.Duration) []interface{} {
+	return []interface{}{
+		titleAggregate(l.Title),
+		l.Removed.Format(timeShortFormat),
+		l.Removed.Add(retention).Format(timeShortFormat),
+		l.Total().Truncate(time.Second).String(),
+		tagsAggregate(l.Tags),
+	}
+}
+
+// RoundUp rounds duration up to the step. Zero step keeps duration as is
+func RoundUp(d, step time.Duration) time.Duration {
+	if step <= 0 || d%step == 0 {
+		return d
+	}
+
+	return d - d%step + step
+}
+
 func tagsAggregate(tgs []Tag) string {
 	var res []string
 	trigger := 10
@@ -227,6 +357,13 @@ type ListState struct {
 	TotalDuration time.Duration
 
 	Status entryStatus
+	// Notes describe the session. Only active states, which open a session, keep them
+	Notes []string `json:",omitempty"`
+	// Commits made during the session, kept on active states as notes
+	Commits []Commit `json:",omitempty"`
+	// Parallel is set on active states of lists started along the running
+	// ones
+	Parallel bool `json:",omitempty"`
 }
 
 func (l *List) safeAppend(status entryStatus) {
@@ -239,14 +376,19 @@ func (l *List) safeAppend(status entryStatus) {
 		panic("wrong start append")
 	}
 
+	l.appendAt(status, time.Now())
+}
+
+// appendAt appends the state at the moment, which follows the last state
+func (l *List) appendAt(status entryStatus, at time.Time) {
 	var ns = &ListState{}
 	ns.Status = status
-	ns.Timestamp = time.Now()
+	ns.Timestamp = at
 	ns.TotalDuration = l.last().TotalDuration
 
 	var delta time.Duration
 	if status == StatusStop {
-		delta += time.Since(l.last().Timestamp)
+		delta += at.Sub(l.last().Timestamp)
 	}
 
 	ns.TotalDuration += delta
@@ -255,6 +397,12 @@ func (l *List) safeAppend(status entryStatus) {
 }
 
 func (elist *EntriesLists) InsertEntry(title ListTitle, status entryStatus) {
+	// parallel task is stopped on its own, current active keeps running
+	if elist.isParallel(title) && status == StatusStop {
+		elist.stopParallel(title)
+		return
+	}
+
 	// if we stop active task, we should remove current active and add stop entry
 	if title == elist.CurrentActive && status == StatusStop {
 		elist.stopActive(title, status)
@@ -271,6 +419,69 @@ func (elist *EntriesLists) InsertEntry(title ListTitle, status entryStatus) {
 	}
 }
 
+// InsertParallel starts the list without stopping the running ones
+func (elist *EntriesLists) InsertParallel(title ListTitle) {
+	if elist.IsActive(title) {
+		return
+	}
+
+	l := elist.getOrCreate(title)
+	l.Archived = false
+	l.safeAppend(StatusActive)
+	l.last().Parallel = true
+	elist.Parallel = append(elist.Parallel, title)
+}
+
+// IsActive reports whether the list is running as current or parallel one
+func (elist *EntriesLists) IsActive(title ListTitle) bool {
+	return title != emptyTitle && (title == elist.CurrentActive || elist.isParallel(title))
+}
+
+// ActiveTitles returns all running lists, the current active goes first
+func (elist *EntriesLists) ActiveTitles() []ListTitle {
+	var titles []ListTitle
+	if elist.CurrentActive != emptyTitle {
+		titles = append(titles, elist.CurrentActive)
+	}
+
+	return append(titles, elist.Parallel...)
+}
+
+// StopAll stops the current active and all the parallel lists
+func (elist *EntriesLists) StopAll() {
+	for _, title := range elist.ActiveTitles() {
+		elist.InsertEntry(title, StatusStop)
+	}
+}
+
+func (elist *EntriesLists) isParallel(title ListTitle) bool {
+	for _, t := range elist.Parallel {
+		if t == title {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (elist *EntriesLists) stopParallel(title ListTitle) {
+	if l, ok := elist.EntriesListsView[title]; ok {
+		l.safeAppend(StatusStop)
+	}
+	elist.unsetParallel(title)
+	elist.LastActive = title
+}
+
+func (elist *EntriesLists) unsetParallel(title ListTitle) {
+	var parallel []ListTitle
+	for _, t := range elist.Parallel {
+		if t != title {
+			parallel = append(parallel, t)
+		}
+	}
+	elist.Parallel = parallel
+}
+
 func (elist *EntriesLists) stopActive(title ListTitle, status entryStatus) {
 	currentActive, ok := elist.EntriesListsView[title]
 	if !ok {
@@ -283,21 +494,230 @@ func (elist *EntriesLists) stopActive(title ListTitle, status entryStatus) {
 	return
 }
 
-func (elist *EntriesLists) RemoveByTitle(title ListTitle) {
-	for _, tag := range elist.EntriesListsView[title].Tags {
-		delete(elist.Tags.View, tag)
+// ErrTrashed is returned on removal of a list when the trash keeps another
+// list with the same title, replacing it would lose the trashed one
+var ErrTrashed = errors.New("task with the same title is in the trash, restore or purge it first")
+
+// RemoveByTitle moves list to the trash. Active list is stopped first.
+// Children of the list are moved to its parent
+func (elist *EntriesLists) RemoveByTitle(title ListTitle) error {
+	if _, ok := elist.EntriesListsView[title]; !ok {
+		return errors.New("title doesn't exist")
+	}
+	if _, ok := elist.Trash[title]; ok {
+		return ErrTrashed
 	}
 
+	elist.promoteChildren(title)
+	elist.trash(title)
+
+	return nil
+}
+
+// Rename changes title of the list keeping its sessions, tags and subtasks
+func (elist *EntriesLists) Rename(title, to ListTitle) error {
+	l, ok := elist.EntriesListsView[title]
+	if !ok {
+		return errors.New("title doesn't exist")
+	}
+
+	if to == emptyTitle {
+		return errors.New("title can't be empty")
+	}
+
+	_, exists := elist.EntriesListsView[to]
+	_, removed := elist.Trash[to]
+	if exists || removed {
+		return errors.New("title already exists")
+	}
+
+	elist.unindexTags(l)
 	delete(elist.EntriesListsView, title)
+	l.Title = to
+	elist.EntriesListsView[to] = l
+	elist.indexTags(l)
+
+	for _, child := range elist.Lists() {
+		if child.Parent == title {
+			child.Parent = to
+		}
+	}
+
 	if elist.CurrentActive == title {
-		elist.CurrentActive = ""
-		elist.LastActive = title
+		elist.CurrentActive = to
+	}
+	if elist.LastActive == title {
+		elist.LastActive = to
+	}
+	for i, t := range elist.Parallel {
+		if t == title {
+			elist.Parallel[i] = to
+		}
+	}
+
+	if elist.renamed == nil {
+		elist.renamed = make(map[ListTitle]ListTitle)
 	}
+	from := title
+	if original, ok := elist.renamed[title]; ok {
+		from = original
+		delete(elist.renamed, title)
+	}
+	elist.renamed[to] = from
+
+	return nil
 }
 
-func (elist *EntriesLists) RemoveAll() {
-	elist.EntriesListsView = make(map[ListTitle]*List)
-	elist.CurrentActive = ""
+// ForgetRenames drops renames described by stored events already, so the
+// next dump doesn't describe them again
+func (elist *EntriesLists) ForgetRenames() {
+	elist.renamed = nil
+}
+
+// RemoveAll moves all the lists to the trash. Nothing is removed when the
+// trash keeps a list with the title of any of them
+func (elist *EntriesLists) RemoveAll() error {
+	for title := range elist.EntriesListsView {
+		if _, ok := elist.Trash[title]; ok {
+			return fmt.Errorf("%s: %w", title, ErrTrashed)
+		}
+	}
+
+	for title := range elist.EntriesListsView {
+		elist.trash(title)
+	}
+
+	return nil
+}
+
+func (elist *EntriesLists) trash(title ListTitle) {
+	l, ok := elist.EntriesListsView[title]
+	if !ok {
+		return
+	}
+
+	if elist.IsActive(title) {
+		elist.InsertEntry(title, StatusStop)
+	}
+
+	if elist.LastActive == title {
+		elist.LastActive = ""
+	}
+
+	elist.unindexTags(l)
+	delete(elist.EntriesListsView, title)
+
+	if elist.Trash == nil {
+		elist.Trash = make(map[ListTitle]*List)
+	}
+	l.Removed = time.Now()
+	elist.Trash[title] = l
+}
+
+// RestoreByTitle moves list from the trash back to the view
+func (elist *EntriesLists) RestoreByTitle(title ListTitle) error {
+	l, ok := elist.Trash[title]
+	if !ok {
+		return errors.New("title isn't in the trash")
+	}
+
+	if _, ok := elist.EntriesListsView[title]; ok {
+		return errors.New("title already exists")
+	}
+
+	l.Removed = time.Time{}
+	elist.EntriesListsView[title] = l
+	elist.indexTags(l)
+	delete(elist.Trash, title)
+
+	return nil
+}
+
+// PurgeTrash deletes lists removed before the given time for good
+func (elist *EntriesLists) PurgeTrash(before time.Time) []ListTitle {
+	var purged []ListTitle
+	for title, l := range elist.Trash {
+		if l.Removed.Before(before) {
+			delete(elist.Trash, title)
+			purged = append(purged, title)
+		}
+	}
+
+	return purged
+}
+
+// TrashLists returns lists in the trash
+func (elist *EntriesLists) TrashLists() []*List {
+	var lists []*List
+	for _, l := range elist.Trash {
+		lists = append(lists, l)
+	}
+
+	return lists
+}
+
+// Archive stops list and hides it from the default list view
+func (elist *EntriesLists) Archive(title ListTitle) error {
+	l, ok := elist.EntriesListsView[title]
+	if !ok {
+		return errors.New("title doesn't exist")
+	}
+
+	if elist.IsActive(title) {
+		elist.InsertEntry(title, StatusStop)
+	}
+
+	l.Archived = true
+
+	return nil
+}
+
+func (elist *EntriesLists) Unarchive(title ListTitle) error {
+	l, ok := elist.EntriesListsView[title]
+	if !ok {
+		return errors.New("title doesn't exist")
+	}
+
+	l.Archived = false
+
+	return nil
+}
+
+// ReindexTags rebuilds tags view from tags of the lists
+func (elist *EntriesLists) ReindexTags() {
+	elist.Tags.View = make(map[Tag][]ListTitle)
+	for _, l := range elist.Lists() {
+		elist.indexTags(l)
+	}
+
+	for _, titles := range elist.Tags.View {
+		sort.Slice(titles, func(i, j int) bool {
+			return titles[i] < titles[j]
+		})
+	}
+}
+
+func (elist *EntriesLists) indexTags(l *List) {
+	for _, tag := range l.Tags {
+		elist.Tags.View[tag] = append(elist.Tags.View[tag], l.Title)
+	}
+}
+
+func (elist *EntriesLists) unindexTags(l *List) {
+	for _, tag := range l.Tags {
+		var titles []ListTitle
+		for _, title := range elist.Tags.View[tag] {
+			if title != l.Title {
+				titles = append(titles, title)
+			}
+		}
+
+		if len(titles) == 0 {
+			delete(elist.Tags.View, tag)
+			continue
+		}
+		elist.Tags.View[tag] = titles
+	}
 }
 
 var emptyTitle = ListTitle("")
@@ -310,6 +730,19 @@ func (elist *EntriesLists) switchActive(title ListTitle) {
 
 	elist.LastActive = elist.CurrentActive
 	elist.CurrentActive = title
+
+	// parallel list is already running, it only becomes the current one
+	if elist.isParallel(title) {
+		elist.unsetParallel(title)
+		return
+	}
+
+	l := elist.getOrCreate(title)
+	l.Archived = false
+	l.safeAppend(StatusActive)
+}
+
+func (elist *EntriesLists) getOrCreate(title ListTitle) *List {
 	l, ok := elist.EntriesListsView[title]
 	if !ok {
 		l = &List{
@@ -318,7 +751,8 @@ func (elist *EntriesLists) switchActive(title ListTitle) {
 		}
 		elist.EntriesListsView[title] = l
 	}
-	l.safeAppend(StatusActive)
+
+	return l
 }
 
 func (l *List) last() *ListState {
@@ -335,5 +769,6 @@ func InitEmptyElist() *EntriesLists {
 		Tags: TagsView{
 			View: make(map[Tag][]ListTitle),
 		},
+		Trash: make(map[ListTitle]*List),
 	}
 }
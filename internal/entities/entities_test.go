@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"regexp"
 	"testing"
 	"time"
 
@@ -199,6 +200,370 @@ func Test_EntriesList(t *testing.T) {
 			assert.Equal(t, 0, len(res))
 
 		})
+
+	t.Run("start t1 t2, tag1 both, rm t1, check t2 keeps tag, restore t1, purge",
+		func(t *testing.T) {
+			tester.reset()
+
+			tester.start1()
+			tester.start2()
+			tester.tag1(firstTag)
+			tester.tag2(firstTag)
+
+			tester.elist.RemoveByTitle(secondTitle)
+
+			assert.Equal(t, emptyTitle, tester.elist.CurrentActive)
+			assert.Equal(t, []ListTitle{firstTitle}, tester.elist.Tags.View[firstTag])
+			assert.Equal(t, StatusStop, tester.elist.Trash[secondTitle].last().Status)
+
+			assert.NoError(t, tester.elist.RestoreByTitle(secondTitle))
+			res := tester.elist.Filter([]Tag{firstTag}, ContainsAny)
+			assert.Equal(t, 2, len(res))
+
+			assert.NoError(t, tester.elist.RemoveAll())
+			assert.Equal(t, 0, len(tester.elist.Tags.View))
+			assert.Equal(t, 2, len(tester.elist.Trash))
+
+			purged := tester.elist.PurgeTrash(time.Now())
+			assert.Equal(t, 2, len(purged))
+			assert.Equal(t, 0, len(tester.elist.Trash))
+		})
+
+	t.Run("rm t1, start t1 again, rm t1 is refused, trashed t1 is kept",
+		func(t *testing.T) {
+			tester.reset()
+
+			tester.start1()
+			tester.tag1(firstTag)
+			tester.stop1()
+			assert.NoError(t, tester.elist.RemoveByTitle(firstTitle))
+			trashed := tester.elist.Trash[firstTitle]
+
+			tester.start1()
+			assert.ErrorIs(t, tester.elist.RemoveByTitle(firstTitle), ErrTrashed)
+			assert.ErrorIs(t, tester.elist.RemoveTree(firstTitle), ErrTrashed)
+			assert.ErrorIs(t, tester.elist.RemoveAll(), ErrTrashed)
+
+			assert.Same(t, trashed, tester.elist.Trash[firstTitle])
+			assert.Equal(t, []Tag{firstTag}, tester.elist.Trash[firstTitle].Tags)
+			tester.checkStatus(t, firstTitle, StatusActive)
+
+			tester.elist.PurgeTrash(time.Now().Add(time.Second))
+			assert.NoError(t, tester.elist.RemoveByTitle(firstTitle))
+			assert.NotSame(t, trashed, tester.elist.Trash[firstTitle])
+		})
+
+	t.Run("start t1, archive t1, check stopped, start t1 check unarchived",
+		func(t *testing.T) {
+			tester.reset()
+
+			tester.start1()
+			assert.NoError(t, tester.elist.Archive(firstTitle))
+
+			tester.checkStatus(t, firstTitle, StatusStop)
+			assert.Equal(t, true, tester.elist.EntriesListsView[firstTitle].Archived)
+
+			tester.start1()
+			assert.Equal(t, false, tester.elist.EntriesListsView[firstTitle].Archived)
+		})
+}
+
+func Test_Sessions(t *testing.T) {
+	tester := &tester{}
+	tester.reset()
+
+	tester.start1()
+	tester.stop1()
+	tester.start1()
+
+	l := tester.elist.EntriesListsView[firstTitle]
+
+	assert.NoError(t, l.Annotate(-1, "running session"))
+	assert.NoError(t, l.Annotate(0, "First session"))
+	assert.Error(t, l.Annotate(2, "missing session"))
+	assert.Error(t, l.Annotate(-2, "missing session"))
+
+	sessions := l.Sessions()
+	assert.Equal(t, 2, len(sessions))
+	assert.Equal(t, false, sessions[0].Running())
+	assert.Equal(t, true, sessions[1].Running())
+	assert.Equal(t, []string{"running session"}, sessions[1].Notes)
+
+	found := Search(tester.elist.Lists(), "first")
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, 1, found[0].Index)
+}
+
+func Test_AddSessions(t *testing.T) {
+	day := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return day.Add(time.Duration(hour) * time.Hour) }
+
+	elist := InitEmptyElist()
+	assert.NoError(t, elist.AddSession(firstTitle, at(0), at(5), nil))
+	// parallel to the first one
+	elist.getOrCreate(secondTitle).SetSessions([]Session{{Start: at(1), End: at(2)}})
+
+	errs := elist.AddSessions([]Session{
+		{Title: thirdTitle, Start: at(6), End: at(7)},
+		// overlaps the long session, not the last one started
+		{Title: thirdTitle, Start: at(3), End: at(4)},
+		{Title: secondTitle, Start: at(5), End: at(6)},
+		// overlaps the added one
+		{Title: firstTitle, Start: at(6), End: at(8)},
+		{Title: firstTitle, Start: at(8), End: at(8)},
+	})
+	assert.NoError(t, errs[0])
+	var overlap *OverlapError
+	assert.ErrorAs(t, errs[1], &overlap)
+	assert.Equal(t, firstTitle, overlap.With.Title)
+	assert.NoError(t, errs[2])
+	assert.ErrorAs(t, errs[3], &overlap)
+	assert.Equal(t, thirdTitle, overlap.With.Title)
+	assert.Error(t, errs[4])
+
+	assert.Equal(t, 2, len(elist.EntriesListsView[secondTitle].Sessions()))
+	assert.Equal(t, 2*time.Hour, elist.EntriesListsView[secondTitle].Total())
+	assert.Equal(t, at(6), elist.EntriesListsView[thirdTitle].Created)
+
+	// running session overlaps everything after its start
+	elist.InsertEntry(firstTitle, StatusActive)
+	errs = elist.AddSessions([]Session{{Title: thirdTitle, Start: time.Now().Add(time.Hour), End: time.Now().Add(2 * time.Hour)}})
+	assert.Error(t, errs[0])
+}
+
+func Test_Parallel(t *testing.T) {
+	tester := &tester{}
+	tester.reset()
+
+	tester.elist.InsertParallel(firstTitle)
+	tester.start2()
+	tester.start3()
+
+	tester.checkStatus(t, firstTitle, StatusActive)
+	tester.checkStatus(t, secondTitle, StatusStop)
+	assert.Equal(t, []ListTitle{thirdTitle, firstTitle}, tester.elist.ActiveTitles())
+
+	tester.stop1()
+	tester.checkStatus(t, firstTitle, StatusStop)
+	assert.Equal(t, thirdTitle, tester.elist.CurrentActive)
+
+	tester.elist.InsertParallel(firstTitle)
+	tester.start1()
+	assert.Equal(t, firstTitle, tester.elist.CurrentActive)
+	assert.Equal(t, 0, len(tester.elist.Parallel))
+	assert.Equal(t, 2, len(tester.elist.EntriesListsView[firstTitle].Sessions()))
+
+	tester.elist.InsertParallel(secondTitle)
+	tester.elist.StopAll()
+	assert.Equal(t, 0, len(tester.elist.ActiveTitles()))
+}
+
+func Test_SplitOverlaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	// first runs 10:00-11:00, second 10:30-11:30
+	first := &List{Title: firstTitle, States: []*ListState{
+		{Timestamp: at(0), Status: StatusActive},
+		{Timestamp: at(60), Status: StatusStop},
+	}}
+	second := &List{Title: secondTitle, States: []*ListState{
+		{Timestamp: at(30), Status: StatusActive},
+		{Timestamp: at(90), Status: StatusStop},
+	}}
+
+	res := SplitOverlaps([]*List{first, second})
+	assert.Equal(t, 45*time.Minute, res[firstTitle])
+	assert.Equal(t, 45*time.Minute, res[secondTitle])
+}
+
+func Test_Tree(t *testing.T) {
+	tester := &tester{}
+	tester.reset()
+
+	tester.start1()
+	tester.start2()
+	tester.start3()
+
+	assert.NoError(t, tester.elist.SetParent(secondTitle, firstTitle))
+	assert.NoError(t, tester.elist.SetParent(thirdTitle, secondTitle))
+	assert.Error(t, tester.elist.SetParent(firstTitle, thirdTitle))
+
+	nodes := Tree(tester.elist.Lists())
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, thirdTitle, nodes[2].List.Title)
+	assert.Equal(t, 2, nodes[2].Depth)
+
+	rolled := RollUp(nodes, map[ListTitle]time.Duration{
+		firstTitle:  time.Minute,
+		secondTitle: time.Minute,
+		thirdTitle:  time.Minute,
+	})
+	assert.Equal(t, 3*time.Minute, rolled[firstTitle])
+	assert.Equal(t, 2*time.Minute, rolled[secondTitle])
+
+	assert.NoError(t, tester.elist.RemoveByTitle(secondTitle))
+	assert.Equal(t, firstTitle, tester.elist.EntriesListsView[thirdTitle].Parent)
+
+	assert.NoError(t, tester.elist.RemoveTree(firstTitle))
+	assert.Equal(t, 0, len(tester.elist.EntriesListsView))
+	assert.Equal(t, firstTitle, tester.elist.Trash[thirdTitle].Parent)
+}
+
+func Test_Issues(t *testing.T) {
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`#[0-9]+`),
+		regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`),
+	}
+
+	keys := DetectIssues("PROJ-1 follow up of #42 and PROJ-1", patterns)
+	assert.Equal(t, []string{"PROJ-1", "#42"}, keys)
+	assert.Nil(t, DetectIssues("no keys here", patterns))
+
+	tester := &tester{}
+	tester.reset()
+	tester.start1()
+	tester.stop1()
+	tester.start1()
+
+	l := tester.elist.EntriesListsView[firstTitle]
+	l.Issues = keys
+
+	// running session isn't logged
+	assert.Equal(t, 2, len(Worklogs(tester.elist.Lists(), "", Range{})))
+	assert.Equal(t, 1, len(Worklogs(tester.elist.Lists(), "proj-1", Range{})))
+	assert.Equal(t, 0, len(Worklogs(tester.elist.Lists(), "PROJ-2", Range{})))
+	assert.Equal(t, 0, len(Worklogs(tester.elist.Lists(), "", Range{From: time.Now().Add(time.Hour)})))
+
+	// a session crossing the bound is logged once, in the period it started
+	start := time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC)
+	week := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	elist := InitEmptyElist()
+	assert.NoError(t, elist.AddSession("PROJ-3 deploy", start, start.Add(2*time.Hour), nil))
+	assert.NoError(t, elist.AddSession("PROJ-3 deploy", week.Add(-3*time.Hour), week.Add(-2*time.Hour), nil))
+	elist.EntriesListsView["PROJ-3 deploy"].Issues = []string{"PROJ-3"}
+
+	before := Worklogs(elist.Lists(), "", Range{To: week})
+	assert.Equal(t, 2, len(before))
+	assert.Equal(t, int64(7200), before[1].Seconds)
+	assert.Equal(t, 0, len(Worklogs(elist.Lists(), "", Range{From: week})))
+	assert.Equal(t, 1, len(Worklogs(elist.Lists(), "", Range{From: week.Add(-2 * time.Hour), To: week.Add(7 * 24 * time.Hour)})))
+}
+
+func Test_Doctor(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	elist := InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", start, start.Add(time.Hour), nil))
+	assert.NoError(t, elist.AddSession("docs", start.Add(6*time.Hour), start.Add(7*time.Hour), nil))
+	elist.AddTag("#work", "api")
+	elist.AddTag("#work", "docs")
+	assert.Empty(t, elist.Doctor(false))
+
+	// states written by the old removal and broken stops
+	api := elist.EntriesListsView["api"]
+	api.States = append(api.States,
+		&ListState{Timestamp: start.Add(2 * time.Hour), TotalDuration: time.Hour, Status: StatusActive},
+		&ListState{Timestamp: start.Add(3 * time.Hour), TotalDuration: time.Hour, Status: StatusActive},
+		&ListState{Timestamp: start.Add(4 * time.Hour), TotalDuration: 3 * time.Hour, Status: StatusStop},
+		&ListState{Timestamp: start.Add(5 * time.Hour), TotalDuration: 3 * time.Hour, Status: StatusStop},
+	)
+	elist.Tags.View["#work"] = []ListTitle{"gone"}
+	elist.CurrentActive = "gone"
+	elist.LastActive = "gone"
+	elist.EntriesListsView["docs"].States = elist.EntriesListsView["docs"].States[:1]
+
+	problems := elist.Doctor(false)
+	assert.NotEmpty(t, problems)
+	assert.Equal(t, ListTitle("gone"), elist.CurrentActive)
+
+	fixed := elist.Doctor(true)
+	assert.Equal(t, len(problems), len(fixed))
+	assert.True(t, fixed[0].Fixed)
+	assert.Empty(t, elist.Doctor(false))
+
+	// repeated start continues the session, the extra stop is dropped
+	assert.Equal(t, 3*time.Hour, api.Total())
+	assert.Equal(t, 4, len(api.States))
+	assert.Equal(t, ListTitle("docs"), elist.CurrentActive)
+	assert.Equal(t, ListTitle(""), elist.LastActive)
+	assert.Equal(t, []ListTitle{"api", "docs"}, elist.Tags.View["#work"])
+}
+
+func Test_Events(t *testing.T) {
+	elist := InitEmptyElist()
+	var log []Event
+
+	// every change is described by events reproducing it from scratch
+	step := func(change func()) []Event {
+		old, err := elist.Clone()
+		assert.NoError(t, err)
+
+		change()
+
+		events, ok := Diff(old, elist, time.Now())
+		assert.True(t, ok)
+		log = append(log, events...)
+
+		replayed := InitEmptyElist()
+		assert.NoError(t, replayed.Replay(log))
+		assert.True(t, sameLists(replayed, elist))
+
+		return events
+	}
+
+	events := step(func() {
+		elist.InsertEntry(firstTitle, StatusActive)
+		elist.AddTag(firstTag, firstTitle)
+	})
+	assert.Equal(t, []EventType{EventTaskCreated, EventStarted, EventTagged}, types(events))
+
+	events = step(func() { elist.InsertEntry(secondTitle, StatusActive) })
+	assert.Equal(t, []EventType{EventStopped, EventTaskCreated, EventStarted}, types(events))
+
+	events = step(func() { elist.InsertParallel(thirdTitle) })
+	assert.Equal(t, []EventType{EventTaskCreated, EventStarted}, types(events))
+	assert.True(t, events[1].Parallel)
+
+	// parallel list becomes the current one without a new session
+	events = step(func() { elist.InsertEntry(thirdTitle, StatusActive) })
+	assert.Equal(t, []EventType{EventStopped, EventFocused}, types(events))
+
+	events = step(func() { assert.NoError(t, elist.Rename(firstTitle, "renamed")) })
+	assert.Equal(t, []EventType{EventRenamed}, types(events))
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	events = step(func() {
+		assert.NoError(t, elist.AddSession("renamed", start, start.Add(time.Hour), []string{"past"}))
+		elist.EntriesListsView["renamed"].RemoveTag(firstTag)
+	})
+	assert.Equal(t, []EventType{EventEdited, EventUntagged}, types(events))
+
+	events = step(func() {
+		assert.NoError(t, elist.SetParent(secondTitle, "renamed"))
+		assert.NoError(t, elist.RemoveByTitle(thirdTitle))
+	})
+	assert.Equal(t, []EventType{EventMoved, EventStopped, EventRemoved}, types(events))
+
+	events = step(func() { elist.PurgeTrash(time.Now().Add(time.Hour)) })
+	assert.Equal(t, []EventType{EventPurged}, types(events))
+
+	// totals are counted from timestamps
+	replayed := InitEmptyElist()
+	assert.NoError(t, replayed.Replay(log))
+	assert.Equal(t, time.Hour, replayed.EntriesListsView["renamed"].Total().Truncate(time.Hour))
+	assert.Equal(t, ListTitle("renamed"), replayed.EntriesListsView[secondTitle].Parent)
+}
+
+func types(events []Event) []EventType {
+	var res []EventType
+	for _, e := range events {
+		res = append(res, e.Type)
+	}
+
+	return res
 }
 
 func getListLastState(l *EntriesLists, t ListTitle) *ListState {
@@ -206,3 +571,56 @@ func getListLastState(l *EntriesLists, t ListTitle) *ListState {
 	last := l.EntriesListsView[t].States[length-1]
 	return last
 }
+
+func Test_Workspaces(t *testing.T) {
+	ws := InitWorkspaces()
+
+	assert.Error(t, ws.Create("Work"))
+	assert.Error(t, ws.Create("my work"))
+	assert.Error(t, ws.Create(""))
+	assert.Error(t, ws.Create(DefaultWorkspace))
+	assert.NoError(t, ws.Create("client-a_2"))
+	assert.Error(t, ws.Create("client-a_2"))
+
+	assert.Error(t, ws.Use("missing"))
+	assert.Equal(t, DefaultWorkspace, ws.Current)
+	assert.NoError(t, ws.Use("client-a_2"))
+	assert.Equal(t, "client-a_2", ws.Current)
+
+	// current workspace falls back to default
+	assert.Error(t, ws.Delete(DefaultWorkspace))
+	assert.Error(t, ws.Delete("missing"))
+	assert.NoError(t, ws.Delete("client-a_2"))
+	assert.Equal(t, DefaultWorkspace, ws.Current)
+	assert.Equal(t, []string{DefaultWorkspace}, ws.Names)
+	assert.Error(t, ws.Use("client-a_2"))
+}
+
+func Test_TimeOff(t *testing.T) {
+	to := InitTimeOff()
+	day := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	first, err := to.Add(DayOff{Date: day, Kind: PTO})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), first.Id)
+	assert.Equal(t, 0, first.Date.Hour())
+
+	_, err = to.Add(DayOff{Date: day.Add(time.Hour), Kind: Sick})
+	assert.Error(t, err)
+	_, err = to.Add(DayOff{Date: day.AddDate(0, 0, 1), Kind: "vacation"})
+	assert.Error(t, err)
+
+	_, err = to.Add(DayOff{Date: day.AddDate(0, 0, -1), Kind: Sick, Hours: 4 * time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), to.Days[0].Id)
+
+	in := to.In(Range{From: day, To: day.AddDate(0, 0, 1)})
+	assert.Equal(t, 1, len(in))
+	assert.Equal(t, PTO, in[0].Kind)
+
+	_, err = to.Remove(1)
+	assert.NoError(t, err)
+	_, err = to.Remove(1)
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(to.Days))
+}
@@ -0,0 +1,569 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// EventType names a change of the lists
+type EventType string
+
+const (
+	EventTaskCreated EventType = "TaskCreated"
+	EventStarted     EventType = "Started"
+	EventStopped     EventType = "Stopped"
+	EventTagged      EventType = "Tagged"
+	EventUntagged    EventType = "Untagged"
+	EventRenamed     EventType = "Renamed"
+	// EventRemoved moves list to the trash
+	EventRemoved EventType = "Removed"
+	// EventRestored moves list from the trash back
+	EventRestored EventType = "Restored"
+	// EventPurged deletes list for good
+	EventPurged     EventType = "Purged"
+	EventArchived   EventType = "Archived"
+	EventUnarchived EventType = "Unarchived"
+	// EventMoved sets parent of the list
+	EventMoved EventType = "Moved"
+	// EventEdited replaces all the sessions of the list. It records changes
+	// of the history like added past sessions and notes
+	EventEdited EventType = "Edited"
+	// EventFocused sets running lists when other events don't tell them
+	EventFocused EventType = "Focused"
+)
+
+// Event is a single change of the lists. Lists are the result of applying
+// all the events in order
+type Event struct {
+	Seq  uint64    `json:"seq"`
+	Type EventType `json:"type"`
+	// Time is the moment the change took effect, start and stop moments
+	// for sessions
+	Time  time.Time `json:"time"`
+	Title ListTitle `json:"title,omitempty"`
+	// To is the new title of the renamed list
+	To       ListTitle `json:"to,omitempty"`
+	Tag      Tag       `json:"tag,omitempty"`
+	Parent   ListTitle `json:"parent,omitempty"`
+	Parallel bool      `json:"parallel,omitempty"`
+	Notes    []string  `json:"notes,omitempty"`
+	Commits  []Commit  `json:"commits,omitempty"`
+	Issues   []string  `json:"issues,omitempty"`
+	Sessions []Session `json:"sessions,omitempty"`
+	// Current, Last and Running are set by EventFocused
+	Current ListTitle   `json:"current,omitempty"`
+	Last    ListTitle   `json:"last,omitempty"`
+	Running []ListTitle `json:"running,omitempty"`
+}
+
+// Details describes payload of the event for the events table
+func (e Event) Details() string {
+	switch e.Type {
+	case EventStarted:
+		if e.Parallel {
+			return "parallel"
+		}
+	case EventTagged, EventUntagged:
+		return string(e.Tag)
+	case EventRenamed:
+		return fmt.Sprintf("to %q", e.To)
+	case EventMoved:
+		if e.Parent == emptyTitle {
+			return "to root"
+		}
+		return fmt.Sprintf("under %q", e.Parent)
+	case EventEdited:
+		return fmt.Sprintf("%d sessions", len(e.Sessions))
+	case EventFocused:
+		return fmt.Sprintf("current %q, last %q, parallel %v", e.Current, e.Last, e.Running)
+	}
+
+	return ""
+}
+
+// t.AppendHeader(table.Row{"#", "Time", "Event", "Title", "Details", "Notes"})
+func (e Event) AggregateRow() []interface{} {
+	return []interface{}{
+		e.Seq,
+		e.Time.Format(timeShortFormat),
+		e.Type,
+		titleAggregate(e.Title),
+		e.Details(),
+		notesAggregate(e.Notes),
+	}
+}
+
+// Replay applies events in order. Durations are counted from timestamps,
+// so a wrong stored total doesn't outlive a snapshot
+func (elist *EntriesLists) Replay(events []Event) error {
+	for _, e := range events {
+		if err := elist.Apply(e); err != nil {
+			return fmt.Errorf("event %d %s %q: %w", e.Seq, e.Type, e.Title, err)
+		}
+	}
+
+	for _, l := range elist.Lists() {
+		l.recount()
+	}
+	for _, l := range elist.Trash {
+		l.recount()
+	}
+	elist.ReindexTags()
+
+	return nil
+}
+
+// Apply changes lists by the event. Tags index is rebuilt by Replay
+func (elist *EntriesLists) Apply(e Event) error {
+	switch e.Type {
+	case EventTaskCreated:
+		if _, ok := elist.EntriesListsView[e.Title]; ok {
+			return errors.New("title already exists")
+		}
+		l := elist.getOrCreate(e.Title)
+		l.Created, l.Issues = e.Time, e.Issues
+		return nil
+	case EventFocused:
+		elist.CurrentActive, elist.LastActive, elist.Parallel = e.Current, e.Last, e.Running
+		return nil
+	case EventRenamed:
+		if err := elist.Rename(e.Title, e.To); err != nil {
+			return err
+		}
+		if e.Issues != nil {
+			elist.EntriesListsView[e.To].Issues = e.Issues
+		}
+		return nil
+	case EventRestored:
+		return elist.RestoreByTitle(e.Title)
+	case EventPurged:
+		delete(elist.EntriesListsView, e.Title)
+		delete(elist.Trash, e.Title)
+		return nil
+	}
+
+	l, ok := elist.EntriesListsView[e.Title]
+	if !ok {
+		l, ok = elist.Trash[e.Title]
+	}
+	if !ok {
+		return errors.New("title doesn't exist")
+	}
+
+	switch e.Type {
+	case EventStarted:
+		if l.running() {
+			return errors.New("list is already running")
+		}
+		l.States = append(l.States, &ListState{
+			Timestamp:     e.Time,
+			TotalDuration: l.last().TotalDuration,
+			Status:        StatusActive,
+			Notes:         e.Notes,
+			Commits:       e.Commits,
+			Parallel:      e.Parallel,
+		})
+		if e.Issues != nil {
+			l.Issues = e.Issues
+		}
+
+		if e.Parallel {
+			if !elist.isParallel(e.Title) {
+				elist.Parallel = append(elist.Parallel, e.Title)
+			}
+			return nil
+		}
+		if elist.CurrentActive != emptyTitle && elist.CurrentActive != e.Title {
+			elist.LastActive = elist.CurrentActive
+		}
+		elist.CurrentActive = e.Title
+		elist.unsetParallel(e.Title)
+	case EventStopped:
+		if !l.running() {
+			return errors.New("list isn't running")
+		}
+		last := l.last()
+		l.States = append(l.States, &ListState{
+			Timestamp:     e.Time,
+			TotalDuration: last.TotalDuration + e.Time.Sub(last.Timestamp),
+			Status:        StatusStop,
+		})
+
+		if e.Title == elist.CurrentActive {
+			elist.CurrentActive, elist.LastActive = emptyTitle, e.Title
+		} else if elist.isParallel(e.Title) {
+			elist.unsetParallel(e.Title)
+			elist.LastActive = e.Title
+		}
+	case EventTagged:
+		if !containsTag(l.Tags, e.Tag) {
+			l.Tags = append(l.Tags, e.Tag)
+		}
+	case EventUntagged:
+		l.RemoveTag(e.Tag)
+	case EventRemoved:
+		if _, ok := elist.EntriesListsView[e.Title]; !ok {
+			return errors.New("list is already removed")
+		}
+		elist.trash(e.Title)
+		l.Removed = e.Time
+	case EventArchived:
+		l.Archived = true
+	case EventUnarchived:
+		l.Archived = false
+	case EventMoved:
+		l.Parent = e.Parent
+	case EventEdited:
+		l.SetSessions(append([]Session{}, e.Sessions...))
+		if len(e.Sessions) > 0 && l.States[0].Timestamp.Before(l.Created) {
+			l.Created = l.States[0].Timestamp
+		}
+	default:
+		return fmt.Errorf("unknown event %s", e.Type)
+	}
+
+	return nil
+}
+
+func (l *List) running() bool {
+	return len(l.States)%2 == 1
+}
+
+// recount sets total durations from timestamps of the states
+func (l *List) recount() {
+	var total time.Duration
+	for i, s := range l.States {
+		if s.Status == StatusStop && i > 0 {
+			total += s.Timestamp.Sub(l.States[i-1].Timestamp)
+		}
+		s.TotalDuration = total
+	}
+}
+
+// Diff returns events turning old lists into new ones. It reports false when
+// the events don't reproduce new lists exactly, then new lists have to be
+// stored as a snapshot
+func Diff(old, new *EntriesLists, now time.Time) ([]Event, bool) {
+	d := &differ{now: now, new: new, olds: make(map[ListTitle]*List), parents: make(map[ListTitle]ListTitle)}
+
+	inTrash := make(map[ListTitle]bool)
+	for title, l := range old.Trash {
+		d.olds[title], inTrash[title] = l, true
+	}
+	for title, l := range old.EntriesListsView {
+		d.olds[title] = l
+		delete(inTrash, title)
+	}
+
+	// renamed lists are compared with their old versions
+	var renamed []ListTitle
+	for to := range new.renamed {
+		renamed = append(renamed, to)
+	}
+	sort.Slice(renamed, func(i, j int) bool { return renamed[i] < renamed[j] })
+	for _, to := range renamed {
+		from := new.renamed[to]
+		o, ok := old.EntriesListsView[from]
+		_, taken := d.olds[to]
+		_, kept := new.EntriesListsView[from]
+		if _, exists := new.EntriesListsView[to]; !ok || !exists || taken || kept {
+			continue
+		}
+
+		d.add(Event{Type: EventRenamed, Title: from, To: to})
+		d.olds[to] = o
+		delete(d.olds, from)
+		d.parents[from] = to
+	}
+
+	titles := make(map[ListTitle]bool)
+	for title := range d.olds {
+		titles[title] = true
+	}
+	for title := range new.EntriesListsView {
+		titles[title] = true
+	}
+	for title := range new.Trash {
+		titles[title] = true
+	}
+
+	var sorted []ListTitle
+	for title := range titles {
+		sorted = append(sorted, title)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, title := range sorted {
+		n, removed := new.Trash[title]
+		if view, ok := new.EntriesListsView[title]; ok {
+			n, removed = view, false
+		}
+		d.list(title, d.olds[title], n, inTrash[title], removed)
+	}
+
+	replayed, err := old.Clone()
+	if err != nil {
+		return d.events, false
+	}
+	if err := replayed.Replay(d.events); err != nil {
+		return d.events, false
+	}
+
+	if replayed.CurrentActive != new.CurrentActive || replayed.LastActive != new.LastActive ||
+		!sameTitles(replayed.Parallel, new.Parallel) {
+		focused := Event{Type: EventFocused, Time: now, Current: new.CurrentActive, Last: new.LastActive, Running: new.Parallel}
+		d.events = append(d.events, focused)
+		replayed.Apply(focused)
+	}
+
+	return d.events, sameLists(replayed, new)
+}
+
+type differ struct {
+	now    time.Time
+	new    *EntriesLists
+	olds   map[ListTitle]*List
+	events []Event
+	// parents maps old titles of renamed lists to the new ones
+	parents map[ListTitle]ListTitle
+}
+
+func (d *differ) add(e Event) {
+	if e.Time.IsZero() {
+		e.Time = d.now
+	}
+	d.events = append(d.events, e)
+}
+
+// list emits events of the single list. Nil n is a purged list
+func (d *differ) list(title ListTitle, o, n *List, wasRemoved, removed bool) {
+	if n == nil {
+		d.add(Event{Type: EventPurged, Title: title})
+		return
+	}
+
+	if o == nil {
+		d.add(Event{Type: EventTaskCreated, Title: title, Time: n.Created, Issues: n.Issues})
+		o = &List{Title: title, Created: n.Created, Issues: n.Issues}
+	}
+
+	if wasRemoved && !removed {
+		d.add(Event{Type: EventRestored, Title: title})
+	}
+
+	d.sessions(title, o, n)
+
+	if !sameStrings(o.Issues, n.Issues) {
+		// issues are detected when the list is started or renamed
+		for i := len(d.events) - 1; i >= 0; i-- {
+			e := &d.events[i]
+			if (e.Type == EventStarted && e.Title == title) || (e.Type == EventRenamed && e.To == title) {
+				e.Issues = n.Issues
+				break
+			}
+		}
+	}
+
+	for _, tag := range o.Tags {
+		if !containsTag(n.Tags, tag) {
+			d.add(Event{Type: EventUntagged, Title: title, Tag: tag})
+		}
+	}
+	for _, tag := range n.Tags {
+		if !containsTag(o.Tags, tag) {
+			d.add(Event{Type: EventTagged, Title: title, Tag: tag})
+		}
+	}
+
+	parent := o.Parent
+	if to, ok := d.parents[parent]; ok {
+		parent = to
+	}
+	if parent != n.Parent {
+		d.add(Event{Type: EventMoved, Title: title, Parent: n.Parent})
+	}
+
+	if o.Archived != n.Archived {
+		if n.Archived {
+			d.add(Event{Type: EventArchived, Title: title})
+		} else {
+			d.add(Event{Type: EventUnarchived, Title: title})
+		}
+	}
+
+	if removed && !wasRemoved {
+		d.add(Event{Type: EventRemoved, Title: title, Time: n.Removed})
+	}
+}
+
+// sessions emits stop of the running session and start of a new one. Any
+// other change of the history replaces all the sessions
+func (d *differ) sessions(title ListTitle, o, n *List) {
+	olds, news := o.Sessions(), n.Sessions()
+	if sameSessions(olds, news) {
+		return
+	}
+
+	var events []Event
+	k := len(olds)
+	incremental := len(news) >= k && len(news) <= k+1
+	if incremental && k > 0 {
+		a, b := olds[k-1], news[k-1]
+		switch {
+		case !sameSessions(olds[:k-1], news[:k-1]):
+			incremental = false
+		case sameSession(a, b):
+		case a.Running() && !b.Running() && a.Start.Equal(b.Start) && sameStrings(a.Notes, b.Notes) && sameCommits(a.Commits, b.Commits):
+			events = append(events, Event{Type: EventStopped, Title: title, Time: b.End})
+		default:
+			incremental = false
+		}
+	}
+	if incremental && len(news) > k {
+		s := news[k]
+		incremental = s.Running()
+		events = append(events, Event{
+			Type:     EventStarted,
+			Title:    title,
+			Time:     s.Start,
+			Parallel: s.Parallel || d.new.isParallel(title),
+			Notes:    s.Notes,
+			Commits:  s.Commits,
+		})
+	}
+
+	if !incremental {
+		events = []Event{{Type: EventEdited, Title: title, Sessions: news}}
+	}
+
+	for _, e := range events {
+		d.add(e)
+	}
+}
+
+// Clone returns a deep copy of the lists
+func (elist *EntriesLists) Clone() (*EntriesLists, error) {
+	enc, err := json.Marshal(elist)
+	if err != nil {
+		return nil, err
+	}
+
+	res := InitEmptyElist()
+	if err := json.Unmarshal(enc, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// sameLists compares lists as they're shown, indexes and totals are derived
+func sameLists(a, b *EntriesLists) bool {
+	if a.CurrentActive != b.CurrentActive || a.LastActive != b.LastActive || !sameTitles(a.Parallel, b.Parallel) {
+		return false
+	}
+
+	return sameListMaps(a.EntriesListsView, b.EntriesListsView) && sameListMaps(a.Trash, b.Trash)
+}
+
+func sameListMaps(a, b map[ListTitle]*List) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for title, l := range a {
+		other, ok := b[title]
+		if !ok || !sameList(l, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameList(a, b *List) bool {
+	return a.Title == b.Title &&
+		a.Created.Equal(b.Created) &&
+		a.Archived == b.Archived &&
+		a.Removed.Equal(b.Removed) &&
+		a.Parent == b.Parent &&
+		sameStrings(a.Issues, b.Issues) &&
+		sameTags(a.Tags, b.Tags) &&
+		sameSessions(a.Sessions(), b.Sessions())
+}
+
+func sameSessions(a, b []Session) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !sameSession(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameSession(a, b Session) bool {
+	return a.Start.Equal(b.Start) && a.End.Equal(b.End) && sameStrings(a.Notes, b.Notes) && sameCommits(a.Commits, b.Commits)
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameTitles(a, b []ListTitle) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameTags(a, b []Tag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameCommits(a, b []Commit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
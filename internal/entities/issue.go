@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DetectIssues returns issue keys found in the title in order of appearance
+func DetectIssues(title ListTitle, patterns []*regexp.Regexp) []string {
+	type match struct {
+		pos int
+		key string
+	}
+
+	var matches []match
+	seen := make(map[string]bool)
+	for _, re := range patterns {
+		for _, loc := range re.FindAllStringIndex(string(title), -1) {
+			key := string(title)[loc[0]:loc[1]]
+			if !seen[key] {
+				seen[key] = true
+				matches = append(matches, match{loc[0], key})
+			}
+		}
+	}
+
+	// keep order of appearance in the title regardless of patterns order
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].pos < matches[j].pos
+	})
+
+	var keys []string
+	for _, m := range matches {
+		keys = append(keys, m.key)
+	}
+
+	return keys
+}
+
+// HasIssue reports whether the issue key was detected in the list title
+func (l *List) HasIssue(key string) bool {
+	for _, issue := range l.Issues {
+		if strings.EqualFold(issue, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Worklog is the time spent on the issue in a single session
+type Worklog struct {
+	Issue   string
+	Title   ListTitle
+	Started time.Time
+	Seconds int64
+	Comment string
+}
+
+// t.AppendHeader(table.Row{"Issue", "Title", "Started", "Seconds", "Comment"})
+func (w Worklog) AggregateRow() []interface{} {
+	return []interface{}{
+		w.Issue,
+		titleAggregate(w.Title),
+		w.Started.Format(timeShortFormat),
+		w.Seconds,
+		w.Comment,
+	}
+}
+
+// Worklogs returns finished sessions started in the range per detected issue.
+// Empty issue selects all of them. Running sessions aren't logged until
+// stopped. A session crossing the range bound belongs to the period it
+// started in, so adjacent exports don't log it twice
+func Worklogs(lists []*List, issue string, r Range) []Worklog {
+	var res []Worklog
+	for _, l := range lists {
+		for _, key := range l.Issues {
+			if issue != "" && !strings.EqualFold(key, issue) {
+				continue
+			}
+
+			for _, s := range l.Sessions() {
+				if s.Running() || !r.Contains(s.Start) {
+					continue
+				}
+
+				res = append(res, Worklog{
+					Issue:   key,
+					Title:   l.Title,
+					Started: s.Start,
+					Seconds: int64(s.Duration().Seconds()),
+					Comment: strings.Join(s.Notes, "; "),
+				})
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule logs or starts the task on every occurrence of the cron
+// expression
+type Schedule struct {
+	Id        uint64
+	Title     ListTitle
+	Cron      string
+	Duration  time.Duration
+	Tags      []Tag `json:",omitempty"`
+	Workspace string
+	// Start starts the task instead of logging a finished session
+	Start bool `json:",omitempty"`
+	// Done is the latest occurrence logged, started or skipped
+	Done time.Time
+	// Started is the start of the session started by the schedule till
+	// it's stopped at the end of the occurrence
+	Started time.Time `json:",omitempty"`
+}
+
+// Schedules keeps schedules of all workspaces
+type Schedules struct {
+	LastId    uint64
+	Schedules []*Schedule
+}
+
+func InitSchedules() *Schedules {
+	return &Schedules{}
+}
+
+// Add registers the schedule, it's materialized since Done
+func (ss *Schedules) Add(s Schedule) *Schedule {
+	ss.LastId++
+	s.Id = ss.LastId
+	ss.Schedules = append(ss.Schedules, &s)
+
+	return &s
+}
+
+// Remove deletes the schedule by its id
+func (ss *Schedules) Remove(id uint64) (*Schedule, error) {
+	for i, s := range ss.Schedules {
+		if s.Id == id {
+			ss.Schedules = append(ss.Schedules[:i], ss.Schedules[i+1:]...)
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("schedule %d doesn't exist", id)
+}
+
+// Of returns schedules of the workspace
+func (ss *Schedules) Of(workspace string) []*Schedule {
+	var res []*Schedule
+	for _, s := range ss.Schedules {
+		if s.Workspace == workspace {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,493 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session is a single active interval of the list
+type Session struct {
+	// Index is the number of the session within the list starting from 1
+	Index int
+	Title ListTitle
+	Start time.Time
+	// End is zero while session is running
+	End     time.Time
+	Notes   []string
+	Commits []Commit
+	// Parallel is set for sessions started along the running ones
+	Parallel bool
+}
+
+// Commit is the git commit recorded during the session
+type Commit struct {
+	Hash    string
+	Subject string `json:",omitempty"`
+	Repo    string `json:",omitempty"`
+}
+
+func (c Commit) String() string {
+	hash := c.Hash
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+
+	return strings.TrimSpace(fmt.Sprint(hash, " ", c.Subject))
+}
+
+// Duration returns session length. Running session is counted till now
+func (s Session) Duration() time.Duration {
+	if s.End.IsZero() {
+		return time.Since(s.Start)
+	}
+
+	return s.End.Sub(s.Start)
+}
+
+func (s Session) Running() bool {
+	return s.End.IsZero()
+}
+
+// t.AppendHeader(table.Row{"#", "Title", "Started", "Stopped", "Duration", "Notes", "Commits"})
+func (s Session) AggregateRow() []interface{} {
+	var stopped string
+	if !s.Running() {
+		stopped = s.End.Format(timeShortFormat)
+	}
+
+	return []interface{}{
+		s.Index,
+		titleAggregate(s.Title),
+		s.Start.Format(timeShortFormat),
+		stopped,
+		s.Duration().Truncate(time.Second).String(),
+		notesAggregate(s.Notes),
+		commitsAggregate(s.Commits),
+	}
+}
+
+// Sessions splits list states into active intervals
+func (l *List) Sessions() []Session {
+	var sessions []Session
+	for i := 0; i < len(l.States); i += 2 {
+		s := Session{
+			Index:    i/2 + 1,
+			Title:    l.Title,
+			Start:    l.States[i].Timestamp,
+			Notes:    l.States[i].Notes,
+			Commits:  l.States[i].Commits,
+			Parallel: l.States[i].Parallel,
+		}
+		if i+1 < len(l.States) {
+			s.End = l.States[i+1].Timestamp
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions
+}
+
+// Notes returns notes of all the sessions
+func (l *List) Notes() []string {
+	var notes []string
+	for i := 0; i < len(l.States); i += 2 {
+		notes = append(notes, l.States[i].Notes...)
+	}
+
+	return notes
+}
+
+// Annotate attaches note to the session with given index. Index -1 points
+// to the last session.
+func (l *List) Annotate(session int, note string) error {
+	if len(l.States) == 0 {
+		return errors.New("list has no sessions")
+	}
+
+	if session == -1 {
+		session = len(l.Sessions()) - 1
+	}
+
+	if session < 0 || session >= len(l.Sessions()) {
+		return errors.New("session doesn't exist")
+	}
+
+	state := l.States[session*2]
+	state.Notes = append(state.Notes, note)
+
+	return nil
+}
+
+// AddCommit records commit on the running session
+func (l *List) AddCommit(c Commit) error {
+	last := l.last()
+	if len(l.States) == 0 || last.Status != StatusActive {
+		return errors.New("list isn't running")
+	}
+
+	for _, recorded := range last.Commits {
+		if recorded.Hash == c.Hash {
+			return nil
+		}
+	}
+	last.Commits = append(last.Commits, c)
+
+	return nil
+}
+
+// Search returns sessions which notes contain the query. Case insensitive
+func Search(lists []*List, query string) []Session {
+	query = strings.ToLower(query)
+
+	var res []Session
+	for _, l := range lists {
+		for _, s := range l.Sessions() {
+			for _, note := range s.Notes {
+				if strings.Contains(strings.ToLower(note), query) {
+					res = append(res, s)
+					break
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+func notesAggregate(notes []string) string {
+	return strings.Join(notes, "\n")
+}
+
+func commitsAggregate(commits []Commit) string {
+	var res []string
+	for _, c := range commits {
+		res = append(res, c.String())
+	}
+
+	return strings.Join(res, "\n")
+}
+
+// SplitOverlaps returns tracked duration per list where time of parallel
+// sessions is divided equally between the lists running at that moment
+func SplitOverlaps(lists []*List) map[ListTitle]time.Duration {
+	type point struct {
+		at    time.Time
+		title ListTitle
+		start bool
+	}
+
+	now := time.Now()
+	var points []point
+	for _, l := range lists {
+		for _, s := range l.Sessions() {
+			end := s.End
+			if s.Running() {
+				end = now
+			}
+			points = append(points, point{s.Start, l.Title, true}, point{end, l.Title, false})
+		}
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].at.Before(points[j].at)
+	})
+
+	res := make(map[ListTitle]time.Duration)
+	running := make(map[ListTitle]bool)
+	for i, p := range points {
+		if i > 0 && len(running) > 0 {
+			share := p.at.Sub(points[i-1].at) / time.Duration(len(running))
+			for title := range running {
+				res[title] += share
+			}
+		}
+
+		if p.start {
+			running[p.title] = true
+		} else {
+			delete(running, p.title)
+		}
+	}
+
+	return res
+}
+
+// Range is the time interval used to filter sessions. Zero bound is open
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Overlaps reports whether any part of the session falls into the range
+func (r Range) Overlaps(s Session) bool {
+	end := s.End
+	if s.Running() {
+		end = time.Now()
+	}
+
+	if !r.From.IsZero() && end.Before(r.From) {
+		return false
+	}
+
+	if !r.To.IsZero() && !s.Start.Before(r.To) {
+		return false
+	}
+
+	return true
+}
+
+// Contains reports whether the moment falls into [From, To)
+func (r Range) Contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+func (r Range) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// SessionsIn returns sessions overlapping the range
+func (l *List) SessionsIn(r Range) []Session {
+	var res []Session
+	for _, s := range l.Sessions() {
+		if r.Overlaps(s) {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
+
+// OverlapError is returned when the new session overlaps tracked one
+type OverlapError struct {
+	With Session
+}
+
+func (e *OverlapError) Error() string {
+	end := "now"
+	if !e.With.Running() {
+		end = e.With.End.Format(time.DateTime)
+	}
+
+	return fmt.Sprintf("overlaps %q session %s - %s", e.With.Title, e.With.Start.Format(time.DateTime), end)
+}
+
+// AddSession inserts finished session into the list creating it if needed.
+// Session overlapping any tracked one is rejected with OverlapError
+func (elist *EntriesLists) AddSession(title ListTitle, start, end time.Time, notes []string) error {
+	return elist.InsertSession(Session{Title: title, Start: start, End: end, Notes: notes})
+}
+
+// InsertSession is AddSession keeping commits of the session
+func (elist *EntriesLists) InsertSession(added Session) error {
+	title, start, end := added.Title, added.Start, added.End
+	if !start.Before(end) {
+		return errors.New("session should end after its start")
+	}
+
+	for _, l := range elist.EntriesListsView {
+		for _, s := range l.Sessions() {
+			// sessions touching each other don't overlap
+			if end.After(s.Start) && (s.Running() || start.Before(s.End)) {
+				return &OverlapError{With: s}
+			}
+		}
+	}
+
+	l := elist.getOrCreate(title)
+	if start.Before(l.Created) {
+		l.Created = start
+	}
+	l.SetSessions(append(l.Sessions(), added))
+
+	return nil
+}
+
+// AddSessions inserts finished sessions like AddSession does, tracked
+// sessions are indexed once, so large imports don't scan them for every
+// session. Sessions overlapping each other are taken in order of their
+// start. It returns the error of every session, nil for the added ones
+func (elist *EntriesLists) AddSessions(sessions []Session) []error {
+	errs := make([]error, len(sessions))
+	index := newSessionIndex(elist)
+
+	order := make([]int, len(sessions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sessions[order[i]].Start.Before(sessions[order[j]].Start)
+	})
+
+	// added sessions don't overlap, the last one ends last
+	var last *Session
+	added := make(map[ListTitle][]Session)
+	for _, i := range order {
+		s := sessions[i]
+		if !s.Start.Before(s.End) {
+			errs[i] = errors.New("session should end after its start")
+			continue
+		}
+		if last != nil && s.Start.Before(last.End) {
+			errs[i] = &OverlapError{With: *last}
+			continue
+		}
+		if with, ok := index.overlap(s.Start, s.End); ok {
+			errs[i] = &OverlapError{With: with}
+			continue
+		}
+
+		added[s.Title] = append(added[s.Title], s)
+		last = &sessions[i]
+	}
+
+	for title, news := range added {
+		l := elist.getOrCreate(title)
+		for _, s := range news {
+			if s.Start.Before(l.Created) {
+				l.Created = s.Start
+			}
+		}
+		l.SetSessions(append(l.Sessions(), news...))
+	}
+
+	return errs
+}
+
+// sessionIndex keeps sessions ordered by start along with the one ending
+// last among every prefix, so an overlap is found by binary search
+type sessionIndex struct {
+	sessions []Session
+	// last[i] is the index of the session ending last in sessions[:i+1]
+	last []int
+}
+
+func newSessionIndex(elist *EntriesLists) *sessionIndex {
+	index := &sessionIndex{}
+	for _, l := range elist.EntriesListsView {
+		index.sessions = append(index.sessions, l.Sessions()...)
+	}
+	sort.Slice(index.sessions, func(i, j int) bool {
+		return index.sessions[i].Start.Before(index.sessions[j].Start)
+	})
+
+	index.last = make([]int, len(index.sessions))
+	for i, s := range index.sessions {
+		index.last[i] = i
+		if i > 0 && endsAfter(index.sessions[index.last[i-1]], s) {
+			index.last[i] = index.last[i-1]
+		}
+	}
+
+	return index
+}
+
+// overlap returns the session overlapping the interval
+func (index *sessionIndex) overlap(start, end time.Time) (Session, bool) {
+	before := sort.Search(len(index.sessions), func(i int) bool {
+		return !index.sessions[i].Start.Before(end)
+	})
+	if before == 0 {
+		return Session{}, false
+	}
+
+	s := index.sessions[index.last[before-1]]
+	if s.Running() || start.Before(s.End) {
+		return s, true
+	}
+
+	return Session{}, false
+}
+
+// endsAfter reports whether a ends after b, running sessions don't end
+func endsAfter(a, b Session) bool {
+	if b.Running() {
+		return false
+	}
+
+	return a.Running() || a.End.After(b.End)
+}
+
+// StartAt starts the list at the moment in the past, like the task was
+// started then. It's refused while any list is running and when a tracked
+// session ends after the moment
+func (elist *EntriesLists) StartAt(title ListTitle, at time.Time) error {
+	if running := elist.ActiveTitles(); len(running) > 0 {
+		return fmt.Errorf("%s is running", running[0])
+	}
+	for _, l := range elist.EntriesListsView {
+		for _, s := range l.Sessions() {
+			if s.End.After(at) {
+				return &OverlapError{With: s}
+			}
+		}
+	}
+
+	l := elist.getOrCreate(title)
+	if at.Before(l.Created) {
+		l.Created = at
+	}
+	l.Archived = false
+	l.appendAt(StatusActive, at)
+	elist.CurrentActive = title
+
+	return nil
+}
+
+// StopAt stops the running list at the moment in the past, which follows
+// the start of its session
+func (elist *EntriesLists) StopAt(title ListTitle, at time.Time) error {
+	l, ok := elist.EntriesListsView[title]
+	if !ok || !elist.IsActive(title) {
+		return errors.New("task isn't running")
+	}
+	if at.Before(l.last().Timestamp) {
+		return errors.New("session should end after its start")
+	}
+
+	l.appendAt(StatusStop, at)
+	if elist.isParallel(title) {
+		elist.unsetParallel(title)
+	} else {
+		elist.CurrentActive = ""
+	}
+	elist.LastActive = title
+
+	return nil
+}
+
+// SetSessions rebuilds states from sessions ordered by start recounting
+// total durations
+func (l *List) SetSessions(sessions []Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+
+	var total time.Duration
+	var states []*ListState
+	for _, s := range sessions {
+		states = append(states, &ListState{
+			Timestamp:     s.Start,
+			TotalDuration: total,
+			Status:        StatusActive,
+			Notes:         s.Notes,
+			Commits:       s.Commits,
+			Parallel:      s.Parallel,
+		})
+
+		if s.Running() {
+			continue
+		}
+
+		total += s.End.Sub(s.Start)
+		states = append(states, &ListState{
+			Timestamp:     s.End,
+			TotalDuration: total,
+			Status:        StatusStop,
+		})
+	}
+
+	l.States = states
+}
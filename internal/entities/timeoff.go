@@ -0,0 +1,90 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DayOffKind tells personal days off apart
+type DayOffKind string
+
+const (
+	PTO  DayOffKind = "pto"
+	Sick DayOffKind = "sick"
+)
+
+// DayOff is a personal day off. Zero Hours means the whole scheduled day
+type DayOff struct {
+	Id    uint64
+	Date  time.Time
+	Kind  DayOffKind
+	Hours time.Duration `json:",omitempty"`
+	Note  string        `json:",omitempty"`
+}
+
+// TimeOff keeps personal days off of all workspaces
+type TimeOff struct {
+	LastId uint64
+	Days   []DayOff
+}
+
+func InitTimeOff() *TimeOff {
+	return &TimeOff{}
+}
+
+// Add records the day off. A date can't be taken twice
+func (to *TimeOff) Add(day DayOff) (DayOff, error) {
+	if day.Kind != PTO && day.Kind != Sick {
+		return day, fmt.Errorf("unknown kind %s, use %s or %s", day.Kind, PTO, Sick)
+	}
+
+	if day.Hours < 0 || day.Hours > 24*time.Hour {
+		return day, errors.New("hours should be within a day")
+	}
+
+	day.Date = time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, day.Date.Location())
+	for _, d := range to.Days {
+		if sameDate(d.Date, day.Date) {
+			return day, fmt.Errorf("%s is already taken off", day.Date.Format(time.DateOnly))
+		}
+	}
+
+	to.LastId++
+	day.Id = to.LastId
+	to.Days = append(to.Days, day)
+	sort.Slice(to.Days, func(i, j int) bool { return to.Days[i].Date.Before(to.Days[j].Date) })
+
+	return day, nil
+}
+
+// Remove deletes the day off by its id
+func (to *TimeOff) Remove(id uint64) (DayOff, error) {
+	for i, d := range to.Days {
+		if d.Id == id {
+			to.Days = append(to.Days[:i], to.Days[i+1:]...)
+			return d, nil
+		}
+	}
+
+	return DayOff{}, fmt.Errorf("day off %d doesn't exist", id)
+}
+
+// In returns days off dated within the range. Dates are compared as
+// written, so they don't move with the time zone
+func (to *TimeOff) In(r Range) []DayOff {
+	var res []DayOff
+	for _, d := range to.Days {
+		date := d.Date.Format(time.DateOnly)
+		if (r.From.IsZero() || date >= r.From.Format(time.DateOnly)) && (r.To.IsZero() || date < r.To.Format(time.DateOnly)) {
+			res = append(res, d)
+		}
+	}
+
+	return res
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
@@ -0,0 +1,183 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TreeNode is the list with its depth in the hierarchy
+type TreeNode struct {
+	List  *List
+	Depth int
+}
+
+// AddList creates list without sessions if it doesn't exist
+func (elist *EntriesLists) AddList(title ListTitle) *List {
+	return elist.getOrCreate(title)
+}
+
+// SetParent moves list with its subtree under the parent. Empty parent makes
+// the list a root one
+func (elist *EntriesLists) SetParent(title, parent ListTitle) error {
+	l, ok := elist.EntriesListsView[title]
+	if !ok {
+		return errors.New("title doesn't exist")
+	}
+
+	if parent != emptyTitle {
+		if _, ok := elist.EntriesListsView[parent]; !ok {
+			return errors.New("parent doesn't exist")
+		}
+
+		for p := parent; p != emptyTitle; p = elist.parentOf(p) {
+			if p == title {
+				return errors.New("list can't be moved under itself")
+			}
+		}
+	}
+
+	l.Parent = parent
+
+	return nil
+}
+
+// Children returns direct children of the list sorted by title
+func (elist *EntriesLists) Children(title ListTitle) []*List {
+	var children []*List
+	for _, l := range elist.EntriesListsView {
+		if l.Parent == title && title != emptyTitle {
+			children = append(children, l)
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Title < children[j].Title
+	})
+
+	return children
+}
+
+// Descendants returns titles of the whole subtree of the list
+func (elist *EntriesLists) Descendants(title ListTitle) []ListTitle {
+	var res []ListTitle
+	for _, child := range elist.Children(title) {
+		res = append(res, child.Title)
+		res = append(res, elist.Descendants(child.Title)...)
+	}
+
+	return res
+}
+
+// RemoveTree moves the list with its subtree to the trash
+func (elist *EntriesLists) RemoveTree(title ListTitle) error {
+	if _, ok := elist.EntriesListsView[title]; !ok {
+		return errors.New("title doesn't exist")
+	}
+
+	descendants := elist.Descendants(title)
+	for _, t := range append(descendants, title) {
+		if _, ok := elist.Trash[t]; ok {
+			return fmt.Errorf("%s: %w", t, ErrTrashed)
+		}
+	}
+
+	// parents are kept, so restored lists return to their places
+	for _, child := range descendants {
+		elist.trash(child)
+	}
+	elist.trash(title)
+
+	return nil
+}
+
+// promoteChildren moves children of the list to its parent
+func (elist *EntriesLists) promoteChildren(title ListTitle) {
+	parent := elist.parentOf(title)
+	for _, child := range elist.Children(title) {
+		child.Parent = parent
+	}
+}
+
+func (elist *EntriesLists) parentOf(title ListTitle) ListTitle {
+	l, ok := elist.EntriesListsView[title]
+	if !ok || l == nil {
+		return emptyTitle
+	}
+
+	return l.Parent
+}
+
+// Tree orders lists depth first. List which parent isn't among the given
+// ones is a root
+func Tree(lists []*List) []TreeNode {
+	byTitle := make(map[ListTitle]bool)
+	children := make(map[ListTitle][]*List)
+	for _, l := range lists {
+		byTitle[l.Title] = true
+	}
+
+	var roots []*List
+	for _, l := range lists {
+		if l.Parent == emptyTitle || !byTitle[l.Parent] {
+			roots = append(roots, l)
+			continue
+		}
+		children[l.Parent] = append(children[l.Parent], l)
+	}
+
+	var res []TreeNode
+	var walk func(lists []*List, depth int)
+	walk = func(lists []*List, depth int) {
+		sort.Slice(lists, func(i, j int) bool {
+			return lists[i].Title < lists[j].Title
+		})
+
+		for _, l := range lists {
+			res = append(res, TreeNode{List: l, Depth: depth})
+			walk(children[l.Title], depth+1)
+		}
+	}
+	walk(roots, 0)
+
+	return res
+}
+
+// RollUp adds durations of children to their parents. Only the given lists
+// are taken into account
+func RollUp(nodes []TreeNode, totals map[ListTitle]time.Duration) map[ListTitle]time.Duration {
+	res := make(map[ListTitle]time.Duration)
+
+	// nodes are depth first, so the subtree of a node follows it
+	for i, node := range nodes {
+		res[node.List.Title] += totals[node.List.Title]
+		for _, child := range nodes[i+1:] {
+			if child.Depth <= node.Depth {
+				break
+			}
+			res[node.List.Title] += totals[child.List.Title]
+		}
+	}
+
+	return res
+}
+
+func treeTitleAggregate(title ListTitle, depth int) string {
+	if depth == 0 {
+		return titleAggregate(title)
+	}
+
+	indent := strings.Repeat("  ", depth-1) + "└ "
+	lines := strings.Split(titleAggregate(title), "\n")
+	for i := range lines {
+		if i == 0 {
+			lines[i] = indent + lines[i]
+			continue
+		}
+		lines[i] = strings.Repeat(" ", len([]rune(indent))) + lines[i]
+	}
+
+	return strings.Join(lines, "\n")
+}
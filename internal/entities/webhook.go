@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery is a webhook payload waiting to be sent again
+type Delivery struct {
+	Id uint64
+	// Target is the name of the webhook from the config, its url and
+	// secret are looked up when the delivery is sent
+	Target  string
+	Event   string
+	Payload json.RawMessage
+	Created time.Time
+	// Attempts is the number of failed sends, the next one is due at
+	// NextAttempt
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string `json:",omitempty"`
+}
+
+// WebhookQueue keeps failed deliveries of all workspaces
+type WebhookQueue struct {
+	LastId     uint64
+	Deliveries []*Delivery
+}
+
+func InitWebhookQueue() *WebhookQueue {
+	return &WebhookQueue{}
+}
+
+// Push adds the delivery due now
+func (q *WebhookQueue) Push(d Delivery) *Delivery {
+	q.LastId++
+	d.Id = q.LastId
+	q.Deliveries = append(q.Deliveries, &d)
+
+	return &d
+}
+
+// Remove deletes the delivery by its id
+func (q *WebhookQueue) Remove(id uint64) {
+	for i, d := range q.Deliveries {
+		if d.Id == id {
+			q.Deliveries = append(q.Deliveries[:i], q.Deliveries[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"errors"
+	"regexp"
+)
+
+// DefaultWorkspace keeps data tracked before workspaces were introduced
+const DefaultWorkspace = "default"
+
+var workspaceName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Workspaces is the registry of separate task lists. Each workspace has its
+// own tasks, tags and active task
+type Workspaces struct {
+	Current string
+	Names   []string
+}
+
+func InitWorkspaces() *Workspaces {
+	return &Workspaces{
+		Current: DefaultWorkspace,
+		Names:   []string{DefaultWorkspace},
+	}
+}
+
+func (ws *Workspaces) Has(name string) bool {
+	for _, n := range ws.Names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ws *Workspaces) Create(name string) error {
+	if !workspaceName.MatchString(name) {
+		return errors.New("workspace name may contain only lower case letters, digits, _ and -")
+	}
+
+	if ws.Has(name) {
+		return errors.New("workspace already exists")
+	}
+
+	ws.Names = append(ws.Names, name)
+
+	return nil
+}
+
+func (ws *Workspaces) Use(name string) error {
+	if !ws.Has(name) {
+		return errors.New("workspace doesn't exist")
+	}
+
+	ws.Current = name
+
+	return nil
+}
+
+// Delete removes workspace from the registry. Current workspace falls back to default
+func (ws *Workspaces) Delete(name string) error {
+	if name == DefaultWorkspace {
+		return errors.New("default workspace can't be deleted")
+	}
+
+	if !ws.Has(name) {
+		return errors.New("workspace doesn't exist")
+	}
+
+	var names []string
+	for _, n := range ws.Names {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	ws.Names = names
+
+	if ws.Current == name {
+		ws.Current = DefaultWorkspace
+	}
+
+	return nil
+}
@@ -14,4 +14,179 @@ var (
 		Name:      "tag",
 		Shorthand: "",
 	}
+
+	Archived = &pflag.Flag{
+		Name:      "archived",
+		Shorthand: "",
+	}
+
+	OlderThan = &pflag.Flag{
+		Name:      "older-than",
+		Shorthand: "",
+	}
+
+	Note = &pflag.Flag{
+		Name:      "note",
+		Shorthand: "",
+	}
+
+	Session = &pflag.Flag{
+		Name:      "session",
+		Shorthand: "",
+	}
+
+	Parallel = &pflag.Flag{
+		Name:      "parallel",
+		Shorthand: "",
+	}
+
+	Parent = &pflag.Flag{
+		Name:      "parent",
+		Shorthand: "",
+	}
+
+	Tree = &pflag.Flag{
+		Name:      "tree",
+		Shorthand: "",
+	}
+
+	Depth = &pflag.Flag{
+		Name:      "depth",
+		Shorthand: "",
+	}
+
+	Recursive = &pflag.Flag{
+		Name:      "recursive",
+		Shorthand: "r",
+	}
+
+	Git = &pflag.Flag{
+		Name:      "git",
+		Shorthand: "",
+	}
+
+	Issue = &pflag.Flag{
+		Name:      "issue",
+		Shorthand: "",
+	}
+
+	Format = &pflag.Flag{
+		Name:      "format",
+		Shorthand: "f",
+	}
+
+	Output = &pflag.Flag{
+		Name:      "output",
+		Shorthand: "o",
+	}
+
+	From = &pflag.Flag{
+		Name:      "from",
+		Shorthand: "",
+	}
+
+	To = &pflag.Flag{
+		Name:      "to",
+		Shorthand: "",
+	}
+
+	DryRun = &pflag.Flag{
+		Name:      "dry-run",
+		Shorthand: "",
+	}
+
+	Timezone = &pflag.Flag{
+		Name:      "timezone",
+		Shorthand: "",
+	}
+
+	AllWorkspaces = &pflag.Flag{
+		Name:      "all-workspaces",
+		Shorthand: "",
+	}
+
+	Mode = &pflag.Flag{
+		Name:      "mode",
+		Shorthand: "",
+	}
+
+	Gzip = &pflag.Flag{
+		Name:      "gzip",
+		Shorthand: "z",
+	}
+
+	Fix = &pflag.Flag{
+		Name:      "fix",
+		Shorthand: "",
+	}
+
+	NewKeyFile = &pflag.Flag{
+		Name:      "new-key-file",
+		Shorthand: "",
+	}
+
+	As = &pflag.Flag{
+		Name:      "as",
+		Shorthand: "",
+	}
+
+	Period = &pflag.Flag{
+		Name:      "period",
+		Shorthand: "",
+	}
+
+	Stack = &pflag.Flag{
+		Name:      "stack",
+		Shorthand: "",
+	}
+
+	Width = &pflag.Flag{
+		Name:      "width",
+		Shorthand: "",
+	}
+
+	NoColor = &pflag.Flag{
+		Name:      "no-color",
+		Shorthand: "",
+	}
+
+	Year = &pflag.Flag{
+		Name:      "year",
+		Shorthand: "",
+	}
+
+	Kind = &pflag.Flag{
+		Name:      "kind",
+		Shorthand: "",
+	}
+
+	Hours = &pflag.Flag{
+		Name:      "hours",
+		Shorthand: "",
+	}
+
+	Cron = &pflag.Flag{
+		Name:      "cron",
+		Shorthand: "",
+	}
+
+	Duration = &pflag.Flag{
+		Name:      "duration",
+		Shorthand: "",
+	}
+
+	AutoStart = &pflag.Flag{
+		Name:      "start",
+		Shorthand: "",
+	}
+
+	Interval = &pflag.Flag{
+		Name:      "interval",
+		Shorthand: "",
+	}
+
+	Listen = &pflag.Flag{
+		Name:      "listen",
+		Shorthand: "",
+	}
 )
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker marks lines added to git hooks by time tracker
+const hookMarker = "# time_tracker"
+
+// Repo is the git repository found from a working directory
+type Repo struct {
+	// Root is the working tree directory
+	Root string
+	// GitDir keeps HEAD, for worktrees it differs from CommonDir
+	GitDir    string
+	CommonDir string
+}
+
+// Open finds the repository containing dir by looking for .git in dir
+// and its parents
+func Open(dir string) (*Repo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			return open(dir, dotGit, info.IsDir())
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errors.New("not a git repository")
+		}
+		dir = parent
+	}
+}
+
+func open(root, dotGit string, isDir bool) (*Repo, error) {
+	r := &Repo{Root: root, GitDir: dotGit, CommonDir: dotGit}
+	if isDir {
+		return r, nil
+	}
+
+	// worktrees and submodules keep "gitdir: <path>" in .git file
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return nil, fmt.Errorf("unexpected content of %s", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+	r.GitDir, r.CommonDir = gitDir, gitDir
+
+	commonDir, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err == nil {
+		r.CommonDir = strings.TrimSpace(string(commonDir))
+		if !filepath.IsAbs(r.CommonDir) {
+			r.CommonDir = filepath.Join(gitDir, r.CommonDir)
+		}
+	}
+
+	return r, nil
+}
+
+// Name returns name of the repository directory
+func (r *Repo) Name() string {
+	return filepath.Base(r.Root)
+}
+
+// Branch returns checked out branch. Detached HEAD is returned as short hash
+func (r *Repo) Branch() (string, error) {
+	head, err := os.ReadFile(filepath.Join(r.GitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+
+	ref := strings.TrimSpace(string(head))
+	if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+		return branch, nil
+	}
+
+	if len(ref) < 7 {
+		return "", fmt.Errorf("unexpected HEAD %s", ref)
+	}
+
+	return ref[:7], nil
+}
+
+// InstallHook appends command to the hook script creating it if needed.
+// Repeated install keeps a single copy of the command
+func (r *Repo) InstallHook(hook, command string) error {
+	dir := filepath.Join(r.CommonDir, "hooks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, hook)
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	script := removeMarked(string(content))
+	if strings.TrimSpace(script) == "" {
+		script = "#!/bin/sh\n"
+	}
+	if !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	script += fmt.Sprintf("%s %s\n", command, hookMarker)
+
+	return os.WriteFile(path, []byte(script), 0755)
+}
+
+// UninstallHook removes lines added by InstallHook
+func (r *Repo) UninstallHook(hook string) error {
+	path := filepath.Join(r.CommonDir, "hooks", hook)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(removeMarked(string(content))), 0755)
+}
+
+func removeMarked(script string) string {
+	var lines []string
+	for _, line := range strings.SplitAfter(script, "\n") {
+		if !strings.Contains(line, hookMarker) {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Repo(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "project")
+	gitDir := filepath.Join(root, ".git")
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "sub", "dir"), 0755))
+	assert.NoError(t, os.MkdirAll(gitDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature/login\n"), 0644))
+
+	repo, err := Open(filepath.Join(root, "sub", "dir"))
+	assert.NoError(t, err)
+	assert.Equal(t, "project", repo.Name())
+
+	branch, err := repo.Branch()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/login", branch)
+
+	t.Run("worktree keeps HEAD apart and hooks in common dir", func(t *testing.T) {
+		worktree := filepath.Join(t.TempDir(), "wt")
+		wtGitDir := filepath.Join(gitDir, "worktrees", "wt")
+		assert.NoError(t, os.MkdirAll(worktree, 0755))
+		assert.NoError(t, os.MkdirAll(wtGitDir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+wtGitDir+"\n"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(wtGitDir, "commondir"), []byte("../..\n"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(wtGitDir, "HEAD"), []byte("0123456789abcdef\n"), 0644))
+
+		wt, err := Open(worktree)
+		assert.NoError(t, err)
+		assert.Equal(t, gitDir, wt.CommonDir)
+
+		branch, err := wt.Branch()
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456", branch)
+	})
+
+	t.Run("hook install is idempotent and keeps user lines", func(t *testing.T) {
+		hook := filepath.Join(gitDir, "hooks", "post-commit")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(hook), 0755))
+		assert.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\necho user\n"), 0755))
+
+		assert.NoError(t, repo.InstallHook("post-commit", "tracker record"))
+		assert.NoError(t, repo.InstallHook("post-commit", "tracker record"))
+
+		content, _ := os.ReadFile(hook)
+		assert.Equal(t, 1, strings.Count(string(content), "tracker record"))
+		assert.Contains(t, string(content), "echo user")
+
+		assert.NoError(t, repo.UninstallHook("post-commit"))
+		content, _ = os.ReadFile(hook)
+		assert.Equal(t, "#!/bin/sh\necho user\n", string(content))
+	})
+}
@@ -0,0 +1,196 @@
+// Package gitsync merges tasks tracked on several devices through a git
+// repository. Every device appends its changes to its own log file, so logs
+// never conflict in git, and every device folds all the logs into the same
+// state.
+package gitsync
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+const (
+	// EventStart is a session started and not stopped yet
+	EventStart = "start"
+	// EventSession is a finished session, it replaces the started one
+	EventSession = "session"
+	EventTag     = "tag"
+	EventUntag   = "untag"
+	EventParent  = "parent"
+	EventRemove  = "remove"
+	EventRestore = "restore"
+)
+
+// logsDir keeps a log file per device in the sync repository
+const logsDir = "devices"
+
+// Event is a line of the device log. Events are never changed once written
+type Event struct {
+	Device string `json:"device"`
+	// Seq orders events of the device
+	Seq int `json:"seq"`
+	// Time is the moment the event was recorded, it orders events of devices
+	Time      time.Time          `json:"time"`
+	Type      string             `json:"type"`
+	Workspace string             `json:"workspace"`
+	Title     entities.ListTitle `json:"title"`
+	Start     *time.Time         `json:"start,omitempty"`
+	End       *time.Time         `json:"end,omitempty"`
+	// Parallel is set for sessions started along with the current one
+	Parallel bool               `json:"parallel,omitempty"`
+	Tag      entities.Tag       `json:"tag,omitempty"`
+	Parent   entities.ListTitle `json:"parent,omitempty"`
+	Notes    []string           `json:"notes,omitempty"`
+	Commits  []entities.Commit  `json:"commits,omitempty"`
+}
+
+func logPath(dir, device string) string {
+	return filepath.Join(dir, logsDir, device+".jsonl")
+}
+
+// Sealer encrypts lines of the logs, vault.Cipher implements it
+type Sealer interface {
+	Seal(plain []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// ErrEncrypted is returned when sealed logs are read without the key
+var ErrEncrypted = errors.New("sync logs are encrypted, enable encryption with the same passphrase or key file to sync")
+
+// ReadLogs reads logs of all the devices ordered by time. Sealed lines are
+// opened with s, plain lines written before encryption are read as they are
+func ReadLogs(dir string, s Sealer) ([]Event, error) {
+	files, err := filepath.Glob(filepath.Join(dir, logsDir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, file := range files {
+		read, err := readLog(file, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		events = append(events, read...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Device != b.Device {
+			return a.Device < b.Device
+		}
+		return a.Seq < b.Seq
+	})
+
+	return events, nil
+}
+
+func readLog(path string, s Sealer) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		data := []byte(text)
+		if !strings.HasPrefix(text, "{") {
+			if s == nil {
+				return nil, ErrEncrypted
+			}
+
+			sealed, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if data, err = s.Open(sealed); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		var e Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+
+	return events, scanner.Err()
+}
+
+// AppendLog appends events to the log of the device numbering them. Lines
+// are sealed with s unless it's nil
+func AppendLog(dir, device string, events []Event, s Sealer) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	path := logPath(dir, device)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	existing, err := readLog(path, s)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	seq := 0
+	if len(existing) > 0 {
+		seq = existing[len(existing)-1].Seq
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, e := range events {
+		seq++
+		e.Device, e.Seq = device, seq
+
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if s != nil {
+			sealed, err := s.Seal(line)
+			if err != nil {
+				return err
+			}
+			line = []byte(base64.StdEncoding.EncodeToString(sealed))
+		}
+
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
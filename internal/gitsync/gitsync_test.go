@@ -0,0 +1,209 @@
+package gitsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+var day = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(hour int) *time.Time {
+	t := day.Add(time.Duration(hour) * time.Hour)
+	return &t
+}
+
+func Test_Fold(t *testing.T) {
+	events := []Event{
+		{Device: "laptop", Seq: 1, Time: *at(9), Type: EventSession, Workspace: "default", Title: "api", Start: at(8), End: at(9)},
+		{Device: "desktop", Seq: 1, Time: *at(10), Type: EventSession, Workspace: "default", Title: "api", Start: at(8), End: at(10)},
+		{Device: "laptop", Seq: 2, Time: *at(11), Type: EventStart, Workspace: "default", Title: "api", Start: at(11)},
+		{Device: "desktop", Seq: 2, Time: *at(12), Type: EventStart, Workspace: "default", Title: "docs", Start: at(12)},
+		{Device: "desktop", Seq: 3, Time: *at(12), Type: EventStart, Workspace: "default", Title: "build", Start: at(12), Parallel: true},
+		{Device: "laptop", Seq: 3, Time: *at(13), Type: EventTag, Workspace: "default", Title: "api", Tag: "#work"},
+	}
+
+	state, conflicts := Fold(events)
+	ws := state.Workspaces["default"]
+
+	// the same time tracked on both devices is counted once
+	api := ws.Tasks["api"]
+	assert.Equal(t, []entities.Session{
+		{Title: "api", Start: *at(8), End: *at(10)},
+		{Title: "api", Start: *at(11), End: *at(12)},
+	}, api.Sessions)
+	assert.Nil(t, api.Running)
+	assert.True(t, api.Tags["#work"])
+
+	assert.Equal(t, []Conflict{{
+		Workspace:    "default",
+		Title:        "api",
+		Device:       "laptop",
+		Winner:       "docs",
+		WinnerDevice: "desktop",
+		Stopped:      *at(12),
+	}}, conflicts)
+
+	current, parallel := ws.active()
+	assert.Equal(t, entities.ListTitle("docs"), current)
+	assert.Equal(t, []entities.ListTitle{"build"}, parallel)
+
+	// order of logs doesn't matter, a task started earlier loses as well
+	reversed := append([]Event{}, events[:2]...)
+	reversed = append(reversed, events[3], events[2])
+	state, conflicts = Fold(reversed)
+	assert.Equal(t, 1, len(conflicts))
+	assert.NotNil(t, state.Workspaces["default"].Tasks["docs"].Running)
+	assert.Nil(t, state.Workspaces["default"].Tasks["api"].Running)
+
+	// stopping the winner doesn't resume the stopped task
+	stopped := append(events, Event{Device: "desktop", Seq: 4, Time: *at(14), Type: EventSession, Workspace: "default", Title: "docs", Start: at(12), End: at(14)})
+	state, _ = Fold(stopped)
+	current, _ = state.Workspaces["default"].active()
+	assert.Equal(t, entities.ListTitle(""), current)
+}
+
+func Test_ParallelRoundTrip(t *testing.T) {
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", *at(9), *at(11), nil))
+	elist.AddList("build").SetSessions([]entities.Session{
+		{Title: "build", Start: *at(9), End: *at(10), Parallel: true},
+		{Title: "build", Start: *at(12), Parallel: true},
+	})
+	elist.Parallel = []entities.ListTitle{"build"}
+
+	state, _ := Fold(Diff(&State{Workspaces: make(map[string]*Workspace)}, entities.DefaultWorkspace, elist, *at(13)))
+	assert.True(t, state.Workspaces[entities.DefaultWorkspace].Tasks["build"].Sessions[0].Parallel)
+
+	// applying the state on every sync keeps the flags
+	Apply(state, entities.DefaultWorkspace, elist)
+	other := entities.InitEmptyElist()
+	Apply(state, entities.DefaultWorkspace, other)
+	for _, synced := range []*entities.EntriesLists{elist, other} {
+		sessions := synced.EntriesListsView["build"].Sessions()
+		assert.Equal(t, 2, len(sessions))
+		assert.True(t, sessions[0].Parallel)
+		assert.True(t, sessions[1].Parallel)
+		assert.False(t, synced.EntriesListsView["api"].Sessions()[0].Parallel)
+		assert.Equal(t, []entities.ListTitle{"build"}, synced.Parallel)
+	}
+
+	// nothing is published again
+	assert.Empty(t, Diff(state, entities.DefaultWorkspace, elist, *at(14)))
+}
+
+// syncDevice runs the same steps as sync command for the single workspace
+func syncDevice(t *testing.T, repo *Repo, device string, elist *entities.EntriesLists) []Change {
+	base, _, err := repo.Base()
+	assert.NoError(t, err)
+
+	events := Diff(base, entities.DefaultWorkspace, elist, day.Add(24*time.Hour))
+	assert.NoError(t, repo.Publish(device, events))
+
+	state, _, err := repo.State()
+	assert.NoError(t, err)
+
+	changes := Apply(state, entities.DefaultWorkspace, elist)
+	assert.NoError(t, repo.SaveBase())
+
+	return changes
+}
+
+func Test_Sync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	_, err := git.Run(dir, "init", "--quiet", "--bare", remote)
+	assert.NoError(t, err)
+
+	laptop, err := Open(filepath.Join(dir, "laptop"), remote)
+	assert.NoError(t, err)
+	desktop, err := Open(filepath.Join(dir, "desktop"), remote)
+	assert.NoError(t, err)
+
+	onLaptop := entities.InitEmptyElist()
+	assert.NoError(t, onLaptop.AddSession("api", *at(9), *at(10), []string{"review"}))
+	onLaptop.AddTag("#work", "api")
+	assert.Empty(t, syncDevice(t, laptop, "laptop", onLaptop))
+
+	onDesktop := entities.InitEmptyElist()
+	assert.NoError(t, onDesktop.AddSession("docs", *at(11), *at(12), nil))
+	changes := syncDevice(t, desktop, "desktop", onDesktop)
+	assert.Equal(t, Change{Workspace: entities.DefaultWorkspace, Title: "api", Message: "added"}, changes[0])
+	assert.Equal(t, time.Hour, onDesktop.EntriesListsView["api"].Total())
+	assert.Equal(t, []string{"review"}, onDesktop.EntriesListsView["api"].Notes())
+	assert.Equal(t, 1, len(onDesktop.Filter([]entities.Tag{"#work"}, entities.ContainsAll)))
+
+	// both devices changed the list before syncing
+	onDesktop.RemoveByTitle("api")
+	assert.NoError(t, onLaptop.AddSession("api", *at(13), *at(14), nil))
+	syncDevice(t, laptop, "laptop", onLaptop)
+	syncDevice(t, desktop, "desktop", onDesktop)
+	syncDevice(t, laptop, "laptop", onLaptop)
+
+	for _, elist := range []*entities.EntriesLists{onLaptop, onDesktop} {
+		_, ok := elist.EntriesListsView["api"]
+		assert.False(t, ok)
+		assert.Equal(t, time.Hour, elist.EntriesListsView["docs"].Total())
+	}
+
+	// nothing is published again
+	base, _, err := laptop.Base()
+	assert.NoError(t, err)
+	assert.Empty(t, Diff(base, entities.DefaultWorkspace, onLaptop, day))
+}
+
+func Test_SyncEncrypted(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	_, err := git.Run(dir, "init", "--quiet", "--bare", remote)
+	assert.NoError(t, err)
+
+	laptop, err := Open(filepath.Join(dir, "laptop"), remote)
+	assert.NoError(t, err)
+	assert.NoError(t, laptop.UseKey([]byte("secret")))
+
+	onLaptop := entities.InitEmptyElist()
+	assert.NoError(t, onLaptop.AddSession("payroll api", *at(9), *at(10), []string{"salary review"}))
+	onLaptop.AddTag("#confidential", "payroll api")
+	syncDevice(t, laptop, "laptop", onLaptop)
+
+	// nothing readable is published
+	published := filepath.Join(dir, "published")
+	_, err = git.Run(dir, "clone", "--quiet", "--branch", branch, remote, published)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(logPath(published, "laptop"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, data)
+	for _, plain := range []string{"payroll", "salary", "confidential", "laptop", "{"} {
+		assert.NotContains(t, string(data), plain)
+	}
+
+	desktop, err := Open(filepath.Join(dir, "desktop"), remote)
+	assert.NoError(t, err)
+	assert.NoError(t, desktop.UseKey([]byte("secret")))
+	onDesktop := entities.InitEmptyElist()
+	syncDevice(t, desktop, "desktop", onDesktop)
+	assert.Equal(t, []string{"salary review"}, onDesktop.EntriesListsView["payroll api"].Notes())
+
+	plain, err := Open(filepath.Join(dir, "plain"), remote)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, plain.UseKey(nil), ErrEncrypted)
+	assert.Error(t, plain.UseKey([]byte("other")))
+
+	_, err = ReadLogs(published, nil)
+	assert.ErrorIs(t, err, ErrEncrypted)
+}
@@ -0,0 +1,268 @@
+package gitsync
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Change is a difference applied to the local lists by sync
+type Change struct {
+	Workspace string
+	Title     entities.ListTitle
+	Message   string
+}
+
+func (c Change) AggregateRow() []interface{} {
+	return []interface{}{c.Workspace, c.Title, c.Message}
+}
+
+// Diff returns events turning the base state, folded at the previous sync,
+// into the local lists of the workspace
+func Diff(base *State, workspace string, elist *entities.EntriesLists, now time.Time) []Event {
+	known := &Workspace{Tasks: make(map[entities.ListTitle]*Task)}
+	if ws, ok := base.Workspaces[workspace]; ok {
+		known = ws
+	}
+
+	var events []Event
+	add := func(e Event) {
+		e.Time, e.Workspace = now, workspace
+		events = append(events, e)
+	}
+
+	lists := elist.Lists()
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Title < lists[j].Title })
+
+	for _, l := range lists {
+		t, ok := known.Tasks[l.Title]
+		if !ok {
+			t = &Task{Tags: make(map[entities.Tag]bool)}
+		}
+
+		if t.Removed {
+			add(Event{Type: EventRestore, Title: l.Title})
+		}
+
+		for _, s := range l.Sessions() {
+			start := s.Start
+			if s.Running() {
+				r := t.Running
+				if r == nil || !r.Start.Equal(s.Start) || !sameNotes(r.Notes, s.Notes) {
+					add(Event{
+						Type:     EventStart,
+						Title:    l.Title,
+						Start:    &start,
+						Parallel: l.Title != elist.CurrentActive,
+						Notes:    s.Notes,
+						Commits:  s.Commits,
+					})
+				}
+				continue
+			}
+
+			if !t.hasSession(s) {
+				end := s.End
+				add(Event{
+					Type:     EventSession,
+					Title:    l.Title,
+					Start:    &start,
+					End:      &end,
+					Parallel: s.Parallel,
+					Notes:    s.Notes,
+					Commits:  s.Commits,
+				})
+			}
+		}
+
+		local := make(map[entities.Tag]bool)
+		for _, tag := range l.Tags {
+			local[tag] = true
+			if !t.Tags[tag] {
+				add(Event{Type: EventTag, Title: l.Title, Tag: tag})
+			}
+		}
+		for _, tag := range sortedTags(t.Tags) {
+			if !local[tag] {
+				add(Event{Type: EventUntag, Title: l.Title, Tag: tag})
+			}
+		}
+
+		if l.Parent != t.Parent {
+			add(Event{Type: EventParent, Title: l.Title, Parent: l.Parent})
+		}
+	}
+
+	for _, title := range known.titles() {
+		if _, ok := elist.EntriesListsView[title]; !ok && !known.Tasks[title].Removed {
+			add(Event{Type: EventRemove, Title: title})
+		}
+	}
+
+	return events
+}
+
+// hasSession reports whether the finished session is known as is. Sessions
+// synced before the flag was kept are published again once to mark them
+// parallel
+func (t *Task) hasSession(s entities.Session) bool {
+	for _, known := range t.Sessions {
+		if known.Start.Equal(s.Start) && known.End.Equal(s.End) && sameNotes(known.Notes, s.Notes) &&
+			(known.Parallel || !s.Parallel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply makes tasks of the workspace equal to the folded state. Tasks which
+// were never synced are kept as is
+func Apply(state *State, workspace string, elist *entities.EntriesLists) []Change {
+	ws, ok := state.Workspaces[workspace]
+	if !ok {
+		return nil
+	}
+
+	var changes []Change
+	change := func(title entities.ListTitle, format string, args ...interface{}) {
+		changes = append(changes, Change{Workspace: workspace, Title: title, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, title := range ws.titles() {
+		t := ws.Tasks[title]
+		l, exists := elist.EntriesListsView[title]
+
+		if t.Removed {
+			if exists {
+				if err := elist.RemoveByTitle(title); err != nil {
+					change(title, "isn't removed: %v", err)
+				} else {
+					change(title, "removed")
+				}
+			}
+			continue
+		}
+
+		if !exists {
+			if elist.RestoreByTitle(title) == nil {
+				l = elist.EntriesListsView[title]
+				change(title, "restored")
+			} else {
+				l = elist.AddList(title)
+				change(title, "added")
+			}
+		}
+
+		sessions := t.sessions(title)
+		if added := countNew(l.Sessions(), sessions); added > 0 {
+			change(title, "%d sessions added or extended", added)
+		}
+		l.SetSessions(sessions)
+		if len(sessions) > 0 && !exists {
+			l.Created = sessions[0].Start
+		}
+
+		local := make(map[entities.Tag]bool)
+		for _, tag := range l.Tags {
+			local[tag] = true
+			if !t.Tags[tag] {
+				change(title, "tag %s removed", tag)
+			}
+		}
+		for _, tag := range sortedTags(t.Tags) {
+			if !local[tag] {
+				change(title, "tag %s added", tag)
+			}
+		}
+		l.Tags = sortedTags(t.Tags)
+	}
+
+	// parents are set once all the tasks exist
+	for _, title := range ws.titles() {
+		t := ws.Tasks[title]
+		l, ok := elist.EntriesListsView[title]
+		if !ok || l.Parent == t.Parent {
+			continue
+		}
+		if elist.SetParent(title, t.Parent) == nil {
+			change(title, "moved under %q", t.Parent)
+		}
+	}
+
+	current, parallel := ws.active()
+	if current != elist.CurrentActive {
+		if current != "" {
+			change(current, "is active now")
+		} else {
+			change(elist.CurrentActive, "stopped")
+		}
+	}
+	elist.CurrentActive, elist.Parallel = current, parallel
+	if _, ok := elist.EntriesListsView[elist.LastActive]; !ok {
+		elist.LastActive = ""
+	}
+
+	elist.ReindexTags()
+
+	return changes
+}
+
+// active returns the running task which isn't parallel and the parallel ones
+func (ws *Workspace) active() (entities.ListTitle, []entities.ListTitle) {
+	var current entities.ListTitle
+	var parallel []entities.ListTitle
+	for _, title := range ws.titles() {
+		r := ws.Tasks[title].Running
+		switch {
+		case r == nil:
+		case r.Parallel:
+			parallel = append(parallel, title)
+		default:
+			current = title
+		}
+	}
+
+	return current, parallel
+}
+
+// countNew returns the number of sessions which differ from the old ones
+func countNew(old, sessions []entities.Session) int {
+	var n int
+	for _, s := range sessions {
+		found := false
+		for _, o := range old {
+			found = found || (o.Start.Equal(s.Start) && o.End.Equal(s.End))
+		}
+		if !found {
+			n++
+		}
+	}
+
+	return n
+}
+
+func sameNotes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedTags(tags map[entities.Tag]bool) []entities.Tag {
+	var res []entities.Tag
+	for tag := range tags {
+		res = append(res, tag)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
@@ -0,0 +1,235 @@
+package gitsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Unheilbar/time_tracker/internal/git"
+	"github.com/Unheilbar/time_tracker/internal/vault"
+)
+
+const (
+	// branch keeps the logs in the sync repository
+	branch = "main"
+
+	// baseDir keeps logs applied to the local lists by the last sync. It's
+	// inside .git so it's never committed
+	baseDir = "time_tracker_base"
+
+	pushAttempts = 3
+
+	// vaultFile keeps parameters of the key sealing the logs, so devices
+	// with the same secret derive the same key
+	vaultFile = "vault.json"
+)
+
+// Repo is the local clone of the sync repository
+type Repo struct {
+	Dir string
+
+	sealer Sealer
+}
+
+// Open clones remote into dir unless it's cloned already
+func Open(dir, remote string) (*Repo, error) {
+	r := &Repo{Dir: dir}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if remote != "" {
+			if _, err := r.git("remote", "set-url", "origin", remote); err != nil {
+				return nil, err
+			}
+		}
+		return r, nil
+	}
+
+	if remote == "" {
+		return nil, errors.New("provide repository to sync with")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0774); err != nil {
+		return nil, err
+	}
+
+	if _, err := git.Run(filepath.Dir(dir), "clone", "--quiet", remote, dir); err != nil {
+		return nil, err
+	}
+
+	// cloned empty repository has no branch yet
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		if _, err := r.git("symbolic-ref", "HEAD", "refs/heads/"+branch); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// UseKey seals logs with a key derived from the secret. Parameters of the
+// key are taken from the repository or committed to it by the first device.
+// Nil secret keeps logs plain, it fails when the repository is encrypted
+func (r *Repo) UseKey(secret []byte) error {
+	if err := r.pull(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(r.Dir, vaultFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	exists := err == nil
+
+	switch {
+	case secret == nil && exists:
+		return ErrEncrypted
+	case secret == nil:
+		return nil
+	case exists:
+		p, err := vault.Unmarshal(data)
+		if err != nil {
+			return err
+		}
+		c, err := p.Unlock(secret)
+		if errors.Is(err, vault.ErrWrongKey) {
+			return errors.New("sync repository is encrypted with another passphrase or key file")
+		}
+		if err != nil {
+			return err
+		}
+		r.sealer = c
+
+		return nil
+	}
+
+	p, c, err := vault.New(secret)
+	if err != nil {
+		return err
+	}
+	if data, err = p.Marshal(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	if _, err := r.git("add", vaultFile); err != nil {
+		return err
+	}
+	if _, err := r.git("commit", "--quiet", "-m", "encrypt logs"); err != nil {
+		return err
+	}
+	r.sealer = c
+
+	return nil
+}
+
+func (r *Repo) git(args ...string) (string, error) {
+	// commits are made by the tracker, user identity may be not configured
+	args = append([]string{"-c", "user.name=time_tracker", "-c", "user.email=time_tracker@localhost"}, args...)
+	return git.Run(r.Dir, args...)
+}
+
+// Base folds logs applied by the previous sync
+func (r *Repo) Base() (*State, []Conflict, error) {
+	events, err := ReadLogs(filepath.Join(r.Dir, ".git", baseDir), r.sealer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, conflicts := Fold(events)
+
+	return state, conflicts, nil
+}
+
+// State folds logs of all the devices in the clone
+func (r *Repo) State() (*State, []Conflict, error) {
+	events, err := ReadLogs(r.Dir, r.sealer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, conflicts := Fold(events)
+
+	return state, conflicts, nil
+}
+
+// Publish appends events to the device log, commits it and exchanges commits
+// with the remote. Push rejected because of another device is retried after
+// the next pull
+func (r *Repo) Publish(device string, events []Event) error {
+	if err := AppendLog(r.Dir, device, events, r.sealer); err != nil {
+		return err
+	}
+
+	if len(events) > 0 {
+		if _, err := r.git("add", filepath.Join(logsDir, device+".jsonl")); err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("%s: %d events", device, len(events))
+		if _, err := r.git("commit", "--quiet", "-m", msg); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	for i := 0; i < pushAttempts; i++ {
+		if err = r.pull(); err != nil {
+			return err
+		}
+
+		if _, err := r.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+			// nothing tracked anywhere yet
+			return nil
+		}
+
+		if _, err = r.git("push", "--quiet", "origin", "HEAD:"+branch); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (r *Repo) pull() error {
+	heads, err := r.git("ls-remote", "--heads", "origin", branch)
+	if err != nil || heads == "" {
+		return err
+	}
+
+	_, err = r.git("pull", "--quiet", "--rebase", "origin", branch)
+
+	return err
+}
+
+// SaveBase remembers the logs as applied to the local lists
+func (r *Repo) SaveBase() error {
+	base := filepath.Join(r.Dir, ".git", baseDir, logsDir)
+	if err := os.RemoveAll(base); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.Dir, logsDir, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(file, filepath.Join(r.Dir, logsDir))
+		if err := os.WriteFile(filepath.Join(base, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package gitsync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// State is the result of folding the logs of all devices
+type State struct {
+	Workspaces map[string]*Workspace
+}
+
+// Workspace keeps tasks of a single workspace
+type Workspace struct {
+	Tasks map[entities.ListTitle]*Task
+}
+
+// Task is the merged task. Finished sessions are ordered and don't overlap
+type Task struct {
+	Sessions []entities.Session
+	Running  *Running
+	Tags     map[entities.Tag]bool
+	Parent   entities.ListTitle
+	Removed  bool
+}
+
+// Running is the session which isn't stopped yet
+type Running struct {
+	Start    time.Time
+	Device   string
+	Parallel bool
+	Notes    []string
+	Commits  []entities.Commit
+}
+
+// Conflict is a running task stopped because another device started a task
+// later. Only one task which isn't parallel can run at once
+type Conflict struct {
+	Workspace string
+	Title     entities.ListTitle
+	Device    string
+	// Winner is the task started later on Winner device
+	Winner       entities.ListTitle
+	WinnerDevice string
+	Stopped      time.Time
+}
+
+// Fold applies events in order. The result depends only on the events, so
+// every device gets the same state from the same logs
+func Fold(events []Event) (*State, []Conflict) {
+	s := &State{Workspaces: make(map[string]*Workspace)}
+
+	var conflicts []Conflict
+	for _, e := range events {
+		t := s.task(e.Workspace, e.Title)
+
+		switch e.Type {
+		case EventStart:
+			if e.Start == nil {
+				continue
+			}
+			r := &Running{Start: *e.Start, Device: e.Device, Parallel: e.Parallel, Notes: e.Notes, Commits: e.Commits}
+			// the task is running on several devices since the earliest start
+			if t.Running != nil && t.Running.Start.Before(r.Start) {
+				r.Start = t.Running.Start
+				r.Notes = appendNew(t.Running.Notes, r.Notes...)
+				r.Commits = appendNewCommits(t.Running.Commits, r.Commits...)
+			}
+			t.Running = r
+			if !r.Parallel {
+				conflicts = append(conflicts, s.Workspaces[e.Workspace].resolve(e.Workspace, e.Title)...)
+			}
+		case EventSession:
+			if e.Start == nil || e.End == nil {
+				continue
+			}
+			t.Sessions = append(t.Sessions, entities.Session{
+				Title:    e.Title,
+				Start:    *e.Start,
+				End:      *e.End,
+				Notes:    e.Notes,
+				Commits:  e.Commits,
+				Parallel: e.Parallel,
+			})
+			if t.Running != nil && t.Running.Start.Equal(*e.Start) {
+				t.Running = nil
+			}
+		case EventTag:
+			t.Tags[e.Tag] = true
+		case EventUntag:
+			delete(t.Tags, e.Tag)
+		case EventParent:
+			t.Parent = e.Parent
+		case EventRemove:
+			t.Removed = true
+			t.Running = nil
+		case EventRestore:
+			t.Removed = false
+		}
+	}
+
+	for _, ws := range s.Workspaces {
+		for _, t := range ws.Tasks {
+			t.normalize()
+		}
+	}
+
+	return s, conflicts
+}
+
+// NewConflicts returns conflicts which aren't among the old ones
+func NewConflicts(old, conflicts []Conflict) []Conflict {
+	var res []Conflict
+	for _, c := range conflicts {
+		found := false
+		for _, o := range old {
+			found = found || (o.Workspace == c.Workspace && o.Title == c.Title && o.Device == c.Device &&
+				o.Winner == c.Winner && o.Stopped.Equal(c.Stopped))
+		}
+		if !found {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+func (s *State) task(workspace string, title entities.ListTitle) *Task {
+	ws, ok := s.Workspaces[workspace]
+	if !ok {
+		ws = &Workspace{Tasks: make(map[entities.ListTitle]*Task)}
+		s.Workspaces[workspace] = ws
+	}
+
+	t, ok := ws.Tasks[title]
+	if !ok {
+		t = &Task{Tags: make(map[entities.Tag]bool)}
+		ws.Tasks[title] = t
+	}
+
+	return t
+}
+
+// Names returns workspace names in order
+func (s *State) Names() []string {
+	var names []string
+	for name := range s.Workspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// normalize merges overlapping sessions, as the same time could be tracked
+// on several devices, and joins the running session with finished ones it
+// overlaps. The merged finished session is parallel if any device started it
+// so, the running one keeps its own flag as it decides the current task
+func (t *Task) normalize() {
+	sort.SliceStable(t.Sessions, func(i, j int) bool {
+		return t.Sessions[i].Start.Before(t.Sessions[j].Start)
+	})
+
+	var merged []entities.Session
+	for _, s := range t.Sessions {
+		if n := len(merged); n > 0 && s.Start.Before(merged[n-1].End) {
+			last := &merged[n-1]
+			if s.End.After(last.End) {
+				last.End = s.End
+			}
+			last.Notes = appendNew(last.Notes, s.Notes...)
+			last.Commits = appendNewCommits(last.Commits, s.Commits...)
+			last.Parallel = last.Parallel || s.Parallel
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	if r := t.Running; r != nil {
+		for len(merged) > 0 && merged[len(merged)-1].End.After(r.Start) {
+			last := merged[len(merged)-1]
+			if last.Start.Before(r.Start) {
+				r.Start = last.Start
+			}
+			r.Notes = appendNew(last.Notes, r.Notes...)
+			r.Commits = appendNewCommits(last.Commits, r.Commits...)
+			merged = merged[:len(merged)-1]
+		}
+	}
+
+	t.Sessions = merged
+}
+
+// resolve keeps running the task started last once the title was started.
+// The other task which isn't parallel is stopped when the winner was started.
+// Tasks of the same device are switched by its own events, so only tasks
+// started on different devices are reported as conflicts
+func (ws *Workspace) resolve(name string, started entities.ListTitle) []Conflict {
+	var conflicts []Conflict
+	for _, title := range ws.titles() {
+		other := ws.Tasks[title]
+		if title == started || other.Running == nil || other.Running.Parallel {
+			continue
+		}
+
+		winner, loser := started, title
+		if other.Running.Start.After(ws.Tasks[started].Running.Start) {
+			winner, loser = title, started
+		}
+
+		w, l := ws.Tasks[winner].Running, ws.Tasks[loser]
+		if l.Running.Device != w.Device {
+			conflicts = append(conflicts, Conflict{
+				Workspace:    name,
+				Title:        loser,
+				Device:       l.Running.Device,
+				Winner:       winner,
+				WinnerDevice: w.Device,
+				Stopped:      w.Start,
+			})
+		}
+
+		l.stop(loser, w.Start)
+		if loser == started {
+			break
+		}
+	}
+
+	return conflicts
+}
+
+// stop finishes the running session at the moment
+func (t *Task) stop(title entities.ListTitle, at time.Time) {
+	r := t.Running
+	t.Running = nil
+	if !r.Start.Before(at) {
+		return
+	}
+
+	t.Sessions = append(t.Sessions, entities.Session{
+		Title:    title,
+		Start:    r.Start,
+		End:      at,
+		Notes:    r.Notes,
+		Commits:  r.Commits,
+		Parallel: r.Parallel,
+	})
+}
+
+// titles returns task titles in order
+func (ws *Workspace) titles() []entities.ListTitle {
+	var titles []entities.ListTitle
+	for title := range ws.Tasks {
+		titles = append(titles, title)
+	}
+	sort.Slice(titles, func(i, j int) bool { return titles[i] < titles[j] })
+
+	return titles
+}
+
+// sessions returns finished sessions followed by the running one
+func (t *Task) sessions(title entities.ListTitle) []entities.Session {
+	sessions := append([]entities.Session{}, t.Sessions...)
+	for i := range sessions {
+		sessions[i].Title = title
+	}
+
+	if t.Running != nil {
+		sessions = append(sessions, entities.Session{
+			Title:    title,
+			Start:    t.Running.Start,
+			Notes:    t.Running.Notes,
+			Commits:  t.Running.Commits,
+			Parallel: t.Running.Parallel,
+		})
+	}
+
+	return sessions
+}
+
+func appendNew(notes []string, added ...string) []string {
+	var res []string
+	res = append(res, notes...)
+	for _, note := range added {
+		found := false
+		for _, n := range res {
+			found = found || n == note
+		}
+		if !found {
+			res = append(res, note)
+		}
+	}
+
+	return res
+}
+
+func appendNewCommits(commits []entities.Commit, added ...entities.Commit) []entities.Commit {
+	var res []entities.Commit
+	res = append(res, commits...)
+	for _, c := range added {
+		found := false
+		for _, existing := range res {
+			found = found || existing.Hash == c.Hash
+		}
+		if !found {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
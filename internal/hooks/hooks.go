@@ -0,0 +1,342 @@
+// Package hooks runs user scripts when timers change
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Events fired by timer commands
+const (
+	Start  = "start"
+	Stop   = "stop"
+	Switch = "switch"
+	Resume = "resume"
+	Remove = "remove"
+)
+
+// Events lists all the known events
+var Events = []string{Start, Stop, Switch, Resume, Remove}
+
+// Policy tells what to do when a hook fails or times out
+type Policy string
+
+const (
+	// Ignore drops the failure silently
+	Ignore Policy = "ignore"
+	// Warn logs the failure and keeps the change
+	Warn Policy = "warn"
+	// Abort cancels the change and the hooks left
+	Abort Policy = "abort"
+)
+
+// ParsePolicy checks the name of the failure policy
+func ParsePolicy(value string) (Policy, error) {
+	switch p := Policy(value); p {
+	case Ignore, Warn, Abort:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown failure policy %s, use ignore, warn or abort", value)
+}
+
+// Task is the state of the task passed to hooks
+type Task struct {
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+	Running bool     `json:"running"`
+	// SessionStart and SessionSeconds describe the last session, the
+	// running one is counted till the event
+	SessionStart   time.Time `json:"session_start"`
+	SessionSeconds int64     `json:"session_seconds"`
+	TotalSeconds   int64     `json:"total_seconds"`
+}
+
+// NewTask describes the list at the moment
+func NewTask(l *entities.List, now time.Time) *Task {
+	t := &Task{Title: string(l.Title), Tags: []string{}}
+	for _, tag := range l.Tags {
+		t.Tags = append(t.Tags, string(tag))
+	}
+
+	for _, s := range l.Sessions() {
+		end := s.End
+		if s.Running() {
+			end = now
+		}
+		d := end.Sub(s.Start)
+
+		t.Running = s.Running()
+		t.SessionStart = s.Start
+		t.SessionSeconds = int64(d.Seconds())
+		t.TotalSeconds += int64(d.Seconds())
+	}
+
+	return t
+}
+
+// Event is the JSON payload hooks receive on stdin and webhooks receive in
+// the request body
+type Event struct {
+	Event     string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Workspace string    `json:"workspace"`
+	// Device is the name of the device from the config
+	Device string `json:"device"`
+	Task   *Task  `json:"task"`
+	// Previous is the task stopped by switch or resume
+	Previous *Task `json:"previous,omitempty"`
+}
+
+// Hook is a shell command from the config
+type Hook struct {
+	// Events the hook fires on, all of them when empty
+	Events  []string `json:"events,omitempty"`
+	Command string   `json:"command"`
+	// Timeout like "5s" and OnFailure override the defaults of the runner
+	Timeout   string `json:"timeout,omitempty"`
+	OnFailure Policy `json:"on_failure,omitempty"`
+}
+
+// Validate checks events, timeout and policy of the hook
+func (h Hook) Validate() error {
+	if strings.TrimSpace(h.Command) == "" {
+		return errors.New("hook command can't be empty")
+	}
+
+	for _, e := range h.Events {
+		if !known(e) {
+			return fmt.Errorf("unknown hook event %s, use one of %s", e, strings.Join(Events, ", "))
+		}
+	}
+
+	if h.Timeout != "" {
+		d, err := time.ParseDuration(h.Timeout)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return errors.New("hook timeout must be positive")
+		}
+	}
+
+	if h.OnFailure != "" {
+		if _, err := ParsePolicy(string(h.OnFailure)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h Hook) fires(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func known(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Runner finds and runs hooks of events
+type Runner struct {
+	// Dir keeps executables named after events, like start, or placed in
+	// directories named after them, like start.d/slack
+	Dir   string
+	Hooks []Hook
+	// Timeout and OnFailure are used when the hook doesn't set its own
+	Timeout   time.Duration
+	OnFailure Policy
+	// Out receives output of hooks
+	Out io.Writer
+}
+
+type job struct {
+	name      string
+	cmd       func(ctx context.Context) *exec.Cmd
+	timeout   time.Duration
+	onFailure Policy
+}
+
+// Run passes the event to its hooks one by one. Failures are handled by
+// policies of the hooks, only an aborting one is returned
+func (r *Runner) Run(ctx context.Context, e Event) error {
+	jobs, err := r.jobs(e.Event)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range jobs {
+		err := r.run(ctx, j, e, payload)
+		if err == nil {
+			continue
+		}
+
+		switch j.onFailure {
+		case Ignore:
+		case Abort:
+			return fmt.Errorf("%s hook %s: %w", e.Event, j.name, err)
+		default:
+			log.Printf("%s hook %s failed: %v", e.Event, j.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) jobs(event string) ([]job, error) {
+	timeout, onFailure := r.Timeout, r.OnFailure
+	if onFailure == "" {
+		onFailure = Warn
+	}
+
+	var jobs []job
+	paths, err := r.executables(event)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		path := path
+		jobs = append(jobs, job{
+			name:      path,
+			cmd:       func(ctx context.Context) *exec.Cmd { return exec.CommandContext(ctx, path) },
+			timeout:   timeout,
+			onFailure: onFailure,
+		})
+	}
+
+	for _, h := range r.Hooks {
+		if !h.fires(event) {
+			continue
+		}
+
+		j := job{name: h.Command, timeout: timeout, onFailure: onFailure}
+		command := h.Command
+		j.cmd = func(ctx context.Context) *exec.Cmd { return exec.CommandContext(ctx, "sh", "-c", command) }
+		if h.Timeout != "" {
+			if j.timeout, err = time.ParseDuration(h.Timeout); err != nil {
+				return nil, err
+			}
+		}
+		if h.OnFailure != "" {
+			j.onFailure = h.OnFailure
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+// executables returns hooks of the event from the directory in order of
+// their names. Files without the executable bit are skipped
+func (r *Runner) executables(event string) ([]string, error) {
+	if r.Dir == "" {
+		return nil, nil
+	}
+
+	paths := []string{filepath.Join(r.Dir, event)}
+	entries, err := os.ReadDir(filepath.Join(r.Dir, event+".d"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		paths = append(paths, filepath.Join(r.Dir, event+".d", name))
+	}
+
+	var res []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			res = append(res, path)
+		}
+	}
+
+	return res, nil
+}
+
+func (r *Runner) run(ctx context.Context, j job, e Event, payload []byte) error {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	cmd := j.cmd(ctx)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"TIME_TRACKER_EVENT="+e.Event,
+		"TIME_TRACKER_TASK="+e.Task.Title,
+	)
+	if e.Previous != nil {
+		cmd.Env = append(cmd.Env, "TIME_TRACKER_PREVIOUS="+e.Previous.Title)
+	}
+	// hooks started by the script shouldn't keep the command waiting
+	cmd.WaitDelay = time.Second
+
+	var stderr bytes.Buffer
+	cmd.Stdout = r.Out
+	cmd.Stderr = &stderr
+	if r.Out == nil {
+		cmd.Stdout = io.Discard
+	}
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", j.timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewTask(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", start, start.Add(time.Hour), nil))
+	assert.NoError(t, elist.AddSession("api", start.Add(2*time.Hour), start.Add(150*time.Minute), nil))
+	assert.NoError(t, elist.AddTag("backend", "api"))
+
+	task := NewTask(elist.EntriesListsView["api"], start.Add(3*time.Hour))
+	assert.Equal(t, "api", task.Title)
+	assert.Equal(t, []string{"backend"}, task.Tags)
+	assert.False(t, task.Running)
+	assert.Equal(t, start.Add(2*time.Hour), task.SessionStart)
+	assert.Equal(t, int64(1800), task.SessionSeconds)
+	assert.Equal(t, int64(5400), task.TotalSeconds)
+}
+
+func Test_Runner(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "start.d"), 0755))
+	script := "#!/bin/sh\ncat >> " + out + "\necho >> " + out + "\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "start"), []byte(script), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "start.d", "b"), []byte("#!/bin/sh\necho b >> "+out+"\n"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "start.d", "a"), []byte("#!/bin/sh\necho a >> "+out+"\n"), 0755))
+	// not executable
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "start.d", "c"), []byte("#!/bin/sh\necho c >> "+out+"\n"), 0644))
+
+	r := &Runner{
+		Dir: dir,
+		Hooks: []Hook{
+			{Events: []string{Stop}, Command: "echo stop >> " + out},
+			{Command: `echo "$TIME_TRACKER_EVENT $TIME_TRACKER_TASK $TIME_TRACKER_PREVIOUS" >> ` + out},
+		},
+		Timeout: time.Second,
+	}
+
+	e := Event{
+		Event:     Start,
+		Time:      time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		Workspace: "default",
+		Task:      &Task{Title: "api", Tags: []string{}},
+	}
+	assert.NoError(t, r.Run(context.Background(), e))
+
+	payload, err := json.Marshal(e)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, string(payload)+"\na\nb\nstart api \n", string(data))
+
+	// failures
+	r = &Runner{
+		Hooks: []Hook{
+			{Command: "echo broken >&2; exit 1", OnFailure: Ignore},
+			{Command: "exec sleep 5", Timeout: "100ms"},
+			{Command: "exit 1", OnFailure: Abort},
+			{Command: "echo never >> " + out},
+		},
+		Timeout: time.Second,
+	}
+	assert.NoError(t, os.Remove(out))
+
+	started := time.Now()
+	err = r.Run(context.Background(), e)
+	assert.ErrorContains(t, err, "start hook exit 1")
+	assert.Less(t, time.Since(started), 3*time.Second)
+	assert.NoFileExists(t, out)
+
+	r.Hooks = r.Hooks[1:2]
+	assert.NoError(t, r.Run(context.Background(), e))
+	r.Hooks[0].OnFailure = Abort
+	assert.ErrorContains(t, r.Run(context.Background(), e), "timed out after 100ms")
+}
+
+func Test_HookValidate(t *testing.T) {
+	assert.NoError(t, Hook{Events: []string{Start, Switch}, Command: "true", Timeout: "1s", OnFailure: Abort}.Validate())
+	assert.Error(t, Hook{}.Validate())
+	assert.Error(t, Hook{Events: []string{"pause"}, Command: "true"}.Validate())
+	assert.Error(t, Hook{Command: "true", Timeout: "-1s"}.Validate())
+	assert.Error(t, Hook{Command: "true", OnFailure: "retry"}.Validate())
+}
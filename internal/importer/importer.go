@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Interval is the tracked time read from another tool
+type Interval struct {
+	Title entities.ListTitle
+	Tags  []entities.Tag
+	Start time.Time
+	// End is zero for the interval which is still running
+	End   time.Time
+	Notes []string
+}
+
+const timewarriorTimeFormat = "20060102T150405Z"
+
+// Timewarrior parses data/*.data file. Timewarrior has no titles, so the first
+// tag becomes the title and the rest become tags. Timestamps are UTC, ones
+// without Z suffix are read in loc.
+//
+//	inc 20240101T100000Z - 20240101T110000Z # api "code review" # "annotation"
+func Timewarrior(r io.Reader, loc *time.Location) ([]Interval, error) {
+	var res []Interval
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		interval, err := parseTimewarriorLine(line, loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		res = append(res, interval)
+	}
+
+	return res, scanner.Err()
+}
+
+func parseTimewarriorLine(line string, loc *time.Location) (Interval, error) {
+	var interval Interval
+
+	body, rest, _ := strings.Cut(line, " # ")
+	fields := strings.Fields(body)
+	if len(fields) < 2 || fields[0] != "inc" {
+		return interval, fmt.Errorf("unexpected interval %q", line)
+	}
+
+	var err error
+	interval.Start, err = parseTimewarriorTime(fields[1], loc)
+	if err != nil {
+		return interval, err
+	}
+
+	if len(fields) == 4 && fields[2] == "-" {
+		interval.End, err = parseTimewarriorTime(fields[3], loc)
+		if err != nil {
+			return interval, err
+		}
+	}
+
+	tags, annotation, _ := strings.Cut(rest, " # ")
+	words := splitQuoted(tags)
+	if len(words) == 0 {
+		words = []string{"timewarrior"}
+	}
+
+	interval.Title = entities.ListTitle(words[0])
+	for _, word := range words[1:] {
+		interval.Tags = append(interval.Tags, toTag(word))
+	}
+
+	if annotation = strings.Trim(strings.TrimSpace(annotation), `"`); annotation != "" {
+		interval.Notes = []string{annotation}
+	}
+
+	return interval, nil
+}
+
+func parseTimewarriorTime(value string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(timewarriorTimeFormat, value)
+	}
+
+	return time.ParseInLocation(strings.TrimSuffix(timewarriorTimeFormat, "Z"), value, loc)
+}
+
+// Watson parses frames file, a JSON array of frames
+//
+//	[start, stop, project, id, [tags], updated_at]
+//
+// with unix timestamps. Project becomes the title.
+func Watson(r io.Reader) ([]Interval, error) {
+	var frames [][]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&frames); err != nil {
+		return nil, err
+	}
+
+	var res []Interval
+	for n, frame := range frames {
+		if len(frame) < 3 {
+			return nil, fmt.Errorf("frame %d: unexpected frame", n)
+		}
+
+		var start, stop int64
+		var project string
+		var tags []string
+
+		if err := json.Unmarshal(frame[0], &start); err != nil {
+			return nil, fmt.Errorf("frame %d: start: %w", n, err)
+		}
+		if err := json.Unmarshal(frame[1], &stop); err != nil {
+			return nil, fmt.Errorf("frame %d: stop: %w", n, err)
+		}
+		if err := json.Unmarshal(frame[2], &project); err != nil {
+			return nil, fmt.Errorf("frame %d: project: %w", n, err)
+		}
+		if len(frame) > 4 {
+			if err := json.Unmarshal(frame[4], &tags); err != nil {
+				return nil, fmt.Errorf("frame %d: tags: %w", n, err)
+			}
+		}
+
+		interval := Interval{
+			Title: entities.ListTitle(project),
+			Start: time.Unix(start, 0),
+			End:   time.Unix(stop, 0),
+		}
+		for _, tag := range tags {
+			interval.Tags = append(interval.Tags, toTag(tag))
+		}
+
+		res = append(res, interval)
+	}
+
+	return res, nil
+}
+
+// toTag converts foreign tag to #tag form without spaces
+func toTag(tag string) entities.Tag {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	tag = strings.Join(strings.Fields(tag), "-")
+
+	return entities.Tag("#" + tag)
+}
+
+// splitQuoted splits words keeping "quoted words" together
+func splitQuoted(s string) []string {
+	var words []string
+	var word strings.Builder
+	var quoted, escaped bool
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// Conflict is the interval which wasn't imported
+type Conflict struct {
+	Interval Interval
+	Reason   string
+}
+
+// Summary describes changes made by Apply
+type Summary struct {
+	Created  []entities.ListTitle
+	Sessions int
+	Duration time.Duration
+	Tags     []entities.Tag
+	Skipped  []Conflict
+}
+
+// Apply adds intervals to the lists. Running intervals and ones overlapping
+// tracked sessions are skipped and reported. Intervals overlapping each other
+// are taken in order of their start
+func Apply(elist *entities.EntriesLists, intervals []Interval) Summary {
+	var summary Summary
+	tags := make(map[entities.Tag]bool)
+
+	exists := make(map[entities.ListTitle]bool)
+	for title := range elist.EntriesListsView {
+		exists[title] = true
+	}
+
+	var sessions []entities.Session
+	for _, interval := range intervals {
+		if !interval.End.IsZero() {
+			sessions = append(sessions, entities.Session{
+				Title: interval.Title,
+				Start: interval.Start,
+				End:   interval.End,
+				Notes: interval.Notes,
+			})
+		}
+	}
+	errs := elist.AddSessions(sessions)
+
+	for _, interval := range intervals {
+		if interval.End.IsZero() {
+			summary.Skipped = append(summary.Skipped, Conflict{interval, "still running"})
+			continue
+		}
+
+		err := errs[0]
+		errs = errs[1:]
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, Conflict{interval, err.Error()})
+			continue
+		}
+
+		if !exists[interval.Title] {
+			exists[interval.Title] = true
+			summary.Created = append(summary.Created, interval.Title)
+		}
+
+		for _, tag := range interval.Tags {
+			elist.AddTag(tag, interval.Title)
+			if !tags[tag] {
+				tags[tag] = true
+				summary.Tags = append(summary.Tags, tag)
+			}
+		}
+
+		summary.Sessions++
+		summary.Duration += interval.End.Sub(interval.Start)
+	}
+
+	return summary
+}
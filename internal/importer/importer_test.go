@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Timewarrior(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	data := `inc 20240101T100000Z - 20240101T110000Z # api "Code Review" # "looked at PR"
+
+inc 20240101T120000 - 20240101T123000 # lunch
+inc 20240103T100000Z # running
+`
+	intervals, err := Timewarrior(strings.NewReader(data), berlin)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(intervals))
+
+	assert.Equal(t, entities.ListTitle("api"), intervals[0].Title)
+	assert.Equal(t, []entities.Tag{"#code-review"}, intervals[0].Tags)
+	assert.Equal(t, []string{"looked at PR"}, intervals[0].Notes)
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), intervals[0].Start.UTC())
+
+	// timestamps without zone are read in the given location
+	assert.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), intervals[1].Start.UTC())
+	assert.True(t, intervals[2].End.IsZero())
+
+	_, err = Timewarrior(strings.NewReader("exc 20240101T100000Z"), time.UTC)
+	assert.Error(t, err)
+}
+
+func Test_WatsonApply(t *testing.T) {
+	frames := `[
+		[1704103200, 1704106800, "api", "a1", ["Review"], 1704106800],
+		[1704105000, 1704105600, "other", "a2", [], 1704105600],
+		[1704189600, 1704193200, "api", "a3", ["review", "docs"], 1704193200]
+	]`
+
+	intervals, err := Watson(strings.NewReader(frames))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(intervals))
+
+	elist := entities.InitEmptyElist()
+	summary := Apply(elist, intervals)
+
+	assert.Equal(t, []entities.ListTitle{"api"}, summary.Created)
+	assert.Equal(t, 2, summary.Sessions)
+	assert.Equal(t, 2*time.Hour, summary.Duration)
+	assert.Equal(t, 1, len(summary.Skipped))
+	assert.Equal(t, entities.ListTitle("other"), summary.Skipped[0].Interval.Title)
+
+	api := elist.EntriesListsView["api"]
+	assert.Equal(t, []entities.Tag{"#review", "#docs"}, api.Tags)
+	assert.Equal(t, 2*time.Hour, api.Total())
+
+	// importing the same frames again only reports conflicts
+	summary = Apply(elist, intervals)
+	assert.Equal(t, 0, summary.Sessions)
+	assert.Equal(t, 3, len(summary.Skipped))
+}
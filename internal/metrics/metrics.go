@@ -0,0 +1,285 @@
+// Package metrics exposes tracked time in Prometheus and OpenMetrics text
+// formats
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Format is the exposition format
+type Format string
+
+const (
+	// Prometheus is the text format 0.0.4 read by node_exporter textfile
+	// collector
+	Prometheus  Format = "prometheus"
+	OpenMetrics Format = "openmetrics"
+)
+
+// ParseFormat checks the name of the format, empty one is Prometheus
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(value); f {
+	case "":
+		return Prometheus, nil
+	case Prometheus, OpenMetrics:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown format %q, use prometheus or openmetrics", value)
+}
+
+// Negotiate picks the format by the Accept header of the scrape
+func Negotiate(accept string) Format {
+	if strings.Contains(accept, "application/openmetrics-text") {
+		return OpenMetrics
+	}
+
+	return Prometheus
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	if f == OpenMetrics {
+		return "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	}
+
+	return "text/plain; version=0.0.4; charset=utf-8"
+}
+
+// Other labels tasks beyond the limit
+const Other = "other"
+
+// Options keep the number of series manageable
+type Options struct {
+	// MaxTasks is the number of tasks with own labels, the ones tracked
+	// less are summed up as "other". Zero means no limit
+	MaxTasks int
+	// MaxTags is the number of tags exported, the ones tracked less are
+	// dropped. Zero means no limit
+	MaxTags int
+	// Tags are the only tags exported, all of them when empty
+	Tags []string
+}
+
+// Workspace is the list of the workspace
+type Workspace struct {
+	Name string
+	List *entities.EntriesLists
+}
+
+// Sample is a single value of the metric
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Family is the gauge with its samples. Tracked time isn't a counter, it
+// drops when sessions are removed or tasks fall behind the limits
+type Family struct {
+	Name    string
+	Help    string
+	Samples []Sample
+}
+
+type task struct {
+	workspace string
+	title     string
+	total     time.Duration
+	sessions  int
+	running   int
+	current   time.Duration
+}
+
+type tag struct {
+	workspace string
+	name      string
+	total     time.Duration
+}
+
+// Collect computes metrics of the workspaces. Running sessions are counted
+// till now, parallel tasks are counted in full
+func Collect(workspaces []Workspace, opts Options, now time.Time) []Family {
+	allowed := make(map[string]bool)
+	for _, t := range opts.Tags {
+		allowed[strings.TrimPrefix(t, "#")] = true
+	}
+
+	var tasks []*task
+	var tags []*tag
+	for _, ws := range workspaces {
+		byTag := make(map[string]*tag)
+		for _, l := range ws.List.Lists() {
+			t := &task{workspace: ws.Name, title: string(l.Title)}
+			for _, s := range l.Sessions() {
+				end := s.End
+				if s.Running() {
+					end = now
+					t.running++
+					t.current = end.Sub(s.Start)
+				}
+				t.total += end.Sub(s.Start)
+				t.sessions++
+			}
+			tasks = append(tasks, t)
+
+			for _, lt := range l.Tags {
+				name := strings.TrimPrefix(string(lt), "#")
+				if len(allowed) > 0 && !allowed[name] {
+					continue
+				}
+				if byTag[name] == nil {
+					byTag[name] = &tag{workspace: ws.Name, name: name}
+					tags = append(tags, byTag[name])
+				}
+				byTag[name].total += t.total
+			}
+		}
+	}
+
+	tasks = limitTasks(tasks, opts.MaxTasks)
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].total != tags[j].total {
+			return tags[i].total > tags[j].total
+		}
+		return tags[i].workspace+tags[i].name < tags[j].workspace+tags[j].name
+	})
+	if opts.MaxTags > 0 && len(tags) > opts.MaxTags {
+		tags = tags[:opts.MaxTags]
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].workspace != tags[j].workspace {
+			return tags[i].workspace < tags[j].workspace
+		}
+		return tags[i].name < tags[j].name
+	})
+
+	taskSeconds := Family{Name: "time_tracker_task_seconds", Help: "Time tracked by the task."}
+	sessions := Family{Name: "time_tracker_task_sessions", Help: "Sessions of the task."}
+	active := Family{Name: "time_tracker_active_task", Help: "Number of running sessions of the task."}
+	current := Family{Name: "time_tracker_current_session_seconds", Help: "Length of the running session of the task."}
+	for _, t := range tasks {
+		labels := []Label{{"workspace", t.workspace}, {"task", t.title}}
+		taskSeconds.Samples = append(taskSeconds.Samples, Sample{labels, seconds(t.total)})
+		sessions.Samples = append(sessions.Samples, Sample{labels, float64(t.sessions)})
+		if t.running > 0 {
+			active.Samples = append(active.Samples, Sample{labels, float64(t.running)})
+			current.Samples = append(current.Samples, Sample{labels, seconds(t.current)})
+		}
+	}
+
+	tagSeconds := Family{Name: "time_tracker_tag_seconds", Help: "Time tracked by tasks with the tag."}
+	for _, t := range tags {
+		labels := []Label{{"workspace", t.workspace}, {"tag", t.name}}
+		tagSeconds.Samples = append(tagSeconds.Samples, Sample{labels, seconds(t.total)})
+	}
+
+	return []Family{taskSeconds, sessions, tagSeconds, active, current}
+}
+
+// limitTasks keeps the tasks tracked most and sums up the others by
+// workspace. Running session of "other" is the longest one
+func limitTasks(tasks []*task, max int) []*task {
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].total != tasks[j].total {
+			return tasks[i].total > tasks[j].total
+		}
+		return tasks[i].workspace+tasks[i].title < tasks[j].workspace+tasks[j].title
+	})
+
+	res := tasks
+	if max > 0 && len(tasks) > max {
+		res = tasks[:max:max]
+
+		others := make(map[string]*task)
+		// a task named other takes the rest of its workspace
+		for _, t := range res {
+			if t.title == Other {
+				others[t.workspace] = t
+			}
+		}
+		for _, t := range tasks[max:] {
+			o, ok := others[t.workspace]
+			if !ok {
+				o = &task{workspace: t.workspace, title: Other}
+				others[t.workspace] = o
+				res = append(res, o)
+			}
+			o.total += t.total
+			o.sessions += t.sessions
+			o.running += t.running
+			if t.current > o.current {
+				o.current = t.current
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].workspace != res[j].workspace {
+			return res[i].workspace < res[j].workspace
+		}
+		return res[i].title < res[j].title
+	})
+
+	return res
+}
+
+func seconds(d time.Duration) float64 {
+	return d.Truncate(time.Millisecond).Seconds()
+}
+
+// Write prints families in the format
+func Write(w io.Writer, families []Family, format Format) error {
+	bw := bufio.NewWriter(w)
+
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, f.Help)
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", f.Name)
+		for _, s := range f.Samples {
+			bw.WriteString(f.Name)
+			writeLabels(bw, s.Labels)
+			bw.WriteString(" ")
+			bw.WriteString(strconv.FormatFloat(s.Value, 'f', -1, 64))
+			bw.WriteString("\n")
+		}
+	}
+
+	if format == OpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabels(w *bufio.Writer, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	w.WriteString("{")
+	for i, l := range labels {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString(l.Name)
+		w.WriteString(`="`)
+		w.WriteString(labelEscaper.Replace(l.Value))
+		w.WriteString(`"`)
+	}
+	w.WriteString("}")
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Collect(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	now := start.Add(5 * time.Hour)
+
+	work := entities.InitEmptyElist()
+	assert.NoError(t, work.AddSession("api", start, start.Add(time.Hour), nil))
+	assert.NoError(t, work.AddSession("api", start.Add(2*time.Hour), start.Add(3*time.Hour), nil))
+	assert.NoError(t, work.AddSession(`say "hi"`, start.Add(time.Hour), start.Add(90*time.Minute), nil))
+	assert.NoError(t, work.AddSession("docs", start.Add(90*time.Minute), start.Add(100*time.Minute), nil))
+	assert.NoError(t, work.AddTag("#backend", "api"))
+	assert.NoError(t, work.AddTag("#chat", `say "hi"`))
+	work.InsertEntry("review", entities.StatusActive)
+	work.EntriesListsView["review"].States[0].Timestamp = now.Add(-20 * time.Minute)
+
+	home := entities.InitEmptyElist()
+	assert.NoError(t, home.AddSession("garden", start, start.Add(time.Hour), nil))
+	assert.NoError(t, home.AddTag("#backend", "garden"))
+
+	workspaces := []Workspace{{Name: "default", List: work}, {Name: "home", List: home}}
+	families := Collect(workspaces, Options{MaxTasks: 3, Tags: []string{"backend"}}, now)
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, families, Prometheus))
+	assert.Equal(t, `# HELP time_tracker_task_seconds Time tracked by the task.
+# TYPE time_tracker_task_seconds gauge
+time_tracker_task_seconds{workspace="default",task="api"} 7200
+time_tracker_task_seconds{workspace="default",task="other"} 1800
+time_tracker_task_seconds{workspace="default",task="say \"hi\""} 1800
+time_tracker_task_seconds{workspace="home",task="garden"} 3600
+# HELP time_tracker_task_sessions Sessions of the task.
+# TYPE time_tracker_task_sessions gauge
+time_tracker_task_sessions{workspace="default",task="api"} 2
+time_tracker_task_sessions{workspace="default",task="other"} 2
+time_tracker_task_sessions{workspace="default",task="say \"hi\""} 1
+time_tracker_task_sessions{workspace="home",task="garden"} 1
+# HELP time_tracker_tag_seconds Time tracked by tasks with the tag.
+# TYPE time_tracker_tag_seconds gauge
+time_tracker_tag_seconds{workspace="default",tag="backend"} 7200
+time_tracker_tag_seconds{workspace="home",tag="backend"} 3600
+# HELP time_tracker_active_task Number of running sessions of the task.
+# TYPE time_tracker_active_task gauge
+time_tracker_active_task{workspace="default",task="other"} 1
+# HELP time_tracker_current_session_seconds Length of the running session of the task.
+# TYPE time_tracker_current_session_seconds gauge
+time_tracker_current_session_seconds{workspace="default",task="other"} 1200
+`, buf.String())
+
+	families = Collect(workspaces, Options{MaxTags: 1}, now)
+	buf.Reset()
+	assert.NoError(t, Write(&buf, families[:1], OpenMetrics))
+	assert.Equal(t, `# HELP time_tracker_task_seconds Time tracked by the task.
+# TYPE time_tracker_task_seconds gauge
+time_tracker_task_seconds{workspace="default",task="api"} 7200
+time_tracker_task_seconds{workspace="default",task="docs"} 600
+time_tracker_task_seconds{workspace="default",task="review"} 1200
+time_tracker_task_seconds{workspace="default",task="say \"hi\""} 1800
+time_tracker_task_seconds{workspace="home",task="garden"} 3600
+# EOF
+`, buf.String())
+	assert.Equal(t, 1, len(families[2].Samples))
+	assert.Equal(t, "default", families[2].Samples[0].Labels[0].Value)
+}
+
+func Test_Format(t *testing.T) {
+	f, err := ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, Prometheus, f)
+	_, err = ParseFormat("json")
+	assert.Error(t, err)
+
+	assert.Equal(t, OpenMetrics, Negotiate("application/openmetrics-text;version=1.0.0,text/plain;q=0.5"))
+	assert.Equal(t, Prometheus, Negotiate("*/*"))
+}
@@ -0,0 +1,170 @@
+// Package notify delivers messages to the user through pluggable notifiers
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a single notification. Kind tells messages apart for hooks
+type Message struct {
+	Kind  string
+	Title string
+	Body  string
+}
+
+// Notifier delivers messages
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// Options configure notifiers created by name
+type Options struct {
+	// Out receives terminal bell messages
+	Out io.Writer
+	// Command is run by the command notifier with sh -c
+	Command string
+}
+
+// Factory creates a notifier
+type Factory func(Options) (Notifier, error)
+
+var (
+	mu       sync.Mutex
+	registry = map[string]Factory{
+		"bell":        newBell,
+		"notify-send": newDesktop,
+		"command":     newCommand,
+	}
+)
+
+// Register makes the notifier available by the name
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registry[name] = f
+}
+
+// New creates notifiers by their names
+func New(names []string, opts Options) ([]Notifier, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var res []Notifier
+	for _, name := range names {
+		f, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown notifier %s", name)
+		}
+
+		n, err := f(opts)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", name, err)
+		}
+		res = append(res, n)
+	}
+
+	return res, nil
+}
+
+// timeout bounds delivery by a single notifier
+const timeout = 10 * time.Second
+
+// Send delivers the message through every notifier, failure of one doesn't
+// stop the others
+func Send(ctx context.Context, notifiers []Notifier, m Message) error {
+	var errs []error
+	for _, n := range notifiers {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		if err := n.Notify(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+		cancel()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Bell rings the terminal bell and prints the message
+type Bell struct {
+	Out io.Writer
+}
+
+func newBell(opts Options) (Notifier, error) {
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	return &Bell{Out: out}, nil
+}
+
+func (b *Bell) Notify(ctx context.Context, m Message) error {
+	_, err := fmt.Fprintf(b.Out, "\a%s %s: %s\n", time.Now().Format(time.TimeOnly), m.Title, m.Body)
+	return err
+}
+
+// Desktop shows the message with notify-send
+type Desktop struct{}
+
+func newDesktop(Options) (Notifier, error) {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return nil, err
+	}
+
+	return &Desktop{}, nil
+}
+
+func (d *Desktop) Notify(ctx context.Context, m Message) error {
+	return run(exec.CommandContext(ctx, "notify-send", "--app-name=time_tracker", m.Title, m.Body))
+}
+
+// Command runs the shell command with the message body on stdin and the
+// message in TIME_TRACKER_NOTIFY_KIND, _TITLE and _BODY env
+type Command struct {
+	Command string
+}
+
+func newCommand(opts Options) (Notifier, error) {
+	if opts.Command == "" {
+		return nil, errors.New("command isn't set")
+	}
+
+	return &Command{Command: opts.Command}, nil
+}
+
+func (c *Command) Notify(ctx context.Context, m Message) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = strings.NewReader(m.Body + "\n")
+	cmd.Env = append(os.Environ(),
+		"TIME_TRACKER_NOTIFY_KIND="+m.Kind,
+		"TIME_TRACKER_NOTIFY_TITLE="+m.Title,
+		"TIME_TRACKER_NOTIFY_BODY="+m.Body,
+	)
+
+	return run(cmd)
+}
+
+func run(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s: %s", cmd.Args[0], msg)
+	}
+
+	return nil
+}
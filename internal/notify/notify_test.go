@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Notify(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+
+	var bell bytes.Buffer
+	notifiers, err := New([]string{"bell", "command"}, Options{
+		Out:     &bell,
+		Command: `cat > ` + out + ` && echo "$TIME_TRACKER_NOTIFY_KIND" >> ` + out,
+	})
+	assert.NoError(t, err)
+
+	m := Message{Kind: "idle", Title: "time_tracker", Body: "No task is running"}
+	assert.NoError(t, Send(context.Background(), notifiers, m))
+	assert.True(t, strings.HasPrefix(bell.String(), "\a"))
+	assert.Contains(t, bell.String(), "time_tracker: No task is running")
+
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "No task is running\nidle\n", string(data))
+
+	failing, err := New([]string{"command", "bell"}, Options{Out: &bell, Command: "echo broken >&2; exit 1"})
+	assert.NoError(t, err)
+	bell.Reset()
+	err = Send(context.Background(), failing, m)
+	assert.ErrorContains(t, err, "broken")
+	// the other notifier still delivers
+	assert.NotEmpty(t, bell.String())
+
+	_, err = New([]string{"pager"}, Options{})
+	assert.Error(t, err)
+	_, err = New([]string{"command"}, Options{})
+	assert.Error(t, err)
+
+	Register("pager", func(Options) (Notifier, error) { return &Bell{Out: &bell}, nil })
+	_, err = New([]string{"pager"}, Options{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,167 @@
+// Package reminder decides when to remind about forgotten timers
+package reminder
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Kinds of reminders
+const (
+	Idle    = "idle"
+	Running = "running"
+)
+
+// Reminder is a single notice for the user
+type Reminder struct {
+	Kind      string
+	Workspace string
+	Title     entities.ListTitle
+	Message   string
+}
+
+// Options set when reminders are due. Zero durations disable them
+type Options struct {
+	// Idle is time without a running task within work hours
+	Idle time.Duration
+	// Running is time of a task running continuously
+	Running time.Duration
+	// Hours returns work hours of the day of the moment, false for days off
+	Hours func(time.Time) (time.Time, time.Time, bool)
+}
+
+// State keeps reminders sent by previous checks, so they're repeated only
+// after another period
+type State struct {
+	idleAt  time.Time
+	running map[task]notice
+}
+
+// task is the running task of the workspace
+type task struct {
+	workspace string
+	title     entities.ListTitle
+}
+
+type notice struct {
+	start time.Time
+	at    time.Time
+}
+
+// Check returns reminders due now for the lists by workspace name. The user
+// is idle only while no task runs in any workspace
+func Check(lists map[string]*entities.EntriesLists, st *State, opts Options, now time.Time) []Reminder {
+	if st.running == nil {
+		st.running = make(map[task]notice)
+	}
+
+	var names []string
+	running := false
+	for name, list := range lists {
+		names = append(names, name)
+		running = running || len(list.ActiveTitles()) > 0
+	}
+	sort.Strings(names)
+
+	var res []Reminder
+	if r, ok := checkIdle(lists, running, st, opts, now); ok {
+		res = append(res, r)
+	}
+
+	seen := make(map[task]bool)
+	for _, name := range names {
+		list := lists[name]
+		for _, title := range list.ActiveTitles() {
+			key := task{workspace: name, title: title}
+			seen[key] = true
+
+			start, ok := runningSince(list.EntriesListsView[title])
+			if !ok || opts.Running <= 0 {
+				continue
+			}
+
+			elapsed := now.Sub(start)
+			n, notified := st.running[key]
+			if elapsed < opts.Running || (notified && n.start.Equal(start) && now.Sub(n.at) < opts.Running) {
+				continue
+			}
+
+			st.running[key] = notice{start: start, at: now}
+			res = append(res, Reminder{
+				Kind:      Running,
+				Workspace: name,
+				Title:     title,
+				Message:   fmt.Sprintf("%s is running for %s", describe(name, title), elapsed.Truncate(time.Minute)),
+			})
+		}
+	}
+
+	for key := range st.running {
+		if !seen[key] {
+			delete(st.running, key)
+		}
+	}
+
+	return res
+}
+
+// describe names the task, the workspace is given unless it's the default one
+func describe(workspace string, title entities.ListTitle) string {
+	if workspace == entities.DefaultWorkspace {
+		return string(title)
+	}
+
+	return fmt.Sprintf("%s in %s workspace", title, workspace)
+}
+
+// checkIdle counts idle time since the last stop in any workspace or since
+// the start of work hours, whichever is later
+func checkIdle(lists map[string]*entities.EntriesLists, running bool, st *State, opts Options, now time.Time) (Reminder, bool) {
+	if running || opts.Idle <= 0 || opts.Hours == nil {
+		st.idleAt = time.Time{}
+		return Reminder{}, false
+	}
+
+	start, end, ok := opts.Hours(now)
+	if !ok || now.Before(start) || !now.Before(end) {
+		return Reminder{}, false
+	}
+
+	since := start
+	for _, list := range lists {
+		for _, l := range list.Lists() {
+			for _, s := range l.Sessions() {
+				if s.End.After(since) {
+					since = s.End
+				}
+			}
+		}
+	}
+	idle := now.Sub(since)
+	if st.idleAt.After(since) {
+		since = st.idleAt
+	}
+
+	if now.Sub(since) < opts.Idle {
+		return Reminder{}, false
+	}
+	st.idleAt = now
+
+	return Reminder{Kind: Idle, Message: fmt.Sprintf("No task is running for %s", idle.Truncate(time.Minute))}, true
+}
+
+func runningSince(l *entities.List) (time.Time, bool) {
+	if l == nil {
+		return time.Time{}, false
+	}
+
+	sessions := l.Sessions()
+	if len(sessions) == 0 || !sessions[len(sessions)-1].Running() {
+		return time.Time{}, false
+	}
+
+	return sessions[len(sessions)-1].Start, true
+}
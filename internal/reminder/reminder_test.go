@@ -0,0 +1,103 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Check(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	opts := Options{
+		Idle:    15 * time.Minute,
+		Running: 2 * time.Hour,
+		Hours: func(t time.Time) (time.Time, time.Time, bool) {
+			return at(9, 0), at(17, 0), true
+		},
+	}
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", at(8, 0), at(9, 10), nil))
+	lists := map[string]*entities.EntriesLists{entities.DefaultWorkspace: elist}
+
+	var st State
+	// before work hours and right after the stop
+	assert.Empty(t, Check(lists, &st, opts, at(8, 59)))
+	assert.Empty(t, Check(lists, &st, opts, at(9, 20)))
+
+	res := Check(lists, &st, opts, at(9, 25))
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, Idle, res[0].Kind)
+	assert.Equal(t, "No task is running for 15m0s", res[0].Message)
+
+	// repeated after another period
+	assert.Empty(t, Check(lists, &st, opts, at(9, 35)))
+	res = Check(lists, &st, opts, at(9, 40))
+	assert.Equal(t, "No task is running for 30m0s", res[0].Message)
+
+	assert.Empty(t, Check(lists, &st, opts, at(17, 30)))
+
+	elist.InsertEntry("docs", entities.StatusActive)
+	start := elist.EntriesListsView["docs"].Sessions()[0].Start
+	now := start.Add(2*time.Hour + time.Minute)
+	opts.Hours = nil
+
+	res = Check(lists, &st, opts, now)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, Running, res[0].Kind)
+	assert.Equal(t, entities.ListTitle("docs"), res[0].Title)
+
+	assert.Empty(t, Check(lists, &st, opts, now.Add(time.Hour)))
+	assert.Equal(t, 1, len(Check(lists, &st, opts, now.Add(2*time.Hour))))
+}
+
+func Test_CheckWorkspaces(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	opts := Options{
+		Idle:    15 * time.Minute,
+		Running: 2 * time.Hour,
+		Hours: func(t time.Time) (time.Time, time.Time, bool) {
+			return at(9, 0), at(17, 0), true
+		},
+	}
+
+	home, work := entities.InitEmptyElist(), entities.InitEmptyElist()
+	assert.NoError(t, home.AddSession("api", at(8, 0), at(9, 10), nil))
+	assert.NoError(t, work.AddSession("api", at(9, 0), at(10, 0), nil))
+	lists := map[string]*entities.EntriesLists{entities.DefaultWorkspace: home, "work": work}
+
+	// the last stop of any workspace counts
+	var st State
+	assert.Empty(t, Check(lists, &st, opts, at(10, 10)))
+	assert.Equal(t, 1, len(Check(lists, &st, opts, at(10, 15))))
+
+	// the task running in another workspace isn't idle time
+	home.InsertEntry("api", entities.StatusActive)
+	work.InsertEntry("api", entities.StatusActive)
+	start := work.EntriesListsView["api"].Sessions()[1].Start
+	opts.Hours = func(t time.Time) (time.Time, time.Time, bool) {
+		return start, start.Add(8 * time.Hour), true
+	}
+	home.InsertEntry("api", entities.StatusStop)
+	assert.Empty(t, Check(lists, &st, opts, start.Add(time.Hour)))
+
+	// tasks of the same title are reminded per workspace
+	home.InsertEntry("api", entities.StatusActive)
+	res := Check(lists, &st, opts, start.Add(2*time.Hour+time.Minute))
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, "work", res[1].Workspace)
+	assert.Contains(t, res[1].Message, "api in work workspace is running for")
+
+	home.InsertEntry("api", entities.StatusStop)
+	res = Check(lists, &st, opts, start.Add(4*time.Hour+2*time.Minute))
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, "work", res[0].Workspace)
+}
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -122,6 +124,84 @@ func (bdb *BadgerDB) Remove(namespace, key []byte) error {
 	return nil
 }
 
+// RemoveNamespace implements the DB interface. It drops all the keys stored in
+// the namespace.
+func (bdb *BadgerDB) RemoveNamespace(namespace []byte) error {
+	return bdb.db.DropPrefix(badgerNamespaceKey(namespace, nil))
+}
+
+// Rewrite implements the DB interface. Values are rewritten in batches, so
+// the rewrite isn't limited by the transaction size, and the extra key is set
+// by the final commit. Interrupted rewrite leaves part of the values
+// rewritten, fn is run on them again by the next rewrite. Old versions of the
+// values are compacted afterwards.
+func (bdb *BadgerDB) Rewrite(fn RewriteFunc, namespace, key, value []byte) error {
+	wb := bdb.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	err := bdb.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			k := item.KeyCopy(nil)
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			ns, key, _ := bytes.Cut(k, []byte("/"))
+			v, err = fn(ns, key, v)
+			if err != nil {
+				return fmt.Errorf("failed to rewrite %s: %w", k, err)
+			}
+			if err := wb.Set(k, v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+
+	err = bdb.db.Update(func(txn *badger.Txn) error {
+		if value == nil {
+			return txn.Delete(badgerNamespaceKey(namespace, key))
+		}
+
+		return txn.Set(badgerNamespaceKey(namespace, key), value)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bdb.compact()
+}
+
+// compact drops old versions of the values from the tables and the value
+// log. The value log file being written isn't collected
+func (bdb *BadgerDB) compact() error {
+	if err := bdb.db.Flatten(1); err != nil {
+		return err
+	}
+
+	for {
+		err := bdb.db.RunValueLogGC(badgerDiscardRatio)
+		if errors.Is(err, badger.ErrNoRewrite) || errors.Is(err, badger.ErrRejected) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 // Has implements the DB interface. It returns a boolean reflecting if the
 // datbase has a given key for a namespace or not. An error is only returned if
 // an error to Get would be returned that is not of type badger.ErrKeyNotFound.
@@ -168,6 +248,32 @@ func (bdb *BadgerDB) All(namespace, prefix []byte) (vals [][]byte, err error) {
 	return vals, nil
 }
 
+// From implements the DB interface. It retrieves values by prefix in order of
+// keys skipping the keys before from
+func (bdb *BadgerDB) From(namespace, prefix, from []byte) (vals [][]byte, err error) {
+	err = bdb.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = badgerNamespaceKey(namespace, prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(badgerNamespaceKey(namespace, from)); it.Valid(); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			vals = append(vals, val)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
+
 // Close implements the DB interface. It closes the connection to the underlying
 // BadgerDB database as well as invoking the context's cancel function.
 func (bdb *BadgerDB) Close() error {
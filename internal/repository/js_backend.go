@@ -2,33 +2,212 @@ package repository
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/schema"
 )
 
 type FileBackend struct {
+	// root is the file of the default workspace
+	root string
 	path string
 }
 
 func NewFileBackend(path string) *FileBackend {
 	return &FileBackend{
+		root: path,
 		path: path,
 	}
 }
 
+// workspacePath returns file of the workspace next to the root file,
+// bd.json keeps default workspace and bd.<name>.json the others
+func (fb *FileBackend) workspacePath(name string) string {
+	if name == entities.DefaultWorkspace {
+		return fb.root
+	}
+
+	ext := filepath.Ext(fb.root)
+	return strings.TrimSuffix(fb.root, ext) + "." + name + ext
+}
+
+// Close does nothing, files are closed after every access
+func (fb *FileBackend) Close() error {
+	return nil
+}
+
+func (fb *FileBackend) UseWorkspace(name string) {
+	fb.path = fb.workspacePath(name)
+}
+
+func (fb *FileBackend) RemoveWorkspace(name string) error {
+	err := os.Remove(fb.workspacePath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (fb *FileBackend) LoadWorkspaces() (*entities.Workspaces, error) {
+	ws := entities.InitWorkspaces()
+
+	fileBytes, err := os.ReadFile(filepath.Join(filepath.Dir(fb.root), "workspaces.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return ws, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(fileBytes, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func (fb *FileBackend) DumpWorkspaces(ws *entities.Workspaces) error {
+	jsonData, err := json.MarshalIndent(ws, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(filepath.Dir(fb.root), "workspaces.json"), jsonData, 0644)
+}
+
+// LoadTimeOff reads days off shared by all workspaces from time_off.json
+func (fb *FileBackend) LoadTimeOff() (*entities.TimeOff, error) {
+	to := entities.InitTimeOff()
+
+	fileBytes, err := os.ReadFile(filepath.Join(filepath.Dir(fb.root), "time_off.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return to, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(fileBytes, to); err != nil {
+		return nil, err
+	}
+
+	return to, nil
+}
+
+func (fb *FileBackend) DumpTimeOff(to *entities.TimeOff) error {
+	jsonData, err := json.MarshalIndent(to, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(filepath.Dir(fb.root), "time_off.json"), jsonData, 0644)
+}
+
+// LoadSchedules reads schedules of all workspaces from schedules.json
+func (fb *FileBackend) LoadSchedules() (*entities.Schedules, error) {
+	ss := entities.InitSchedules()
+
+	fileBytes, err := os.ReadFile(filepath.Join(filepath.Dir(fb.root), "schedules.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return ss, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(fileBytes, ss); err != nil {
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+func (fb *FileBackend) DumpSchedules(ss *entities.Schedules) error {
+	jsonData, err := json.MarshalIndent(ss, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(filepath.Dir(fb.root), "schedules.json"), jsonData, 0644)
+}
+
+// LoadWebhookQueue reads failed webhook deliveries from webhook_queue.json
+func (fb *FileBackend) LoadWebhookQueue() (*entities.WebhookQueue, error) {
+	q := entities.InitWebhookQueue()
+
+	fileBytes, err := os.ReadFile(filepath.Join(filepath.Dir(fb.root), "webhook_queue.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(fileBytes, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func (fb *FileBackend) DumpWebhookQueue(q *entities.WebhookQueue) error {
+	jsonData, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(filepath.Dir(fb.root), "webhook_queue.json"), jsonData, 0644)
+}
+
+// versioned is the file content, schema version is kept along with the list
+type versioned struct {
+	SchemaVersion int
+	*entities.EntriesLists
+}
+
+// SchemaVersion returns version of the file. File written before versioning
+// is version 0, missing file has the current version
+func (fb *FileBackend) SchemaVersion() (int, error) {
+	fileBytes, _ := os.ReadFile(fb.path)
+	if len(fileBytes) == 0 {
+		return schema.Version, nil
+	}
+
+	var v struct{ SchemaVersion int }
+	if err := json.Unmarshal(fileBytes, &v); err != nil {
+		return 0, err
+	}
+
+	return v.SchemaVersion, nil
+}
+
+// LoadList upgrades file written by older versions
 func (js *FileBackend) LoadList() (*entities.EntriesLists, error) {
 	fileBytes, _ := os.ReadFile(js.path)
 
-	elist := &entities.EntriesLists{
-		EntriesListsView: make(map[entities.ListTitle]*entities.List),
-	}
+	elist := entities.InitEmptyElist()
 
 	if len(fileBytes) == 0 {
 		return elist, nil
 	}
 
-	err := json.Unmarshal(fileBytes, elist)
+	version, err := js.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	fileBytes, err = schema.Upgrade(fileBytes, version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(fileBytes, elist)
 
 	if err != nil {
 		return nil, err
@@ -39,7 +218,7 @@ func (js *FileBackend) LoadList() (*entities.EntriesLists, error) {
 
 func (fb *FileBackend) DumpList(list *entities.EntriesLists) error {
 	// Marshal the data into JSON format
-	jsonData, err := json.MarshalIndent(list, "", "  ")
+	jsonData, err := json.MarshalIndent(versioned{schema.Version, list}, "", "  ")
 	if err != nil {
 		return err
 	}
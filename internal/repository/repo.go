@@ -1,10 +1,17 @@
 package repository
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/schema"
+	"github.com/Unheilbar/time_tracker/internal/vault"
 	"github.com/dgraph-io/badger"
 )
 
@@ -15,51 +22,627 @@ type DB interface {
 	Set(namespace, key, value []byte) error
 	Has(namespace, key []byte) (bool, error)
 	All(namespace, prefix []byte) (vals [][]byte, err error)
+	// From returns values by prefix in order of keys starting at the key from
+	From(namespace, prefix, from []byte) (vals [][]byte, err error)
+	RemoveNamespace(namespace []byte) error
+	// Rewrite replaces every stored value with the result of fn and sets the
+	// key of the namespace to value once all of them are replaced. Nil value
+	// removes the key
+	Rewrite(fn RewriteFunc, namespace, key, value []byte) error
 	Close() error
 }
 
+// RewriteFunc returns new value of the key
+type RewriteFunc func(namespace, key, value []byte) ([]byte, error)
+
 type Repository struct {
 	db DB
+	ns []byte
+	// cipher seals stored values, it's nil while encryption is disabled
+	cipher *vault.Cipher
+	// stored keeps loaded lists by namespaces, DumpList stores events of
+	// the difference
+	stored map[string]*stored
 }
 
 var Repo *Repository
 
 func NewRepo(db DB) *Repository {
-	return &Repository{db}
+	return &Repository{db: db, ns: defaultNs, stored: make(map[string]*stored)}
 }
 
+// listPrefix keeps the whole list stored before events were introduced
 var listPrefix = []byte("my_list")
-var ns = []byte("ns")
 
-func (repo *Repository) LoadList() (*entities.EntriesLists, error) {
-	enc, err := repo.db.Get(ns, listPrefix)
-	if err != nil && err != badger.ErrKeyNotFound {
+// Events are stored under eventPrefix with zero padded sequence, so keys are
+// ordered. headKey keeps sequence of the last event, events after it were
+// written by an interrupted run and are ignored
+var eventPrefix = []byte("event:")
+var headKey = []byte("event_head")
+
+// snapshotKey keeps lists replayed up to the sequence
+var snapshotKey = []byte("snapshot")
+
+// snapshotInterval is the number of events written before the next snapshot
+const snapshotInterval = 100
+
+type snapshot struct {
+	Seq   uint64
+	Lists json.RawMessage
+}
+
+// stored is the list of the workspace as it's stored
+type stored struct {
+	list     *entities.EntriesLists
+	head     uint64
+	snapshot uint64
+	// current is false when snapshot has to be written, for lists stored
+	// before events and by older schema versions
+	current bool
+}
+
+func eventKey(seq uint64) []byte {
+	return []byte(fmt.Sprintf("%s%020d", eventPrefix, seq))
+}
+
+// versionKey keeps schema version of my_list in the same namespace
+var versionKey = []byte("schema_version")
+var defaultNs = []byte("ns")
+
+var metaNs = []byte("meta")
+var workspacesKey = []byte("workspaces")
+var timeOffKey = []byte("time_off")
+var schedulesKey = []byte("schedules")
+var webhookQueueKey = []byte("webhook_queue")
+
+// encryptionKey keeps parameters of the key derivation in plain
+var encryptionKey = []byte("encryption")
+
+// nextEncryptionKey keeps parameters of the key being set till all the values
+// are rewritten, so an interrupted change is repeated with the same key.
+// plainParams there stands for decryption
+var nextEncryptionKey = []byte("encryption_next")
+var plainParams = []byte("plain")
+
+// workspaceNs returns namespace of the workspace. Default workspace keeps
+// namespace used before workspaces were introduced
+func workspaceNs(name string) []byte {
+	if name == entities.DefaultWorkspace {
+		return defaultNs
+	}
+
+	return []byte("ws:" + name)
+}
+
+// Close releases the database, so other processes can open it
+func (repo *Repository) Close() error {
+	return repo.db.Close()
+}
+
+// UseWorkspace switches repository to the namespace of the workspace
+func (repo *Repository) UseWorkspace(name string) {
+	repo.ns = workspaceNs(name)
+}
+
+// RemoveWorkspace drops all the data of the workspace
+func (repo *Repository) RemoveWorkspace(name string) error {
+	delete(repo.stored, string(workspaceNs(name)))
+	return repo.db.RemoveNamespace(workspaceNs(name))
+}
+
+func (repo *Repository) LoadWorkspaces() (*entities.Workspaces, error) {
+	enc, err := repo.get(metaNs, workspacesKey)
+	if err == badger.ErrKeyNotFound {
+		return entities.InitWorkspaces(), nil
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	res := &entities.EntriesLists{
-		EntriesListsView: make(map[entities.ListTitle]*entities.List),
-		Tags:             entities.TagsView{View: make(map[entities.Tag][]entities.ListTitle)},
+	res := entities.InitWorkspaces()
+	err = json.Unmarshal(enc, res)
+	if err != nil {
+		return nil, err
 	}
 
+	return res, nil
+}
+
+func (repo *Repository) DumpWorkspaces(ws *entities.Workspaces) error {
+	enc, err := json.Marshal(ws)
+	if err != nil {
+		return err
+	}
+
+	return repo.set(metaNs, workspacesKey, enc)
+}
+
+// LoadTimeOff returns days off shared by all workspaces
+func (repo *Repository) LoadTimeOff() (*entities.TimeOff, error) {
+	enc, err := repo.get(metaNs, timeOffKey)
+	if err == badger.ErrKeyNotFound {
+		return entities.InitTimeOff(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := entities.InitTimeOff()
+	if err := json.Unmarshal(enc, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *Repository) DumpTimeOff(to *entities.TimeOff) error {
+	enc, err := json.Marshal(to)
+	if err != nil {
+		return err
+	}
+
+	return repo.set(metaNs, timeOffKey, enc)
+}
+
+// LoadSchedules returns schedules of all workspaces
+func (repo *Repository) LoadSchedules() (*entities.Schedules, error) {
+	enc, err := repo.get(metaNs, schedulesKey)
 	if err == badger.ErrKeyNotFound {
+		return entities.InitSchedules(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := entities.InitSchedules()
+	if err := json.Unmarshal(enc, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *Repository) DumpSchedules(ss *entities.Schedules) error {
+	enc, err := json.Marshal(ss)
+	if err != nil {
+		return err
+	}
+
+	return repo.set(metaNs, schedulesKey, enc)
+}
+
+// LoadWebhookQueue returns failed webhook deliveries
+func (repo *Repository) LoadWebhookQueue() (*entities.WebhookQueue, error) {
+	enc, err := repo.get(metaNs, webhookQueueKey)
+	if err == badger.ErrKeyNotFound {
+		return entities.InitWebhookQueue(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := entities.InitWebhookQueue()
+	if err := json.Unmarshal(enc, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *Repository) DumpWebhookQueue(q *entities.WebhookQueue) error {
+	enc, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	return repo.set(metaNs, webhookQueueKey, enc)
+}
+
+// SchemaVersion returns version of the stored snapshot. List stored before
+// versioning is version 0, missing list has the current version
+func (repo *Repository) SchemaVersion() (int, error) {
+	enc, err := repo.get(repo.ns, versionKey)
+	if err == nil {
+		return strconv.Atoi(string(enc))
+	}
+	if err != badger.ErrKeyNotFound {
+		return 0, err
+	}
+
+	for _, key := range [][]byte{snapshotKey, listPrefix} {
+		ok, err := repo.db.Has(repo.ns, key)
+		if err != nil || ok {
+			return 0, err
+		}
+	}
+
+	return schema.Version, nil
+}
+
+// LoadList replays events stored after the snapshot. Snapshot written by
+// older versions is upgraded
+func (repo *Repository) LoadList() (*entities.EntriesLists, error) {
+	res, s, err := repo.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
 		log.Print("Create task list at db")
-		return res, nil
+		res = entities.InitEmptyElist()
+	}
+
+	s.head, err = repo.head()
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := repo.Events(s.snapshot)
+	if err != nil {
+		return nil, err
 	}
 
-	err = json.Unmarshal(enc, &res)
+	if err := res.Replay(events); err != nil {
+		return nil, err
+	}
+
+	s.list, err = res.Clone()
 	if err != nil {
 		return nil, err
 	}
+	repo.stored[string(repo.ns)] = s
 
 	return res, nil
 }
 
+// loadSnapshot returns nil list when nothing is stored. List stored before
+// events is the snapshot before the first event
+func (repo *Repository) loadSnapshot() (*entities.EntriesLists, *stored, error) {
+	s := &stored{}
+
+	enc, err := repo.get(repo.ns, snapshotKey)
+	switch err {
+	case nil:
+		var snap snapshot
+		if err := json.Unmarshal(enc, &snap); err != nil {
+			return nil, nil, err
+		}
+		s.snapshot, s.current, enc = snap.Seq, true, snap.Lists
+	case badger.ErrKeyNotFound:
+		enc, err = repo.get(repo.ns, listPrefix)
+		if err == badger.ErrKeyNotFound {
+			return nil, s, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, err
+	}
+
+	version, err := repo.SchemaVersion()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.current = s.current && version == schema.Version
+
+	enc, err = schema.Upgrade(enc, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res := entities.InitEmptyElist()
+	if err := json.Unmarshal(enc, &res); err != nil {
+		return nil, nil, err
+	}
+
+	return res, s, nil
+}
+
+// DumpList appends events turning the loaded list into l. Snapshot is written
+// every snapshotInterval events and when events don't describe the change
 func (repo *Repository) DumpList(l *entities.EntriesLists) error {
-	enc, err := json.Marshal(l)
+	s, ok := repo.stored[string(repo.ns)]
+	if !ok {
+		if _, err := repo.LoadList(); err != nil {
+			return err
+		}
+		s = repo.stored[string(repo.ns)]
+	}
+
+	events, exact := entities.Diff(s.list, l, time.Now())
+	for i := range events {
+		events[i].Seq = s.head + uint64(i) + 1
+		enc, err := json.Marshal(events[i])
+		if err != nil {
+			return err
+		}
+
+		if err := repo.set(repo.ns, eventKey(events[i].Seq), enc); err != nil {
+			return err
+		}
+	}
+
+	if len(events) > 0 {
+		head := s.head + uint64(len(events))
+		if err := repo.set(repo.ns, headKey, []byte(strconv.FormatUint(head, 10))); err != nil {
+			return err
+		}
+		s.head = head
+	}
+
+	if !exact || !s.current || s.head-s.snapshot >= snapshotInterval {
+		if err := repo.writeSnapshot(l, s.head); err != nil {
+			return err
+		}
+		s.snapshot, s.current = s.head, true
+	}
+	l.ForgetRenames()
+
+	var err error
+	s.list, err = l.Clone()
+
+	return err
+}
+
+func (repo *Repository) writeSnapshot(l *entities.EntriesLists, seq uint64) error {
+	lists, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	enc, err := json.Marshal(snapshot{Seq: seq, Lists: lists})
 	if err != nil {
 		return err
 	}
 
-	return repo.db.Set(ns, listPrefix, enc)
+	if err := repo.set(repo.ns, snapshotKey, enc); err != nil {
+		return err
+	}
+
+	if err := repo.set(repo.ns, versionKey, []byte(strconv.Itoa(schema.Version))); err != nil {
+		return err
+	}
+
+	// the list stored before events is in the snapshot now
+	return repo.db.Remove(repo.ns, listPrefix)
+}
+
+func (repo *Repository) head() (uint64, error) {
+	enc, err := repo.get(repo.ns, headKey)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(string(enc), 10, 64)
+}
+
+// Events returns events of the workspace stored after the sequence
+func (repo *Repository) Events(after uint64) ([]entities.Event, error) {
+	head, err := repo.head()
+	if err != nil {
+		return nil, err
+	}
+
+	vals, err := repo.db.From(repo.ns, eventPrefix, eventKey(after+1))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []entities.Event
+	for _, enc := range vals {
+		enc, err = repo.open(enc)
+		if err != nil {
+			return nil, err
+		}
+
+		var e entities.Event
+		if err := json.Unmarshal(enc, &e); err != nil {
+			return nil, err
+		}
+		if e.Seq > head {
+			break
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// SnapshotSeq returns sequence of the last event in the stored snapshot
+func (repo *Repository) SnapshotSeq() (uint64, error) {
+	enc, err := repo.get(repo.ns, snapshotKey)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var snap snapshot
+	err = json.Unmarshal(enc, &snap)
+
+	return snap.Seq, err
+}
+
+// get returns the stored value opening it when encryption is enabled
+func (repo *Repository) get(namespace, key []byte) ([]byte, error) {
+	value, err := repo.db.Get(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.open(value)
+}
+
+// open returns the value as is while encryption is disabled
+func (repo *Repository) open(value []byte) ([]byte, error) {
+	if repo.cipher == nil {
+		return value, nil
+	}
+
+	return repo.cipher.Open(value)
+}
+
+// set stores the value sealing it when encryption is enabled
+func (repo *Repository) set(namespace, key, value []byte) error {
+	if repo.cipher != nil {
+		sealed, err := repo.cipher.Seal(value)
+		if err != nil {
+			return err
+		}
+		value = sealed
+	}
+
+	return repo.db.Set(namespace, key, value)
+}
+
+// Encrypted reports whether stored values are sealed
+func (repo *Repository) Encrypted() (bool, error) {
+	return repo.db.Has(metaNs, encryptionKey)
+}
+
+// Unlock derives the key opening stored values from the passphrase or
+// the key file content. It fails with vault.ErrWrongKey on a wrong secret
+func (repo *Repository) Unlock(secret []byte) error {
+	enc, err := repo.db.Get(metaNs, encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	params, err := vault.Unmarshal(enc)
+	if err != nil {
+		return err
+	}
+
+	repo.cipher, err = params.Unlock(secret)
+
+	return err
+}
+
+// SetEncryption seals all the stored values with the key derived from the
+// secret with a new salt. Nil secret stores the values in plain. Repository
+// must be unlocked before. Interrupted change is finished by the next call
+// with the same secret
+func (repo *Repository) SetEncryption(secret []byte) error {
+	next, params, err := repo.nextCipher(secret)
+	if err != nil {
+		return err
+	}
+
+	err = repo.db.Rewrite(func(namespace, key, value []byte) ([]byte, error) {
+		if bytes.Equal(namespace, metaNs) && (bytes.Equal(key, encryptionKey) || bytes.Equal(key, nextEncryptionKey)) {
+			return value, nil
+		}
+
+		return repo.reseal(value, next)
+	}, metaNs, encryptionKey, params)
+	if err != nil {
+		return err
+	}
+
+	repo.cipher = next
+
+	return repo.db.Remove(metaNs, nextEncryptionKey)
+}
+
+var errInterrupted = errors.New("previous encryption change was interrupted, repeat it with the same passphrase or key file")
+
+// nextCipher returns the cipher of the key being set with its parameters,
+// nil ones for decryption. Key of an interrupted change is used again
+func (repo *Repository) nextCipher(secret []byte) (*vault.Cipher, []byte, error) {
+	pending, err := repo.db.Get(metaNs, nextEncryptionKey)
+	if err == badger.ErrKeyNotFound {
+		return repo.newCipher(secret)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := repo.db.Get(metaNs, encryptionKey)
+	if err != nil && err != badger.ErrKeyNotFound {
+		return nil, nil, err
+	}
+	// the change was interrupted after the final commit only
+	if bytes.Equal(pending, current) || (bytes.Equal(pending, plainParams) && current == nil) {
+		return repo.newCipher(secret)
+	}
+
+	if bytes.Equal(pending, plainParams) {
+		if secret != nil {
+			return nil, nil, errors.New("previous decryption was interrupted, repeat encryption disable")
+		}
+		return nil, nil, nil
+	}
+	if secret == nil {
+		return nil, nil, errInterrupted
+	}
+
+	params, err := vault.Unmarshal(pending)
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err := params.Unlock(secret)
+	if errors.Is(err, vault.ErrWrongKey) {
+		return nil, nil, errInterrupted
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return next, pending, nil
+}
+
+// newCipher derives a key with a new salt and keeps its parameters till the
+// change is done
+func (repo *Repository) newCipher(secret []byte) (*vault.Cipher, []byte, error) {
+	if secret == nil {
+		return nil, nil, repo.db.Set(metaNs, nextEncryptionKey, plainParams)
+	}
+
+	p, next, err := vault.New(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	params, err := p.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := repo.db.Set(metaNs, nextEncryptionKey, params); err != nil {
+		return nil, nil, err
+	}
+
+	return next, params, nil
+}
+
+// reseal opens the value and seals it with next, nil next leaves it plain.
+// Values rewritten by an interrupted change already are kept
+func (repo *Repository) reseal(value []byte, next *vault.Cipher) ([]byte, error) {
+	if next != nil {
+		if _, err := next.Open(value); err == nil {
+			return value, nil
+		}
+	}
+
+	plain := value
+	if repo.cipher != nil {
+		var err error
+		plain, err = repo.cipher.Open(value)
+		if errors.Is(err, vault.ErrNotSealed) && next == nil {
+			return value, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if next == nil {
+		return plain, nil
+	}
+
+	return next.Seal(plain)
 }
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRepo(t *testing.T) (*Repository, DB) {
+	db, err := NewBadgerDB(t.TempDir())
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewRepo(db), db
+}
+
+// sameSessions compares sessions as they are stored, loaded times lack
+// monotonic clock
+func sameSessions(t *testing.T, expected, actual *entities.List) {
+	e, err := json.Marshal(expected.Sessions())
+	assert.NoError(t, err)
+	a, err := json.Marshal(actual.Sessions())
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(e), string(a))
+}
+
+func Test_Events(t *testing.T) {
+	repo, _ := testRepo(t)
+
+	l, err := repo.LoadList()
+	assert.NoError(t, err)
+	l.InsertEntry("api", entities.StatusActive)
+	assert.NoError(t, repo.DumpList(l))
+	l.InsertEntry("docs", entities.StatusActive)
+	assert.NoError(t, repo.DumpList(l))
+
+	events, err := repo.Events(0)
+	assert.NoError(t, err)
+	assert.True(t, len(events) > 2)
+	for i, e := range events {
+		assert.Equal(t, uint64(i+1), e.Seq)
+	}
+
+	after, err := repo.Events(2)
+	assert.NoError(t, err)
+	assert.Equal(t, events[2:], after)
+}
+
+func Test_InterruptedRun(t *testing.T) {
+	repo, db := testRepo(t)
+
+	l, err := repo.LoadList()
+	assert.NoError(t, err)
+	l.InsertEntry("api", entities.StatusActive)
+	assert.NoError(t, repo.DumpList(l))
+	head, err := repo.head()
+	assert.NoError(t, err)
+
+	// the run is interrupted before the head is moved
+	ghost, err := json.Marshal(entities.Event{Seq: head + 1, Type: entities.EventTaskCreated, Title: "ghost"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Set(defaultNs, eventKey(head+1), ghost))
+
+	repo = NewRepo(db)
+	l, err = repo.LoadList()
+	assert.NoError(t, err)
+	assert.NotContains(t, l.EntriesListsView, entities.ListTitle("ghost"))
+
+	events, err := repo.Events(0)
+	assert.NoError(t, err)
+	assert.Equal(t, head, events[len(events)-1].Seq)
+
+	// the next run takes the place of the discarded events
+	l.InsertEntry("api", entities.StatusStop)
+	assert.NoError(t, repo.DumpList(l))
+	events, err = repo.Events(head)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.EventStopped, events[0].Type)
+	assert.Equal(t, entities.ListTitle("api"), events[0].Title)
+}
+
+func Test_Snapshot(t *testing.T) {
+	repo, db := testRepo(t)
+
+	l, err := repo.LoadList()
+	assert.NoError(t, err)
+	l.InsertEntry("api", entities.StatusActive)
+	assert.NoError(t, repo.DumpList(l))
+
+	// new list is stored with the snapshot
+	first, err := repo.SnapshotSeq()
+	assert.NoError(t, err)
+	head, err := repo.head()
+	assert.NoError(t, err)
+	assert.Equal(t, head, first)
+
+	for head-first < snapshotInterval {
+		seq, err := repo.SnapshotSeq()
+		assert.NoError(t, err)
+		assert.Equal(t, first, seq)
+
+		status := entities.StatusStop
+		if !l.IsActive("api") {
+			status = entities.StatusActive
+		}
+		l.InsertEntry("api", status)
+		assert.NoError(t, repo.DumpList(l))
+
+		head, err = repo.head()
+		assert.NoError(t, err)
+	}
+
+	seq, err := repo.SnapshotSeq()
+	assert.NoError(t, err)
+	assert.Equal(t, head, seq)
+
+	repo = NewRepo(db)
+	events, err := repo.Events(seq)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	loaded, err := repo.LoadList()
+	assert.NoError(t, err)
+	sameSessions(t, l.EntriesListsView["api"], loaded.EntriesListsView["api"])
+}
+
+func Test_LegacyList(t *testing.T) {
+	repo, db := testRepo(t)
+
+	legacy := `{"CurrentActive":"api","EntriesListsView":{"api":{"Title":"api","Created":"2024-01-01T10:00:00Z",` +
+		`"Tags":["#work","#work"],"States":[{"Timestamp":"2024-01-01T10:00:00Z","TotalDuration":0,"Status":1}]}},` +
+		`"Tags":{"View":{"#work":["api","api"]}}}`
+	assert.NoError(t, db.Set(defaultNs, listPrefix, []byte(legacy)))
+
+	version, err := repo.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	l, err := repo.LoadList()
+	assert.NoError(t, err)
+	assert.Equal(t, entities.ListTitle("api"), l.CurrentActive)
+	assert.Equal(t, []entities.Tag{"#work"}, l.EntriesListsView["api"].Tags)
+
+	// the first dump moves the list into the snapshot
+	assert.NoError(t, repo.DumpList(l))
+	ok, err := db.Has(defaultNs, listPrefix)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	version, err = repo.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, schema.Version, version)
+
+	repo = NewRepo(db)
+	loaded, err := repo.LoadList()
+	assert.NoError(t, err)
+	sameSessions(t, l.EntriesListsView["api"], loaded.EntriesListsView["api"])
+}
+
+func Test_Rename(t *testing.T) {
+	repo, _ := testRepo(t)
+
+	l, err := repo.LoadList()
+	assert.NoError(t, err)
+	l.InsertEntry("api", entities.StatusActive)
+	assert.NoError(t, repo.DumpList(l))
+
+	renamed := func(from, to entities.ListTitle) {
+		head, err := repo.head()
+		assert.NoError(t, err)
+
+		assert.NoError(t, l.Rename(from, to))
+		assert.NoError(t, repo.DumpList(l))
+
+		events, err := repo.Events(head)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, entities.EventRenamed, events[0].Type)
+		assert.Equal(t, from, events[0].Title)
+		assert.Equal(t, to, events[0].To)
+	}
+
+	// the stored rename isn't described again
+	renamed("api", "backend")
+	renamed("backend", "server")
+}
+
+func Test_Workspaces(t *testing.T) {
+	repo, db := testRepo(t)
+
+	dump := func(workspace string, title entities.ListTitle) {
+		repo.UseWorkspace(workspace)
+		l, err := repo.LoadList()
+		assert.NoError(t, err)
+		l.InsertEntry(title, entities.StatusActive)
+		assert.NoError(t, repo.DumpList(l))
+	}
+	titles := func(workspace string) []entities.ListTitle {
+		repo.UseWorkspace(workspace)
+		list, err := repo.LoadList()
+		assert.NoError(t, err)
+
+		var res []entities.ListTitle
+		for _, l := range list.Lists() {
+			res = append(res, l.Title)
+		}
+		return res
+	}
+
+	dump(entities.DefaultWorkspace, "api")
+	dump("work", "review")
+	dump("work-2", "docs")
+
+	assert.Equal(t, []entities.ListTitle{"api"}, titles(entities.DefaultWorkspace))
+	assert.Equal(t, []entities.ListTitle{"review"}, titles("work"))
+	assert.Equal(t, []entities.ListTitle{"docs"}, titles("work-2"))
+
+	// only the namespace of the workspace is dropped
+	assert.NoError(t, repo.RemoveWorkspace("work"))
+	for _, key := range [][]byte{snapshotKey, headKey, eventKey(1)} {
+		ok, err := db.Has(workspaceNs("work"), key)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Empty(t, titles("work"))
+	assert.Equal(t, []entities.ListTitle{"api"}, titles(entities.DefaultWorkspace))
+	assert.Equal(t, []entities.ListTitle{"docs"}, titles("work-2"))
+
+	// a new repository sees the same
+	repo = NewRepo(db)
+	assert.Empty(t, titles("work"))
+	assert.Equal(t, []entities.ListTitle{"docs"}, titles("work-2"))
+}
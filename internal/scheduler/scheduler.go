@@ -0,0 +1,157 @@
+// Package scheduler turns occurrences of scheduled tasks into sessions
+package scheduler
+
+import (
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/cron"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// maxOccurrences bounds a single run, the rest is done by the next one
+const maxOccurrences = 1000
+
+// Reasons of skipped occurrences
+const (
+	SkipDayOff    = "day off"
+	SkipTracked   = "task is tracked that day"
+	SkipOverlap   = "overlaps tracked session"
+	SkipRunning   = "task is running"
+	SkipBusy      = "another task is running"
+	SkipMissed    = "missed"
+	SkipWrongCron = "wrong cron"
+)
+
+// Result tells what was done with an occurrence
+type Result struct {
+	Schedule *entities.Schedule
+	Start    time.Time
+	End      time.Time
+	// Started is set for started tasks, Stopped for the ones stopped at the
+	// end of their occurrence, others are logged or skipped
+	Started bool
+	Stopped bool
+	Skipped string
+}
+
+// Run materializes occurrences of the schedules passed since their Done
+// into the list. Logged occurrences wait till their end, started ones are
+// started at their start only while they last and nothing else runs, they
+// are stopped at their end. Days for which off reports true are skipped,
+// off may be nil
+func Run(list *entities.EntriesLists, schedules []*entities.Schedule, off func(time.Time) bool, now time.Time) []Result {
+	var res []Result
+	for _, s := range schedules {
+		if r, ok := stopStarted(list, s, now); ok {
+			res = append(res, r)
+		}
+
+		spec, err := cron.Parse(s.Cron)
+		if err != nil {
+			res = append(res, Result{Schedule: s, Skipped: SkipWrongCron})
+			continue
+		}
+
+		for n := 0; n < maxOccurrences; n++ {
+			start := spec.Next(s.Done.In(now.Location()))
+			if start.IsZero() || start.After(now) {
+				break
+			}
+			end := start.Add(s.Duration)
+			// it's logged when it's over
+			if !s.Start && end.After(now) {
+				break
+			}
+
+			r := Result{Schedule: s, Start: start, End: end}
+			switch {
+			case s.Start && !now.Before(end):
+				r.Skipped = SkipMissed
+			case off != nil && off(start):
+				r.Skipped = SkipDayOff
+			case trackedOn(list, s.Title, start):
+				r.Skipped = SkipTracked
+			case s.Start && list.IsActive(s.Title):
+				r.Skipped = SkipRunning
+			case s.Start && len(list.ActiveTitles()) > 0:
+				r.Skipped = SkipBusy
+			case s.Start:
+				if err := list.StartAt(s.Title, start); err != nil {
+					r.Skipped = SkipOverlap
+					break
+				}
+				r.Started = true
+				s.Started = start
+			default:
+				if err := list.AddSession(s.Title, start, end, nil); err != nil {
+					r.Skipped = SkipOverlap
+				}
+			}
+
+			if r.Skipped == "" {
+				for _, tag := range s.Tags {
+					list.AddTag(tag, s.Title)
+				}
+			}
+
+			s.Done = start
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// stopStarted stops the session started by the schedule at the end of its
+// occurrence. Session stopped by hand is forgotten
+func stopStarted(list *entities.EntriesLists, s *entities.Schedule, now time.Time) (Result, bool) {
+	if s.Started.IsZero() {
+		return Result{}, false
+	}
+
+	start, end := s.Started, s.Started.Add(s.Duration)
+	if !runningSince(list, s.Title, start) {
+		s.Started = time.Time{}
+		return Result{}, false
+	}
+	if now.Before(end) {
+		return Result{}, false
+	}
+
+	s.Started = time.Time{}
+	if err := list.StopAt(s.Title, end); err != nil {
+		return Result{}, false
+	}
+
+	return Result{Schedule: s, Start: start, End: end, Stopped: true}, true
+}
+
+// runningSince reports whether the task runs the session started at the
+// moment
+func runningSince(list *entities.EntriesLists, title entities.ListTitle, start time.Time) bool {
+	l, ok := list.EntriesListsView[title]
+	if !ok || !list.IsActive(title) {
+		return false
+	}
+
+	sessions := l.Sessions()
+
+	return len(sessions) > 0 && sessions[len(sessions)-1].Start.Equal(start)
+}
+
+// trackedOn reports whether the task has a session on the day of the moment
+func trackedOn(list *entities.EntriesLists, title entities.ListTitle, at time.Time) bool {
+	l, ok := list.EntriesListsView[title]
+	if !ok {
+		return false
+	}
+
+	y, m, d := at.Date()
+	for _, s := range l.Sessions() {
+		if sy, sm, sd := s.Start.In(at.Location()).Date(); sy == y && sm == m && sd == d {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Run(t *testing.T) {
+	// 2024-01-01 is monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	standup := &entities.Schedule{
+		Title:    "standup",
+		Cron:     "0 10 * * 1-5",
+		Duration: 15 * time.Minute,
+		Tags:     []entities.Tag{"#meeting"},
+		Done:     at(1, 0, 0),
+	}
+
+	elist := entities.InitEmptyElist()
+	// tuesday standup is tracked by hand, wednesday is busy
+	assert.NoError(t, elist.AddSession("standup", at(2, 10, 5), at(2, 10, 20), nil))
+	assert.NoError(t, elist.AddSession("api", at(3, 9, 0), at(3, 12, 0), nil))
+
+	off := func(t time.Time) bool { return t.Day() == 4 }
+
+	// friday standup isn't over yet
+	res := Run(elist, []*entities.Schedule{standup}, off, at(5, 10, 5))
+	assert.Equal(t, 4, len(res))
+	assert.Equal(t, "", res[0].Skipped)
+	assert.Equal(t, SkipTracked, res[1].Skipped)
+	assert.Equal(t, SkipOverlap, res[2].Skipped)
+	assert.Equal(t, SkipDayOff, res[3].Skipped)
+	assert.Equal(t, at(4, 10, 0), standup.Done)
+
+	l := elist.EntriesListsView["standup"]
+	assert.Equal(t, 2, len(l.Sessions()))
+	assert.Equal(t, []entities.Tag{"#meeting"}, l.Tags)
+
+	res = Run(elist, []*entities.Schedule{standup}, off, at(5, 10, 15))
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, at(5, 10, 15), res[0].End)
+	assert.Equal(t, 3, len(l.Sessions()))
+
+	review := &entities.Schedule{
+		Title:    "review",
+		Cron:     "0 16 * * *",
+		Duration: time.Hour,
+		Start:    true,
+		Done:     at(5, 10, 15),
+	}
+	res = Run(elist, []*entities.Schedule{standup, review}, nil, at(6, 16, 30))
+	// saturday has no standup
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, SkipMissed, res[0].Skipped)
+	assert.True(t, res[1].Started)
+	assert.True(t, elist.IsActive("review"))
+	// started at the occurrence, not at the run
+	sessions := elist.EntriesListsView["review"].Sessions()
+	assert.Equal(t, at(6, 16, 0), sessions[len(sessions)-1].Start)
+
+	res = Run(elist, []*entities.Schedule{review}, nil, at(6, 16, 45))
+	assert.Equal(t, 0, len(res))
+
+	// stopped at the end of the occurrence
+	res = Run(elist, []*entities.Schedule{review}, nil, at(6, 17, 30))
+	assert.Equal(t, 1, len(res))
+	assert.True(t, res[0].Stopped)
+	assert.False(t, elist.IsActive("review"))
+	sessions = elist.EntriesListsView["review"].Sessions()
+	assert.Equal(t, at(6, 17, 0), sessions[len(sessions)-1].End)
+
+	// running task of the user is kept
+	elist.InsertEntry("api", entities.StatusActive)
+	res = Run(elist, []*entities.Schedule{review}, nil, at(7, 16, 30))
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, SkipBusy, res[0].Skipped)
+	assert.Equal(t, entities.ListTitle("api"), elist.CurrentActive)
+	assert.False(t, elist.IsActive("review"))
+
+	res = Run(elist, []*entities.Schedule{{Title: "x", Cron: "wrong"}}, nil, at(6, 16, 30))
+	assert.Equal(t, SkipWrongCron, res[0].Skipped)
+}
@@ -0,0 +1,186 @@
+// Package schema keeps the version of stored task lists and upgrades older
+// documents step by step. Migrations work on raw JSON, so they don't depend
+// on the current entities which may not decode old data anymore.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Version of the stored lists written by this build
+const Version = 2
+
+// Document is the decoded JSON of the stored lists
+type Document map[string]interface{}
+
+// Migration upgrades document of version From to From+1
+type Migration struct {
+	From        int
+	Description string
+	up          func(doc Document) error
+}
+
+// migrations are ordered by From, each version has exactly one migration.
+// Unversioned data, as it was stored before versioning, is version 0
+var migrations = []Migration{
+	{
+		From:        0,
+		Description: "deduplicate tags and rebuild tag index from task tags",
+		up:          rebuildTags,
+	},
+	{
+		From:        1,
+		Description: "store session status as text instead of its number",
+		up:          statusText,
+	},
+}
+
+// Pending returns migrations needed to upgrade document of the version
+func Pending(from int) ([]Migration, error) {
+	if from > Version {
+		return nil, fmt.Errorf("data has schema version %d, this build supports up to %d", from, Version)
+	}
+	if from < 0 {
+		return nil, fmt.Errorf("wrong schema version %d", from)
+	}
+
+	return migrations[from:], nil
+}
+
+// Upgrade applies pending migrations to the encoded document
+func Upgrade(data []byte, from int) ([]byte, error) {
+	pending, err := Pending(from)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return data, nil
+	}
+
+	// numbers are kept as is, durations in nanoseconds don't fit float64
+	doc := Document{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for _, m := range pending {
+		if err := m.up(doc); err != nil {
+			return nil, fmt.Errorf("migration from version %d: %w", m.From, err)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// rebuildTags fixes tag index left by removal which dropped the whole tag
+// instead of the removed task and by repeated tagging
+func rebuildTags(doc Document) error {
+	lists, err := doc.object("EntriesListsView")
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]interface{})
+	for _, title := range sortedKeys(lists) {
+		list, ok := lists[title].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("task %q isn't an object", title)
+		}
+
+		tags, _ := list["Tags"].([]interface{})
+		seen := make(map[string]bool)
+		var dedup []interface{}
+		for _, t := range tags {
+			tag, ok := t.(string)
+			if !ok || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			dedup = append(dedup, tag)
+
+			titles, _ := index[tag].([]interface{})
+			index[tag] = append(titles, title)
+		}
+		list["Tags"] = dedup
+	}
+
+	doc["Tags"] = map[string]interface{}{"View": index}
+
+	if doc["Trash"] == nil {
+		doc["Trash"] = map[string]interface{}{}
+	}
+
+	return nil
+}
+
+var statusNames = map[json.Number]string{
+	"0": "stopped",
+	"1": "active",
+}
+
+// statusText replaces numbers of the status enum, which depended on the order
+// of constants, with names
+func statusText(doc Document) error {
+	for _, key := range []string{"EntriesListsView", "Trash"} {
+		lists, err := doc.object(key)
+		if err != nil {
+			return err
+		}
+
+		for title, l := range lists {
+			list, ok := l.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("task %q isn't an object", title)
+			}
+
+			states, _ := list["States"].([]interface{})
+			for _, s := range states {
+				state, ok := s.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("task %q has wrong state", title)
+				}
+
+				// already converted states are kept
+				num, ok := state["Status"].(json.Number)
+				if !ok {
+					continue
+				}
+
+				name, ok := statusNames[num]
+				if !ok {
+					return fmt.Errorf("task %q has unknown status %v", title, num)
+				}
+				state["Status"] = name
+			}
+		}
+	}
+
+	return nil
+}
+
+// object returns nested object, missing one is returned empty
+func (doc Document) object(key string) (map[string]interface{}, error) {
+	switch v := doc[key].(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s isn't an object", key)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
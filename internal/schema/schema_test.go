@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// load upgrades frozen fixture of the version and decodes it with current entities
+func load(t *testing.T, name string, version int) *entities.EntriesLists {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+
+	data, err = Upgrade(data, version)
+	assert.NoError(t, err)
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, json.Unmarshal(data, elist))
+
+	return elist
+}
+
+func Test_Baseline(t *testing.T) {
+	elist := load(t, "v0_baseline.json", 0)
+
+	api := elist.EntriesListsView["api"]
+	assert.Equal(t, []entities.Tag{"#work", "#x"}, api.Tags)
+	assert.Equal(t, entities.StatusActive, api.States[2].Status)
+	assert.Equal(t, entities.ListTitle("api"), elist.CurrentActive)
+
+	// index dropped by removal of another task is rebuilt, removed task is gone
+	assert.Equal(t, 1, len(elist.Filter([]entities.Tag{"#work"}, entities.ContainsAll)))
+	assert.Equal(t, []entities.ListTitle{"api"}, elist.Tags.View["#x"])
+	assert.NotNil(t, elist.Trash)
+
+	// durations above float64 precision are kept
+	docs := elist.EntriesListsView["docs"]
+	assert.Equal(t, entities.StatusStop, docs.States[1].Status)
+	assert.Equal(t, time.Duration(9007199254740993), docs.Total())
+}
+
+func Test_Trash(t *testing.T) {
+	elist := load(t, "v0_trash.json", 0)
+
+	assert.Equal(t, []entities.ListTitle{"api", "review"}, elist.ActiveTitles())
+	assert.Equal(t, entities.ListTitle("api"), elist.EntriesListsView["review"].Parent)
+	assert.Equal(t, []string{"PROJ-1"}, elist.EntriesListsView["api"].Issues)
+	assert.Equal(t, "0123456789abcdef", elist.EntriesListsView["api"].Sessions()[0].Commits[0].Hash)
+	assert.True(t, elist.EntriesListsView["old"].Archived)
+
+	gone := elist.Trash["gone"]
+	assert.Equal(t, entities.StatusStop, gone.States[1].Status)
+	assert.Equal(t, 30*time.Minute, gone.Total())
+	assert.Equal(t, []entities.ListTitle{"api"}, elist.Tags.View["#work"])
+}
+
+func Test_Versions(t *testing.T) {
+	v1 := load(t, "v1.json", 1)
+	v2 := load(t, "v2.json", 2)
+
+	assert.Equal(t, time.Hour, v1.EntriesListsView["api"].Total())
+	assert.Equal(t, entities.StatusStop, v1.EntriesListsView["api"].States[1].Status)
+	assert.Equal(t, entities.StatusActive, v2.EntriesListsView["api"].States[2].Status)
+
+	// current version is decoded as is and encoded back the same way
+	data, err := json.Marshal(v2)
+	assert.NoError(t, err)
+	frozen, err := os.ReadFile(filepath.Join("testdata", "v2.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(frozen), string(data))
+
+	_, err = Upgrade(data, Version+1)
+	assert.Error(t, err)
+}
+
+func Test_Pending(t *testing.T) {
+	pending, err := Pending(0)
+	assert.NoError(t, err)
+	assert.Equal(t, Version, len(pending))
+
+	for i, m := range pending {
+		assert.Equal(t, i, m.From)
+	}
+
+	pending, err = Pending(Version)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,211 @@
+// Package stats computes productivity statistics from tracked sessions
+package stats
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Duration is marshaled as whole seconds
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int64(time.Duration(d).Seconds()))
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).Truncate(time.Second).String()
+}
+
+// Bin counts sessions not shorter than Min and shorter than Max. Zero Max
+// is open
+type Bin struct {
+	Label string   `json:"label"`
+	Min   Duration `json:"min_seconds"`
+	Max   Duration `json:"max_seconds,omitempty"`
+	Count int      `json:"count"`
+}
+
+// bins split session lengths for the distribution
+var bins = []Bin{
+	{Label: "< 15m", Max: Duration(15 * time.Minute)},
+	{Label: "15m - 30m", Min: Duration(15 * time.Minute), Max: Duration(30 * time.Minute)},
+	{Label: "30m - 1h", Min: Duration(30 * time.Minute), Max: Duration(time.Hour)},
+	{Label: "1h - 2h", Min: Duration(time.Hour), Max: Duration(2 * time.Hour)},
+	{Label: "2h - 4h", Min: Duration(2 * time.Hour), Max: Duration(4 * time.Hour)},
+	{Label: ">= 4h", Min: Duration(4 * time.Hour)},
+}
+
+// Day is the activity of a single day
+type Day struct {
+	Date     string   `json:"date"`
+	Tracked  Duration `json:"tracked_seconds"`
+	Switches int      `json:"switches"`
+}
+
+// Stats are computed for sessions started within the range
+type Stats struct {
+	Sessions int      `json:"sessions"`
+	Tracked  Duration `json:"tracked_seconds"`
+	Average  Duration `json:"average_session_seconds"`
+	Longest  Duration `json:"longest_session_seconds"`
+	// Streaks are numbers of days in a row with tracked time. Current one
+	// ends today or yesterday
+	LongestStreak int `json:"longest_streak_days"`
+	CurrentStreak int `json:"current_streak_days"`
+
+	Distribution []Bin `json:"distribution"`
+
+	// Switches is the number of tasks started as the current one, parallel
+	// starts don't switch context
+	Switches        int      `json:"switches"`
+	SwitchesPerDay  float64  `json:"switches_per_day"`
+	Days            []Day    `json:"days"`
+	BusiestHour     int      `json:"busiest_hour"`
+	BusiestHourTime Duration `json:"busiest_hour_seconds"`
+
+	DeepWorkThreshold Duration `json:"deep_work_threshold_seconds"`
+	DeepWorkSessions  int      `json:"deep_work_sessions"`
+	// DeepWorkShare is the share of sessions not shorter than the threshold,
+	// DeepWorkTimeShare is the share of their time
+	DeepWorkShare     float64 `json:"deep_work_share"`
+	DeepWorkTimeShare float64 `json:"deep_work_time_share"`
+}
+
+// Compute returns statistics of the sessions of the lists started within
+// the range. Running sessions last till now, its location sets days
+func Compute(lists []*entities.List, r entities.Range, deepWork time.Duration, now time.Time) Stats {
+	st := Stats{DeepWorkThreshold: Duration(deepWork), BusiestHour: -1}
+	st.Distribution = append(st.Distribution, bins...)
+
+	var all []entities.Session
+	for _, l := range lists {
+		all = append(all, l.Sessions()...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Start.Before(all[j].Start) })
+
+	loc := now.Location()
+	days := make(map[string]*Day)
+	day := func(t time.Time) *Day {
+		date := t.In(loc).Format(time.DateOnly)
+		d, ok := days[date]
+		if !ok {
+			d = &Day{Date: date}
+			days[date] = d
+		}
+		return d
+	}
+
+	var hours [24]time.Duration
+	var deepTime time.Duration
+	for _, s := range all {
+		if !inRange(s.Start, r) {
+			continue
+		}
+
+		end := s.End
+		if s.Running() {
+			end = now
+		}
+		d := end.Sub(s.Start)
+
+		st.Sessions++
+		st.Tracked += Duration(d)
+		if Duration(d) > st.Longest {
+			st.Longest = Duration(d)
+		}
+
+		for j := range st.Distribution {
+			b := &st.Distribution[j]
+			if Duration(d) >= b.Min && (b.Max == 0 || Duration(d) < b.Max) {
+				b.Count++
+			}
+		}
+
+		if d >= deepWork {
+			st.DeepWorkSessions++
+			deepTime += d
+		}
+
+		if !s.Parallel {
+			st.Switches++
+			day(s.Start).Switches++
+		}
+
+		// hours of the wall clock, zones may be shifted by half an hour
+		for at := s.Start.In(loc); at.Before(end); {
+			next := time.Date(at.Year(), at.Month(), at.Day(), at.Hour()+1, 0, 0, 0, loc)
+			if next.After(end) {
+				next = end
+			}
+			hours[at.Hour()] += next.Sub(at)
+			day(at).Tracked += Duration(next.Sub(at))
+			at = next
+		}
+	}
+
+	if st.Sessions == 0 {
+		return st
+	}
+
+	st.Average = st.Tracked / Duration(st.Sessions)
+	st.DeepWorkShare = float64(st.DeepWorkSessions) / float64(st.Sessions)
+	if st.Tracked > 0 {
+		st.DeepWorkTimeShare = float64(deepTime) / float64(st.Tracked)
+	}
+
+	for hour, d := range hours {
+		if d > time.Duration(st.BusiestHourTime) {
+			st.BusiestHour, st.BusiestHourTime = hour, Duration(d)
+		}
+	}
+
+	for _, d := range days {
+		st.Days = append(st.Days, *d)
+	}
+	sort.Slice(st.Days, func(i, j int) bool { return st.Days[i].Date < st.Days[j].Date })
+	st.SwitchesPerDay = float64(st.Switches) / float64(len(st.Days))
+
+	st.LongestStreak, st.CurrentStreak = streaks(st.Days, now)
+
+	return st
+}
+
+func inRange(t time.Time, r entities.Range) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+// streaks returns the longest run of days with tracked time and the run
+// ending today or yesterday
+func streaks(days []Day, now time.Time) (int, int) {
+	var longest, run int
+	var prev time.Time
+	for _, d := range days {
+		date, _ := time.ParseInLocation(time.DateOnly, d.Date, now.Location())
+		if d.Tracked <= 0 {
+			continue
+		}
+
+		if !prev.IsZero() && prev.AddDate(0, 0, 1).Equal(date) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = date
+
+		if run > longest {
+			longest = run
+		}
+	}
+
+	today := now.Format(time.DateOnly)
+	yesterday := now.AddDate(0, 0, -1).Format(time.DateOnly)
+	if last := prev.Format(time.DateOnly); prev.IsZero() || (last != today && last != yesterday) {
+		return longest, 0
+	}
+
+	return longest, run
+}
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compute(t *testing.T) {
+	day := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	now := day.AddDate(0, 0, 5).Add(3 * time.Hour)
+
+	elist := entities.InitEmptyElist()
+	// three days in a row, a gap and yesterday
+	assert.NoError(t, elist.AddSession("api", day, day.Add(2*time.Hour), nil))
+	assert.NoError(t, elist.AddSession("docs", day.Add(2*time.Hour), day.Add(2*time.Hour+10*time.Minute), nil))
+	assert.NoError(t, elist.AddSession("api", day.AddDate(0, 0, 1), day.AddDate(0, 0, 1).Add(30*time.Minute), nil))
+	assert.NoError(t, elist.AddSession("api", day.AddDate(0, 0, 2), day.AddDate(0, 0, 2).Add(time.Hour), nil))
+	assert.NoError(t, elist.AddSession("docs", day.AddDate(0, 0, 4), day.AddDate(0, 0, 4).Add(20*time.Minute), nil))
+	lists := elist.Lists()
+
+	// parallel to api on the first day, it doesn't switch context
+	review := &entities.List{Title: "review"}
+	review.SetSessions([]entities.Session{{Start: day.Add(time.Hour), End: day.Add(90 * time.Minute), Parallel: true}})
+	lists = append(lists, review)
+
+	st := Compute(lists, entities.Range{}, 90*time.Minute, now)
+	assert.Equal(t, 6, st.Sessions)
+	assert.Equal(t, Duration(4*time.Hour+30*time.Minute), st.Tracked)
+	assert.Equal(t, Duration(45*time.Minute), st.Average)
+	assert.Equal(t, Duration(2*time.Hour), st.Longest)
+	assert.Equal(t, 3, st.LongestStreak)
+	assert.Equal(t, 1, st.CurrentStreak)
+
+	counts := make(map[string]int)
+	for _, b := range st.Distribution {
+		counts[b.Label] = b.Count
+	}
+	assert.Equal(t, map[string]int{"< 15m": 1, "15m - 30m": 1, "30m - 1h": 2, "1h - 2h": 1, "2h - 4h": 1, ">= 4h": 0}, counts)
+
+	assert.Equal(t, 5, st.Switches)
+	assert.Equal(t, 4, len(st.Days))
+	assert.Equal(t, 2, st.Days[0].Switches)
+	assert.Equal(t, 1.25, st.SwitchesPerDay)
+
+	assert.Equal(t, 9, st.BusiestHour)
+	assert.Equal(t, Duration(2*time.Hour+50*time.Minute), st.BusiestHourTime)
+
+	assert.Equal(t, 1, st.DeepWorkSessions)
+	assert.InDelta(t, 1.0/6, st.DeepWorkShare, 1e-9)
+	assert.InDelta(t, 2.0/4.5, st.DeepWorkTimeShare, 1e-9)
+
+	// only the first day
+	st = Compute(lists, entities.Range{From: day, To: day.AddDate(0, 0, 1)}, 90*time.Minute, now)
+	assert.Equal(t, 3, st.Sessions)
+	assert.Equal(t, 1, st.LongestStreak)
+	assert.Equal(t, 0, st.CurrentStreak)
+
+	data, err := json.Marshal(st)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"tracked_seconds":9600`)
+
+	st = Compute(nil, entities.Range{}, time.Hour, now)
+	assert.Equal(t, 0, st.Sessions)
+	assert.Equal(t, -1, st.BusiestHour)
+}
+
+func Test_HalfHourZone(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	start := time.Date(2024, 1, 1, 9, 40, 0, 0, loc)
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", start, start.Add(time.Hour), nil))
+
+	// 20 minutes till 10:00 and 40 minutes after
+	st := Compute(elist.Lists(), entities.Range{}, time.Hour, start.Add(24*time.Hour))
+	assert.Equal(t, 10, st.BusiestHour)
+	assert.Equal(t, Duration(40*time.Minute), st.BusiestHourTime)
+}
+
+func Test_Switches(t *testing.T) {
+	elist := entities.InitEmptyElist()
+	elist.InsertEntry("api", entities.StatusActive)
+	elist.InsertParallel("build")
+	// switch from api to docs while build keeps running
+	elist.InsertEntry("docs", entities.StatusActive)
+
+	st := Compute(elist.Lists(), entities.Range{}, time.Hour, time.Now())
+	assert.Equal(t, 3, st.Sessions)
+	assert.Equal(t, 2, st.Switches)
+
+	// parallel starts are kept by stored events
+	events, _ := entities.Diff(entities.InitEmptyElist(), elist, time.Now())
+	replayed := entities.InitEmptyElist()
+	assert.NoError(t, replayed.Replay(events))
+	st = Compute(replayed.Lists(), entities.Range{}, time.Hour, time.Now())
+	assert.Equal(t, 2, st.Switches)
+}
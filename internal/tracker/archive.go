@@ -0,0 +1,134 @@
+package tracker
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// trashRetention is how long removed tasks are kept before purge
+var trashRetention = 30 * 24 * time.Hour
+
+// Archive stops the task and hides it from list
+func (a *App) Archive(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide task title")
+	}
+
+	title := getTitleByArgs(args)
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	if err := list.Archive(title); err != nil {
+		log.Fatalf("failed to archive %s: %v", title, err)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+// Unarchive brings archived task back to list
+func (a *App) Unarchive(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide task title")
+	}
+
+	title := getTitleByArgs(args)
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	if err := list.Unarchive(title); err != nil {
+		log.Fatalf("failed to unarchive %s: %v", title, err)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+// Trash shows removed tasks
+func (a *App) Trash(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	lists := list.TrashLists()
+	sortByTitle(lists)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Title", "Removed", "Purge After", "Total Duration", "Tags"})
+	t.AppendSeparator()
+	for _, l := range lists {
+		t.AppendRow(l.AggregateTrashRow(trashRetention))
+		t.AppendSeparator()
+	}
+	t.Render()
+}
+
+// Restore moves task from the trash back to list
+func (a *App) Restore(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide task title")
+	}
+
+	title := getTitleByArgs(args)
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	if err := list.RestoreByTitle(title); err != nil {
+		log.Fatalf("failed to restore %s: %v", title, err)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+// Purge deletes tasks from the trash for good
+func (a *App) Purge(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	before := time.Now().Add(-trashRetention)
+	if cmd.Flags().Lookup(flags.OlderThan.Name).Changed {
+		olderThan, err := cmd.Flags().GetDuration(flags.OlderThan.Name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		before = time.Now().Add(-olderThan)
+	}
+
+	if cmd.Flags().Lookup(flags.All.Name).Changed {
+		before = time.Now()
+	}
+
+	for _, title := range list.PurgeTrash(before) {
+		log.Printf("Purged %s", title)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
@@ -0,0 +1,167 @@
+package tracker
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Unheilbar/time_tracker/internal/backup"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// restoreReplace makes the storage equal to the backup
+	restoreReplace = "replace"
+	// restoreMerge adds missing tasks and sessions keeping the tracked ones
+	restoreMerge = "merge"
+)
+
+// Backup writes all workspaces to the file or to stdout when it's omitted
+// or "-". Files ending with .gz are gzipped as well as with --gzip
+func (a *App) Backup(cmd *cobra.Command, args []string) {
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	lists := make(map[string]*entities.EntriesLists)
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Fatal("failed to upload list from db", err)
+		}
+		lists[name] = list
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	doc := backup.NewDocument(ws.Current, ws.Names, lists)
+
+	gzipped := cmd.Flags().Lookup(flags.Gzip.Name).Changed
+	w := io.Writer(os.Stdout)
+	var f *os.File
+	if len(args) > 0 && args[0] != "-" {
+		var err error
+		if f, err = os.Create(args[0]); err != nil {
+			log.Fatal(err)
+		}
+		w = f
+
+		gzipped = gzipped || strings.HasSuffix(args[0], ".gz")
+	}
+
+	if err := doc.Write(w, gzipped); err != nil {
+		log.Fatal("failed to write backup ", err)
+	}
+
+	// write errors may be reported on close only
+	if f != nil {
+		if err := f.Close(); err != nil {
+			log.Fatal("failed to write backup ", err)
+		}
+	}
+}
+
+// RestoreBackup reads the backup and applies it with --mode. The backup is validated
+// and applied in memory first, so a broken file leaves the storage untouched
+func (a *App) RestoreBackup(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Provide backup file")
+	}
+
+	mode := cmd.Flags().Lookup(flags.Mode.Name).Value.String()
+	if mode != restoreReplace && mode != restoreMerge {
+		log.Fatalf("Unknown mode %q, use %s or %s", mode, restoreReplace, restoreMerge)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := backup.Read(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Backup %s is invalid: %v", args[0], err)
+	}
+
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	restored := entities.InitWorkspaces()
+	if mode == restoreMerge {
+		restored.Current, restored.Names = ws.Current, ws.Names
+	}
+
+	lists := make(map[string]*entities.EntriesLists)
+	var added int
+	for _, w := range doc.Workspaces {
+		if !restored.Has(w.Name) {
+			restored.Names = append(restored.Names, w.Name)
+		}
+
+		if mode == restoreReplace {
+			lists[w.Name] = w.Lists()
+			continue
+		}
+
+		list := entities.InitEmptyElist()
+		if ws.Has(w.Name) {
+			a.repo.UseWorkspace(w.Name)
+			list, err = a.repo.LoadList()
+			if err != nil {
+				log.Fatal("failed to upload list from db", err)
+			}
+		}
+		added += w.Merge(list)
+		lists[w.Name] = list
+	}
+
+	if mode == restoreReplace {
+		if doc.CurrentWorkspace != "" {
+			restored.Current = doc.CurrentWorkspace
+		}
+		// default workspace always exists, it's emptied if it isn't in the backup
+		if lists[entities.DefaultWorkspace] == nil {
+			lists[entities.DefaultWorkspace] = entities.InitEmptyElist()
+		}
+	}
+
+	for _, name := range restored.Names {
+		list, ok := lists[name]
+		if !ok {
+			continue
+		}
+		a.repo.UseWorkspace(name)
+		if err := a.repo.DumpList(list); err != nil {
+			log.Fatal("failed to save list to db ", err)
+		}
+	}
+
+	if mode == restoreReplace {
+		for _, name := range ws.Names {
+			if restored.Has(name) {
+				continue
+			}
+			if err := a.repo.RemoveWorkspace(name); err != nil {
+				log.Fatal("failed to remove workspace data ", err)
+			}
+		}
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	if err := a.repo.DumpWorkspaces(restored); err != nil {
+		log.Fatal("failed to save workspaces to db ", err)
+	}
+
+	if mode == restoreMerge {
+		fmt.Printf("Merged %d sessions from %d workspaces\n", added, len(doc.Workspaces))
+		return
+	}
+	fmt.Printf("Restored %d workspaces\n", len(doc.Workspaces))
+}
@@ -0,0 +1,127 @@
+package tracker
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/chart"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	defaultWidth = 80
+	// chartDays and chartWeeks are drawn when --from is omitted
+	chartDays  = 14
+	chartWeeks = 12
+	untagged   = "untagged"
+)
+
+// Chart draws hours per day or per --period week, stacked by the first tag
+// of the tasks with --stack
+func (a *App) Chart(cmd *cobra.Command, args []string) {
+	period := chart.Period(cmd.Flags().Lookup(flags.Period.Name).Value.String())
+	if period != chart.PeriodDay && period != chart.PeriodWeek {
+		log.Fatalf("Unknown period %q, use %s or %s", period, chart.PeriodDay, chart.PeriodWeek)
+	}
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	now := time.Now()
+	r := getRange(cmd)
+	if r.To.IsZero() {
+		r.To = now
+	}
+	if r.From.IsZero() {
+		r.From = r.To.AddDate(0, 0, -chartDays+1)
+		if period == chart.PeriodWeek {
+			r.From = r.To.AddDate(0, 0, -7*(chartWeeks-1))
+		}
+		// the first bar covers the whole period
+		r.From = chart.PeriodStart(r.From, period, a.settings.WeekStart)
+	}
+
+	opts := chart.Options{
+		Period:    period,
+		From:      r.From,
+		To:        r.To,
+		WeekStart: a.settings.WeekStart,
+		Now:       now,
+	}
+	if cmd.Flags().Lookup(flags.Stack.Name).Changed {
+		opts.Stack = stackByTag
+	}
+
+	buckets := chart.Bars(list.Filter(getTags(cmd), entities.ContainsAll), opts)
+	if err := chart.RenderBars(os.Stdout, buckets, period, chartStyle(cmd)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ChartHeatmap draws tracked time per day of the --year or of the last one
+func (a *App) ChartHeatmap(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	now := time.Now()
+	opts := chart.Options{
+		Period:    chart.PeriodDay,
+		From:      chart.PeriodStart(now.AddDate(-1, 0, 1), chart.PeriodDay, a.settings.WeekStart),
+		To:        now,
+		WeekStart: a.settings.WeekStart,
+		Now:       now,
+	}
+	if year := cmd.Flags().Lookup(flags.Year.Name); year.Changed {
+		y, err := strconv.Atoi(year.Value.String())
+		if err != nil {
+			log.Fatalf("Wrong year %s", year.Value)
+		}
+		opts.From = time.Date(y, time.January, 1, 0, 0, 0, 0, time.Local)
+		opts.To = opts.From.AddDate(1, 0, 0)
+	}
+
+	days := chart.Bars(list.Filter(getTags(cmd), entities.ContainsAll), opts)
+	if err := chart.RenderHeatmap(os.Stdout, days, a.settings.WeekStart, chartStyle(cmd)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func stackByTag(l *entities.List) string {
+	if len(l.Tags) == 0 {
+		return untagged
+	}
+
+	return string(l.Tags[0])
+}
+
+// chartStyle fits the chart to the terminal. Colors are disabled by
+// --no-color, NO_COLOR env and when output isn't a terminal
+func chartStyle(cmd *cobra.Command) chart.Style {
+	style := chart.Style{Width: defaultWidth}
+
+	fd := int(os.Stdout.Fd())
+	isTerminal := term.IsTerminal(fd)
+	if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+		style.Width = width
+	} else if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		style.Width = width
+	}
+
+	if width, err := cmd.Flags().GetInt(flags.Width.Name); err == nil && width > 0 {
+		style.Width = width
+	}
+
+	_, noColor := os.LookupEnv("NO_COLOR")
+	style.Color = isTerminal && !noColor && !cmd.Flags().Lookup(flags.NoColor.Name).Changed
+
+	return style
+}
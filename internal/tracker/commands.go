@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/Unheilbar/time_tracker/internal/config"
 	"github.com/Unheilbar/time_tracker/internal/entities"
 	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/hooks"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 )
@@ -17,63 +21,161 @@ var noTaskMsg = "No tasks yet. Add your first one with command start"
 type Repository interface {
 	LoadList() (*entities.EntriesLists, error)
 	DumpList(*entities.EntriesLists) error
+
+	LoadWorkspaces() (*entities.Workspaces, error)
+	DumpWorkspaces(*entities.Workspaces) error
+	// UseWorkspace switches LoadList and DumpList to the workspace
+	UseWorkspace(name string)
+	RemoveWorkspace(name string) error
+
+	// LoadTimeOff and DumpTimeOff keep days off of all workspaces
+	LoadTimeOff() (*entities.TimeOff, error)
+	DumpTimeOff(*entities.TimeOff) error
+	// LoadSchedules and DumpSchedules keep schedules of all workspaces
+	LoadSchedules() (*entities.Schedules, error)
+	DumpSchedules(*entities.Schedules) error
+	// LoadWebhookQueue and DumpWebhookQueue keep failed webhook deliveries
+	LoadWebhookQueue() (*entities.WebhookQueue, error)
+	DumpWebhookQueue(*entities.WebhookQueue) error
+
+	Close() error
+
+	// SchemaVersion returns version of the stored list of the workspace
+	SchemaVersion() (int, error)
 }
 
 type App struct {
-	repo Repository
+	repo      Repository
+	settings  config.Settings
+	workspace string
+	hooks     *hooks.Runner
+	// events are changes of timers made by the command
+	events []hooks.Event
 }
 
-func NewApp(repo Repository) *App {
-	return &App{
-		repo: repo,
+// NewApp unlocks encrypted repo and switches it to the workspace from
+// settings or to the current one
+func NewApp(repo Repository, settings config.Settings) (*App, error) {
+	a := &App{
+		repo:     repo,
+		settings: settings,
+	}
+	a.hooks = newHooks(a)
+
+	if err := unlock(repo, settings); err != nil {
+		return nil, err
+	}
+
+	ws, err := repo.LoadWorkspaces()
+	if err != nil {
+		return nil, err
 	}
+
+	a.workspace = ws.Current
+	if settings.Workspace != "" {
+		if !ws.Has(settings.Workspace) {
+			return nil, fmt.Errorf("workspace %s doesn't exist", settings.Workspace)
+		}
+		a.workspace = settings.Workspace
+	}
+
+	repo.UseWorkspace(a.workspace)
+
+	return a, nil
+}
+
+// Close releases the storage
+func (a *App) Close() error {
+	return a.repo.Close()
 }
 
-// Root prints active task or provides usage info
+// Root prints running tasks or provides usage info
 func (a *App) Root(cmd *cobra.Command, args []string) {
 	list, err := a.repo.LoadList()
 	if err != nil {
 		log.Fatal("failed to upload list from db", err)
 	}
 
-	activeTitle := list.CurrentActive
-	lastActive := list.LastActive
-	if activeTitle == "" {
+	active := list.ActiveTitles()
+	if len(active) == 0 {
 		log.Println("No tasks are running. Run a task with start [taskname] command")
-		if lastActive != "" {
-			// display last active
-		}
 		return
 	}
 
-	// yet to implement
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"Title", "Created", "Stopped", "Started", "Total Duration", "Session Duration", "Status"})
+	t.AppendHeader(table.Row{"Title", "Created", "Started", "Stopped", "Total Duration", "Session Duration", "Status", "Tags"})
 	t.AppendSeparator()
-	t.AppendRow(list.EntriesListsView[activeTitle].AggregateAllRows())
+	for _, title := range active {
+		t.AppendRow(list.EntriesListsView[title].AggregateAllRows())
+		t.AppendSeparator()
+	}
 	t.Render()
 }
 
 func (a *App) Start(cmd *cobra.Command, args []string) {
-	if len(args) == 0 {
+	isGit := cmd.Flags().Lookup(flags.Git.Name).Changed
+	if len(args) == 0 && !isGit {
 		log.Fatal("Provide task title")
 	}
 
 	title := getTitleByArgs(args)
 
+	tags := getTags(cmd)
+	if !cmd.Flags().Lookup(flags.Tag.Name).Changed {
+		tags = parseTags(a.settings.DefaultTags)
+	}
+
+	// task is named after the branch and tagged with the repository
+	if isGit {
+		branch, repoTag := gitTask()
+		if title == "" {
+			title = branch
+		}
+		tags = append(tags, repoTag)
+	}
+
 	list, err := a.repo.LoadList()
 	if err != nil {
 		log.Fatal("failed to upload list from db", err)
 	}
 
-	list.InsertEntry(title, entities.StatusActive)
+	previous, wasActive := list.CurrentActive, list.IsActive(title)
+	isParallel := cmd.Flags().Lookup(flags.Parallel.Name).Changed
+	if isParallel {
+		list.InsertParallel(title)
+	} else {
+		list.InsertEntry(title, entities.StatusActive)
+	}
+
+	list.EntriesListsView[title].Issues = entities.DetectIssues(title, a.settings.IssuePatterns)
+
+	if parent := getParent(cmd); parent != "" {
+		list.AddList(parent)
+		if err := list.SetParent(title, parent); err != nil {
+			log.Fatalf("failed to set parent %s: %v", parent, err)
+		}
+	}
 
-	tags := getTags(cmd)
 	for _, tag := range tags {
 		list.AddTag(tag, title)
 	}
 
+	if note := getNote(cmd); note != "" {
+		list.EntriesListsView[title].Annotate(-1, note)
+	}
+
+	switch {
+	case !isParallel && previous != "" && previous != title:
+		if err := a.fire(a.event(hooks.Switch, list, title, previous)); err != nil {
+			log.Fatal(err)
+		}
+	case !wasActive:
+		if err := a.fire(a.event(hooks.Start, list, title, "")); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	err = a.repo.DumpList(list)
 	if err != nil {
 		log.Fatal("failed to save list to db ", err)
@@ -81,15 +183,52 @@ func (a *App) Start(cmd *cobra.Command, args []string) {
 
 }
 
+// Stop stops the current active task, the given one or all running tasks.
+// Parallel tasks are never stopped by plain stop
 func (a *App) Stop(cmd *cobra.Command, args []string) {
 	list, err := a.repo.LoadList()
 	if err != nil {
 		log.Fatal("failed to upload list from db")
 	}
 
-	title := list.CurrentActive
+	titles := []entities.ListTitle{list.CurrentActive}
+	if title := getTitleByArgs(args); title != "" {
+		if !list.IsActive(title) {
+			log.Fatalf("Task %s isn't running", title)
+		}
+		titles = []entities.ListTitle{title}
+	}
+
+	all := cmd.Flags().Lookup(flags.All.Name).Changed
+	if all {
+		titles = list.ActiveTitles()
+	}
+
+	// parallel tasks are stopped only by their titles or with --all
+	if !all && titles[0] == "" && len(list.Parallel) > 0 {
+		var running []string
+		for _, t := range list.Parallel {
+			running = append(running, string(t))
+		}
+		log.Fatalf("No current task, running in parallel: %s. Stop them by title or with --all", strings.Join(running, ", "))
+	}
 
-	list.InsertEntry(title, entities.StatusStop)
+	note := getNote(cmd)
+	var events []hooks.Event
+	for _, title := range titles {
+		list.InsertEntry(title, entities.StatusStop)
+		if title == "" {
+			continue
+		}
+
+		if note != "" {
+			list.EntriesListsView[title].Annotate(-1, note)
+		}
+		events = append(events, a.event(hooks.Stop, list, title, ""))
+	}
+	if err := a.fire(events...); err != nil {
+		log.Fatal(err)
+	}
 
 	err = a.repo.DumpList(list)
 	if err != nil {
@@ -105,17 +244,47 @@ func (a *App) Remove(cmd *cobra.Command, args []string) {
 		log.Fatal("failed to upload list from db")
 	}
 
+	remove := list.RemoveByTitle
+	if cmd.Flags().Lookup(flags.Recursive.Name).Changed {
+		remove = list.RemoveTree
+	}
+
+	// hooks see the tasks as they were before removal
+	before := make(map[entities.ListTitle]hooks.Event)
+	for t := range list.EntriesListsView {
+		before[t] = a.event(hooks.Remove, list, t, "")
+	}
+
 	if title != "" {
-		list.RemoveByTitle(title)
+		if err := remove(title); err != nil {
+			log.Fatalf("failed to remove %s: %v", title, err)
+		}
 	}
 
-	isAll := cmd.Flags().Lookup("all").Changed
+	isAll := cmd.Flags().Lookup(flags.All.Name).Changed
 	if isAll {
-		list.RemoveAll()
+		if err := list.RemoveAll(); err != nil {
+			log.Fatalf("failed to remove all: %v", err)
+		}
 	}
 
-	a.repo.DumpList(list)
+	var events []hooks.Event
+	for t, e := range before {
+		if _, ok := list.EntriesListsView[t]; !ok {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Task.Title < events[j].Task.Title })
+	if err := a.fire(events...); err != nil {
+		log.Fatal(err)
+	}
+
+	list.PurgeTrash(time.Now().Add(-trashRetention))
 
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db")
+	}
 }
 
 func (a *App) Resume(cmd *cobra.Command, args []string) {
@@ -129,7 +298,13 @@ func (a *App) Resume(cmd *cobra.Command, args []string) {
 		title = list.LastActive
 	}
 
+	previous, wasActive := list.CurrentActive, list.IsActive(title)
 	list.InsertEntry(title, entities.StatusActive)
+	if title != "" && !wasActive {
+		if err := a.fire(a.event(hooks.Resume, list, title, previous)); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	err = a.repo.DumpList(list)
 	if err != nil {
@@ -144,12 +319,70 @@ func (a *App) List(cmd *cobra.Command, args []string) {
 	}
 
 	tags := getTags(cmd)
+	archived := cmd.Flags().Lookup(flags.Archived.Name).Changed
+	r := getRange(cmd)
+
+	if cmd.Flags().Lookup(flags.Tree.Name).Changed {
+		renderTree(list, tags, archived, r)
+		return
+	}
+
+	renderAggregatedAll(list, tags, archived, r)
+}
+
+// Move puts the task with its subtasks under another parent
+func (a *App) Move(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide task title")
+	}
 
-	renderAggregatedAll(list, tags)
+	title := getTitleByArgs(args)
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	if err := list.SetParent(title, getParent(cmd)); err != nil {
+		log.Fatalf("failed to move %s: %v", title, err)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+// Rename changes title of the task to --as keeping its sessions and tags
+func (a *App) Rename(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide task title")
+	}
+
+	title := getTitleByArgs(args)
+	to := entities.ListTitle(strings.TrimSpace(cmd.Flags().Lookup(flags.As.Name).Value.String()))
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	if err := list.Rename(title, to); err != nil {
+		log.Fatalf("failed to rename %s: %v", title, err)
+	}
+	list.EntriesListsView[to].Issues = entities.DetectIssues(to, a.settings.IssuePatterns)
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
 }
 
 func getTags(cmd *cobra.Command) []entities.Tag {
-	tagsStr := cmd.Flags().Lookup(flags.Tag.Name).Value.String()
+	return parseTags(cmd.Flags().Lookup(flags.Tag.Name).Value.String())
+}
+
+func parseTags(tagsStr string) []entities.Tag {
 	if len(tagsStr) == 0 {
 		return nil
 	}
@@ -162,6 +395,9 @@ func getTags(cmd *cobra.Command) []entities.Tag {
 	for _, tag := range tags {
 		tag = strings.Trim(tag, " ")
 		tag = strings.ToLower(tag)
+		if tag == "" {
+			continue
+		}
 
 		if strings.Contains(tag, " ") {
 			log.Fatalf("Wrong tag format %s. Make sure your tags start with # lie in #youidiot.", tag)
@@ -173,28 +409,109 @@ func getTags(cmd *cobra.Command) []entities.Tag {
 	return res
 }
 
+// getRange reads --from and --to. Dates without time cover the whole day
+func getRange(cmd *cobra.Command) entities.Range {
+	var r entities.Range
+
+	if from := cmd.Flags().Lookup(flags.From.Name); from != nil && from.Changed {
+		r.From = parseTime(from.Value.String(), false)
+	}
+
+	if to := cmd.Flags().Lookup(flags.To.Name); to != nil && to.Changed {
+		r.To = parseTime(to.Value.String(), true)
+	}
+
+	return r
+}
+
+func parseTime(value string, endOfDay bool) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		log.Fatalf("Wrong time %s, use 2006-01-02 or RFC3339", value)
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+// inRange reports whether the list has sessions in the range. Any list
+// matches the open range
+func inRange(l *entities.List, r entities.Range) bool {
+	return r.IsZero() || len(l.SessionsIn(r)) > 0
+}
+
+func getParent(cmd *cobra.Command) entities.ListTitle {
+	return entities.ListTitle(strings.TrimSpace(cmd.Flags().Lookup(flags.Parent.Name).Value.String()))
+}
+
+func getNote(cmd *cobra.Command) string {
+	return strings.TrimSpace(cmd.Flags().Lookup(flags.Note.Name).Value.String())
+}
+
 func getTitleByArgs(args []string) entities.ListTitle {
 	return entities.ListTitle(strings.Join(args, " "))
 }
 
-func renderAggregatedAll(list *entities.EntriesLists, tags []entities.Tag) {
+func renderAggregatedAll(list *entities.EntriesLists, tags []entities.Tag, archived bool, r entities.Range) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"Title", "Created", "Started", "Stopped", "Total Duration", "Session Duration", "Status", "Tags"})
 	t.AppendSeparator()
-	var hasActive bool
+	// running tasks go last
+	var active []*entities.List
 	for _, entries := range list.Filter(tags, entities.ContainsAll) {
-		if entries.Title != list.CurrentActive {
-			t.AppendRow(entries.AggregateAllRows())
-			t.AppendSeparator()
-		} else {
-			hasActive = true
+		if entries.Archived != archived || !inRange(entries, r) {
+			continue
+		}
+
+		if list.IsActive(entries.Title) {
+			active = append(active, entries)
+			continue
+		}
+
+		t.AppendRow(entries.AggregateAllRows())
+		t.AppendSeparator()
+	}
+
+	for _, entries := range active {
+		t.AppendRow(entries.AggregateAllRows())
+		t.AppendSeparator()
+	}
+
+	t.Render()
+}
+
+// renderTree prints tasks under their parents with subtasks time rolled up
+func renderTree(list *entities.EntriesLists, tags []entities.Tag, archived bool, r entities.Range) {
+	var lists []*entities.List
+	for _, l := range list.Filter(tags, entities.ContainsAll) {
+		if l.Archived == archived && inRange(l, r) {
+			lists = append(lists, l)
 		}
 	}
 
-	if list.CurrentActive != "" && hasActive {
-		t.AppendRow(list.EntriesListsView[list.CurrentActive].AggregateAllRows())
+	nodes := entities.Tree(lists)
+
+	totals := make(map[entities.ListTitle]time.Duration)
+	for _, l := range lists {
+		totals[l.Title] = l.Total()
 	}
+	rolled := entities.RollUp(nodes, totals)
 
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Title", "Created", "Started", "Stopped", "Total Duration", "Session Duration", "Status", "Tags"})
+	t.AppendSeparator()
+	for _, node := range nodes {
+		t.AppendRow(node.List.AggregateTreeRow(node.Depth, rolled[node.List.Title]))
+		t.AppendSeparator()
+	}
 	t.Render()
 }
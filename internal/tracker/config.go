@@ -0,0 +1,69 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// ConfigShow prints all settings of the selected profile with their sources
+func ConfigShow(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Config: %s\nProfile: %s\n", cfg.Path, cfg.Profile)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Key", "Value", "Source"})
+	t.AppendSeparator()
+	for _, v := range cfg.Values() {
+		t.AppendRow(table.Row{v.Key, fmt.Sprintf("%q", v.Value), v.Source})
+	}
+	t.Render()
+}
+
+// ConfigGet prints the value of a single setting
+func ConfigGet(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Provide setting name")
+	}
+
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	v, ok := cfg.Get(args[0])
+	if !ok {
+		log.Fatalf("Unknown setting %s", args[0])
+	}
+
+	fmt.Println(v.Value)
+}
+
+// ConfigSet stores the setting in the config file for the selected profile
+func ConfigSet(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		log.Fatal("Provide setting name and value")
+	}
+
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := cfg.File.Set(cfg.Profile, args[0], args[1]); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := cfg.File.Write(cfg.Path); err != nil {
+		log.Fatal("failed to save config ", err)
+	}
+}
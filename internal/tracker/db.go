@@ -0,0 +1,76 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/schema"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Migrate upgrades data of all workspaces to the current schema version.
+// Lists are upgraded on load anyway, migrate stores the result
+func (a *App) Migrate(cmd *cobra.Command, args []string) {
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	dryRun := cmd.Flags().Lookup(flags.DryRun.Name).Changed
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Workspace", "From", "To", "Migration"})
+
+	var migrated int
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		version, err := a.repo.SchemaVersion()
+		if err != nil {
+			log.Fatal("failed to read schema version ", err)
+		}
+
+		pending, err := schema.Pending(version)
+		if err != nil {
+			log.Fatalf("Workspace %s: %v", name, err)
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		for _, m := range pending {
+			t.AppendRow(table.Row{name, m.From, m.From + 1, m.Description})
+		}
+		migrated++
+
+		if dryRun {
+			continue
+		}
+
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Fatalf("failed to migrate workspace %s: %v", name, err)
+		}
+
+		err = a.repo.DumpList(list)
+		if err != nil {
+			log.Fatal("failed to save list to db ", err)
+		}
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	if migrated == 0 {
+		fmt.Printf("Data is up to date, schema version %d\n", schema.Version)
+		return
+	}
+
+	t.Render()
+	verb := "Migrated"
+	if dryRun {
+		verb = "Would migrate"
+	}
+	fmt.Printf("%s %d workspaces to schema version %d\n", verb, migrated, schema.Version)
+}
@@ -0,0 +1,64 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Doctor checks lists of all workspaces and repairs them with --fix
+func (a *App) Doctor(cmd *cobra.Command, args []string) {
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	fix := cmd.Flags().Lookup(flags.Fix.Name).Changed
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Workspace", "Check", "Title", "Problem", "Fixed"})
+	t.AppendSeparator()
+
+	var found int
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Fatalf("failed to upload list of workspace %s: %v", name, err)
+		}
+
+		problems := list.Doctor(fix)
+		for _, p := range problems {
+			t.AppendRow(append(table.Row{name}, p.AggregateRow()...))
+		}
+		found += len(problems)
+
+		if !fix || len(problems) == 0 {
+			continue
+		}
+
+		err = a.repo.DumpList(list)
+		if err != nil {
+			log.Fatal("failed to save list to db ", err)
+		}
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	if found == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	t.Render()
+	if fix {
+		fmt.Printf("Fixed %d problems\n", found)
+		return
+	}
+	fmt.Printf("Found %d problems, run doctor --fix to repair them\n", found)
+	os.Exit(1)
+}
@@ -0,0 +1,199 @@
+package tracker
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Vault is implemented by storages able to encrypt the data at rest
+type Vault interface {
+	Encrypted() (bool, error)
+	Unlock(secret []byte) error
+	// SetEncryption reseals all the data, nil secret disables encryption
+	SetEncryption(secret []byte) error
+}
+
+// unlock opens encrypted storage with key_file, passphrase from env or prompt
+func unlock(repo Repository, settings config.Settings) error {
+	v, ok := repo.(Vault)
+	if !ok {
+		return nil
+	}
+
+	encrypted, err := v.Encrypted()
+	if err != nil || !encrypted {
+		return err
+	}
+
+	secret := unlocked
+	if secret == nil {
+		if secret, err = readSecret(settings.KeyFile, config.PassphraseEnv, false); err != nil {
+			return err
+		}
+	}
+
+	if err := v.Unlock(secret); err != nil {
+		return fmt.Errorf("failed to unlock data: %w", err)
+	}
+	unlocked = secret
+
+	return nil
+}
+
+// unlocked keeps the secret of the storage opened by this process, so watch
+// asks for the passphrase once
+var unlocked []byte
+
+// readSecret returns content of the key file, passphrase from env or the one
+// typed in the terminal. New passphrase is asked twice
+func readSecret(keyFile, env string, confirm bool) ([]byte, error) {
+	if keyFile != "" {
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.TrimSpace(key), nil
+	}
+
+	if val, ok := os.LookupEnv(env); ok {
+		return []byte(val), nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("data is encrypted, set %s or key_file setting", env)
+	}
+
+	secret, err := prompt("Passphrase: ")
+	if err != nil || !confirm {
+		return secret, err
+	}
+
+	repeated, err := prompt("Repeat passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(secret, repeated) {
+		return nil, errors.New("passphrases don't match")
+	}
+
+	return secret, nil
+}
+
+func prompt(msg string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, msg)
+	defer fmt.Fprintln(os.Stderr)
+
+	return term.ReadPassword(int(os.Stdin.Fd()))
+}
+
+func (a *App) vault() Vault {
+	v, ok := a.repo.(Vault)
+	if !ok {
+		log.Fatalf("Backend %s doesn't support encryption", a.settings.Backend)
+	}
+
+	return v
+}
+
+func (a *App) encrypted() bool {
+	encrypted, err := a.vault().Encrypted()
+	if err != nil {
+		log.Fatal("failed to read encryption state ", err)
+	}
+
+	return encrypted
+}
+
+// Encryption shows whether the data is encrypted
+func (a *App) Encryption(cmd *cobra.Command, args []string) {
+	if a.encrypted() {
+		fmt.Println("Data is encrypted")
+		return
+	}
+
+	fmt.Println("Data isn't encrypted")
+}
+
+// EncryptionEnable encrypts all the data with key from --new-key-file or
+// with the new passphrase
+func (a *App) EncryptionEnable(cmd *cobra.Command, args []string) {
+	if a.encrypted() {
+		log.Fatal("Data is already encrypted, use encryption rotate to change the key")
+	}
+
+	a.setEncryption(cmd)
+}
+
+// EncryptionRotate reencrypts all the data with the new key
+func (a *App) EncryptionRotate(cmd *cobra.Command, args []string) {
+	if !a.encrypted() {
+		log.Fatal("Data isn't encrypted, use encryption enable")
+	}
+
+	a.setEncryption(cmd)
+}
+
+// EncryptionDisable decrypts all the data
+func (a *App) EncryptionDisable(cmd *cobra.Command, args []string) {
+	if !a.encrypted() {
+		log.Fatal("Data isn't encrypted")
+	}
+
+	if err := a.vault().SetEncryption(nil); err != nil {
+		log.Fatal("failed to decrypt data ", err)
+	}
+
+	fmt.Println("Data is decrypted")
+}
+
+func (a *App) setEncryption(cmd *cobra.Command) {
+	keyFile := cmd.Flags().Lookup(flags.NewKeyFile.Name).Value.String()
+	secret, err := readSecret(keyFile, config.NewPassphraseEnv, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := a.vault().SetEncryption(secret); err != nil {
+		log.Fatal("failed to encrypt data ", err)
+	}
+
+	fmt.Println("Data is encrypted. Storage is compacted, but its current log file keeps old copies")
+	fmt.Println("till it's rotated, and the disk may keep them in freed blocks")
+	if keyFile != "" && keyFile != a.settings.KeyFile {
+		fmt.Printf("Unlock it with: config set %s %s\n", config.KeyFile, keyFile)
+	}
+}
+
+// EncryptionKeygen writes a random key file readable only by the owner.
+// It doesn't open the storage
+func EncryptionKeygen(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Provide key file path")
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(key)); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,78 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// EventLog is implemented by storages keeping lists as events
+type EventLog interface {
+	// Events returns events stored after the sequence
+	Events(after uint64) ([]entities.Event, error)
+	SnapshotSeq() (uint64, error)
+}
+
+// Events prints stored events of the workspace, of a single task when the
+// title is given. --format json prints them as stored, one per line
+func (a *App) Events(cmd *cobra.Command, args []string) {
+	store, ok := a.repo.(EventLog)
+	if !ok {
+		log.Fatalf("Backend %s doesn't keep events", a.settings.Backend)
+	}
+
+	events, err := store.Events(0)
+	if err != nil {
+		log.Fatal("failed to read events ", err)
+	}
+
+	title := getTitleByArgs(args)
+	var filtered []entities.Event
+	for _, e := range events {
+		if title == "" || e.Title == title || e.To == title {
+			filtered = append(filtered, e)
+		}
+	}
+
+	format := cmd.Flags().Lookup(flags.Format.Name).Value.String()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range filtered {
+			if err := enc.Encode(e); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	case "", "table":
+	default:
+		log.Fatalf("Unknown format %q, use table or json", format)
+	}
+
+	if len(filtered) == 0 {
+		fmt.Println("No events yet")
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Time", "Event", "Title", "Details", "Notes"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Name: "Notes", WidthMax: notesWidth}})
+	t.AppendSeparator()
+	for _, e := range filtered {
+		t.AppendRow(e.AggregateRow())
+	}
+	t.Render()
+
+	seq, err := store.SnapshotSeq()
+	if err != nil {
+		log.Fatal("failed to read snapshot ", err)
+	}
+	fmt.Printf("Snapshot includes events up to #%d\n", seq)
+}
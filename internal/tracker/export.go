@@ -0,0 +1,133 @@
+package tracker
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// exporter writes sessions of the workspace lists in the range in a single
+// format
+type exporter func(w io.Writer, workspace string, lists []*entities.List, r entities.Range) error
+
+var exporters = map[string]exporter{
+	"jira-worklog": exportJiraWorklog,
+	"ics":          exportICS,
+}
+
+// Export writes tasks in the given format to stdout or to --output file
+func (a *App) Export(cmd *cobra.Command, args []string) {
+	format := cmd.Flags().Lookup(flags.Format.Name).Value.String()
+	export, ok := exporters[format]
+	if !ok {
+		log.Fatalf("Unknown format %q, use one of: %s", format, strings.Join(exportFormats(), ", "))
+	}
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	lists := list.Filter(getTags(cmd), entities.ContainsAll)
+	sortByTitle(lists)
+	a.detectIssues(lists)
+
+	w := io.Writer(os.Stdout)
+	if output := cmd.Flags().Lookup(flags.Output.Name).Value.String(); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := export(w, a.workspace, lists, getRange(cmd)); err != nil {
+		log.Fatal("failed to export ", err)
+	}
+}
+
+// Worklog prints time spent per issue detected in task titles
+func (a *App) Worklog(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	lists := list.Filter(getTags(cmd), entities.ContainsAll)
+	sortByTitle(lists)
+	a.detectIssues(lists)
+
+	issue := cmd.Flags().Lookup(flags.Issue.Name).Value.String()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Issue", "Title", "Started", "Seconds", "Comment"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Name: "Comment", WidthMax: notesWidth}})
+	t.AppendSeparator()
+
+	var total int64
+	for _, w := range entities.Worklogs(lists, issue, getRange(cmd)) {
+		t.AppendRow(w.AggregateRow())
+		t.AppendSeparator()
+		total += w.Seconds
+	}
+
+	t.AppendRow(table.Row{"Total", "", "", total})
+	t.Render()
+}
+
+// detectIssues fills issue keys of tasks started before detection was configured
+func (a *App) detectIssues(lists []*entities.List) {
+	for _, l := range lists {
+		if len(l.Issues) == 0 {
+			l.Issues = entities.DetectIssues(l.Title, a.settings.IssuePatterns)
+		}
+	}
+}
+
+func exportFormats() []string {
+	var formats []string
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// jiraWorklog follows the body of Jira add worklog request with the issue key
+// added, so the output can be fed to a bulk import script as is
+type jiraWorklog struct {
+	IssueKey         string `json:"issueKey"`
+	Started          string `json:"started"`
+	TimeSpentSeconds int64  `json:"timeSpentSeconds"`
+	Comment          string `json:"comment,omitempty"`
+}
+
+const jiraTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+func exportJiraWorklog(w io.Writer, workspace string, lists []*entities.List, r entities.Range) error {
+	res := []jiraWorklog{}
+	for _, wl := range entities.Worklogs(lists, "", r) {
+		res = append(res, jiraWorklog{
+			IssueKey:         wl.Issue,
+			Started:          wl.Started.Format(jiraTimeFormat),
+			TimeSpentSeconds: wl.Seconds,
+			Comment:          wl.Comment,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(res)
+}
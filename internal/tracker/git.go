@@ -0,0 +1,124 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/git"
+	"github.com/spf13/cobra"
+)
+
+const postCommitHook = "post-commit"
+
+// GitHookInstall adds post-commit hook which records commits on the active
+// session of the profile and workspace the hook is installed with
+func (a *App) GitHookInstall(cmd *cobra.Command, args []string) {
+	repo, err := git.Open(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	command := hookCommand(exe, a.settings.Profile, a.workspace)
+	if err := repo.InstallHook(postCommitHook, command); err != nil {
+		log.Fatal("failed to install hook ", err)
+	}
+
+	log.Printf("Installed %s hook in %s", postCommitHook, repo.Root)
+}
+
+// hookCommand runs the executable recording the commit with the profile and
+// the workspace. The hook must never fail the commit
+func hookCommand(exe, profile, workspace string) string {
+	return fmt.Sprintf(`%s git-hook record --%s %s --%s %s -- "$(git rev-parse HEAD)" "$(git log -1 --format=%%s)" >/dev/null 2>&1 || true`,
+		shellQuote(exe),
+		config.ProfileFlag, shellQuote(profile),
+		config.Key{Name: config.Workspace}.Flag(), shellQuote(workspace))
+}
+
+// shellQuote quotes the string for sh, nothing is expanded inside single
+// quotes
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (a *App) GitHookUninstall(cmd *cobra.Command, args []string) {
+	repo, err := git.Open(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := repo.UninstallHook(postCommitHook); err != nil {
+		log.Fatal("failed to uninstall hook ", err)
+	}
+}
+
+// GitHookRecord is called by the hook with commit hash and subject. Commit is
+// recorded on the running task named after the branch or on the current one
+func (a *App) GitHookRecord(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide commit hash")
+	}
+
+	commit := entities.Commit{Hash: args[0]}
+	if len(args) > 1 {
+		commit.Subject = strings.Join(args[1:], " ")
+	}
+
+	var branch entities.ListTitle
+	if repo, err := git.Open("."); err == nil {
+		commit.Repo = repo.Name()
+		if b, err := repo.Branch(); err == nil {
+			branch = entities.ListTitle(b)
+		}
+	}
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	title := list.CurrentActive
+	if list.IsActive(branch) {
+		title = branch
+	}
+
+	l, ok := list.EntriesListsView[title]
+	if !ok {
+		return
+	}
+
+	if err := l.AddCommit(commit); err != nil {
+		log.Fatal(err)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+// gitTask returns branch of the current repository and tag with its name
+func gitTask() (entities.ListTitle, entities.Tag) {
+	repo, err := git.Open(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	branch, err := repo.Branch()
+	if err != nil {
+		log.Fatal("failed to read branch ", err)
+	}
+
+	name := strings.ToLower(strings.ReplaceAll(repo.Name(), " ", "-"))
+
+	return entities.ListTitle(branch), entities.Tag("#" + name)
+}
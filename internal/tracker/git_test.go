@@ -0,0 +1,33 @@
+package tracker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HookCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh isn't installed")
+	}
+
+	// the executable prints its arguments instead of recording the commit
+	dir := filepath.Join(t.TempDir(), `it's $HOME `+"`id`"+` é`)
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	exe := filepath.Join(dir, "tracker")
+	out := filepath.Join(t.TempDir(), "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + shellQuote(out) + "\n"
+	assert.NoError(t, os.WriteFile(exe, []byte(script), 0755))
+
+	command := strings.NewReplacer(`"$(git rev-parse HEAD)"`, "abc", `"$(git log -1 --format=%s)"`, `'fix: it'\''s done'`).
+		Replace(hookCommand(exe, "work's", "client $x"))
+	assert.NoError(t, exec.Command("sh", "-c", command).Run())
+
+	args, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "git-hook\nrecord\n--profile\nwork's\n--workspace\nclient $x\n--\nabc\nfix: it's done\n", string(args))
+}
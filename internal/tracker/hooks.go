@@ -0,0 +1,53 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/hooks"
+)
+
+func newHooks(a *App) *hooks.Runner {
+	return &hooks.Runner{
+		Dir:       a.settings.HooksDir,
+		Hooks:     a.settings.Hooks,
+		Timeout:   a.settings.HookTimeout,
+		OnFailure: a.settings.HookFailure,
+		Out:       os.Stderr,
+	}
+}
+
+// fire runs hooks of the events before the change is saved, the error of an
+// aborting hook cancels the change. Webhooks get the events after the command
+func (a *App) fire(events ...hooks.Event) error {
+	for _, e := range events {
+		if err := a.hooks.Run(context.Background(), e); err != nil {
+			return err
+		}
+	}
+
+	a.events = append(a.events, events...)
+
+	return nil
+}
+
+// event describes the change of the task, previous is the task stopped
+// by the change
+func (a *App) event(name string, list *entities.EntriesLists, title, previous entities.ListTitle) hooks.Event {
+	now := time.Now()
+	e := hooks.Event{
+		Event:     name,
+		Time:      now,
+		Workspace: a.workspace,
+		Device:    a.settings.Device,
+		Task:      hooks.NewTask(list.EntriesListsView[title], now),
+	}
+
+	if l, ok := list.EntriesListsView[previous]; ok && previous != title {
+		e.Previous = hooks.NewTask(l, now)
+	}
+
+	return e
+}
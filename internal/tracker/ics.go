@@ -0,0 +1,128 @@
+package tracker
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// exportICS writes iCalendar with an event per session. UID is derived from
+// the workspace and the session start, not the title, so re-import updates
+// existing events after renames as well
+func exportICS(w io.Writer, workspace string, lists []*entities.List, r entities.Range) error {
+	bw := bufio.NewWriter(w)
+	now := time.Now().UTC()
+
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:-//Unheilbar//time_tracker//EN")
+	writeICSLine(bw, "CALSCALE:GREGORIAN")
+
+	uids := sessionUIDs(workspace, lists, r)
+	for _, l := range lists {
+		for i, s := range l.SessionsIn(r) {
+			end := s.End
+			if s.Running() {
+				end = now
+			}
+
+			writeICSLine(bw, "BEGIN:VEVENT")
+			writeICSLine(bw, "UID:"+uids[l][i])
+			writeICSLine(bw, "DTSTAMP:"+now.Format(icsTimeFormat))
+			writeICSLine(bw, "DTSTART:"+s.Start.UTC().Format(icsTimeFormat))
+			writeICSLine(bw, "DTEND:"+end.UTC().Format(icsTimeFormat))
+			writeICSLine(bw, "SUMMARY:"+escapeICS(string(s.Title)))
+
+			if len(l.Tags) > 0 {
+				var categories []string
+				for _, tag := range l.Tags {
+					categories = append(categories, escapeICS(strings.TrimPrefix(string(tag), "#")))
+				}
+				writeICSLine(bw, "CATEGORIES:"+strings.Join(categories, ","))
+			}
+
+			description := s.Notes
+			for _, c := range s.Commits {
+				description = append(description, "commit "+c.String())
+			}
+			if len(description) > 0 {
+				writeICSLine(bw, "DESCRIPTION:"+escapeICS(strings.Join(description, "\n")))
+			}
+
+			writeICSLine(bw, "END:VEVENT")
+		}
+	}
+
+	writeICSLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// sessionUIDs returns UIDs of the sessions in the range by list. Sessions of
+// several tasks may start at once, they're numbered in order of creation of
+// the tasks, which renames keep
+func sessionUIDs(workspace string, lists []*entities.List, r entities.Range) map[*entities.List][]string {
+	byCreation := append([]*entities.List{}, lists...)
+	sort.SliceStable(byCreation, func(i, j int) bool {
+		return byCreation[i].Created.Before(byCreation[j].Created)
+	})
+
+	res := make(map[*entities.List][]string)
+	starts := make(map[int64]int)
+	for _, l := range byCreation {
+		for _, s := range l.SessionsIn(r) {
+			n := starts[s.Start.UnixNano()]
+			starts[s.Start.UnixNano()]++
+			res[l] = append(res[l], sessionUID(workspace, s.Start, n))
+		}
+	}
+
+	return res
+}
+
+// sessionUID identifies the n-th session started at the moment in the
+// workspace
+func sessionUID(workspace string, start time.Time, n int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s/%d/%d", workspace, start.UnixNano(), n)))
+	return fmt.Sprintf("%x@time_tracker", sum)
+}
+
+func escapeICS(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// writeICSLine folds lines longer than 75 octets as RFC 5545 requires
+// without splitting UTF-8 sequences
+func writeICSLine(w *bufio.Writer, line string) {
+	// continuation lines start with a space which counts too
+	limit := 75
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
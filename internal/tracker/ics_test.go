@@ -0,0 +1,67 @@
+package tracker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExportICS(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	title := entities.ListTitle("Review the quarterly plan, budget; hiring and the roadmap for Zürich, Köln; München")
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession(title, start, start.Add(time.Hour), []string{"notes, with\nlines"}))
+	assert.NoError(t, elist.AddSession(title, start.Add(2*time.Hour), start.Add(3*time.Hour), nil))
+	assert.NoError(t, elist.AddTag("#work", title))
+
+	export := func() string {
+		var buf bytes.Buffer
+		assert.NoError(t, exportICS(&buf, entities.DefaultWorkspace, elist.Lists(), entities.Range{}))
+		return buf.String()
+	}
+	data := export()
+
+	// lines are folded at 75 octets without splitting characters
+	assert.True(t, strings.HasSuffix(data, "\r\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+		assert.True(t, utf8.ValidString(line), line)
+	}
+
+	var uids []string
+	unfolded := strings.ReplaceAll(data, "\r\n ", "")
+	for _, line := range strings.Split(unfolded, "\r\n") {
+		if uid, ok := strings.CutPrefix(line, "UID:"); ok {
+			uids = append(uids, uid)
+		}
+	}
+	assert.Contains(t, unfolded, "\r\nSUMMARY:Review the quarterly plan\\, budget\\; hiring and the roadmap for Zürich\\, Köln\\; München\r\n")
+	assert.Contains(t, unfolded, "\r\nDESCRIPTION:notes\\, with\\nlines\r\n")
+	assert.Contains(t, unfolded, "\r\nCATEGORIES:work\r\n")
+	assert.Contains(t, unfolded, "\r\nDTSTART:20240101T100000Z\r\nDTEND:20240101T110000Z\r\n")
+
+	// sessions keep their UIDs between exports and after renames
+	assert.Equal(t, 2, len(uids))
+	assert.NotEqual(t, uids[0], uids[1])
+	assert.NoError(t, elist.Rename(title, "review"))
+	exported := strings.ReplaceAll(export(), "\r\n ", "")
+	assert.Contains(t, exported, "\r\nSUMMARY:review\r\n")
+	for _, uid := range uids {
+		assert.Contains(t, exported, "\r\nUID:"+uid+"\r\n")
+	}
+
+	// parallel sessions of several tasks started at once get their own UIDs
+	elist.AddList("docs").SetSessions([]entities.Session{{Title: "docs", Start: start, End: start.Add(time.Hour), Parallel: true}})
+	elist.EntriesListsView["docs"].Created = start.Add(time.Hour)
+	exported = strings.ReplaceAll(export(), "\r\n ", "")
+	assert.Equal(t, 3, strings.Count(exported, "\r\nUID:"))
+	for _, uid := range uids {
+		assert.Equal(t, 1, strings.Count(exported, "\r\nUID:"+uid+"\r\n"))
+	}
+}
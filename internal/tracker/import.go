@@ -0,0 +1,143 @@
+package tracker
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/importer"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// ImportTimewarrior imports data/*.data files of Timewarrior. Directory
+// arguments are searched for .data files
+func (a *App) ImportTimewarrior(cmd *cobra.Command, args []string) {
+	loc := getTimezone(cmd)
+
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(arg, "*.data"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	a.importFiles(cmd, files, func(r io.Reader) ([]importer.Interval, error) {
+		return importer.Timewarrior(r, loc)
+	})
+}
+
+// ImportWatson imports frames file of Watson
+func (a *App) ImportWatson(cmd *cobra.Command, args []string) {
+	a.importFiles(cmd, args, importer.Watson)
+}
+
+func (a *App) importFiles(cmd *cobra.Command, files []string, parse func(io.Reader) ([]importer.Interval, error)) {
+	if len(files) == 0 {
+		log.Fatal("Provide files to import")
+	}
+
+	var intervals []importer.Interval
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		parsed, err := parse(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("failed to parse %s: %v", file, err)
+		}
+		intervals = append(intervals, parsed...)
+	}
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	summary := importer.Apply(list, intervals)
+	for _, title := range summary.Created {
+		a.detectIssues([]*entities.List{list.EntriesListsView[title]})
+	}
+
+	dryRun := cmd.Flags().Lookup(flags.DryRun.Name).Changed
+	renderImportSummary(summary, dryRun)
+	if dryRun {
+		return
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+func renderImportSummary(summary importer.Summary, dryRun bool) {
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+
+	fmt.Printf("%s %d sessions, %s in total\n", verb, summary.Sessions, summary.Duration)
+	fmt.Printf("New tasks: %d, tags: %d, skipped: %d\n", len(summary.Created), len(summary.Tags), len(summary.Skipped))
+
+	if len(summary.Created) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"New Task"})
+		for _, title := range summary.Created {
+			t.AppendRow(table.Row{title})
+		}
+		t.Render()
+	}
+
+	if len(summary.Skipped) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Skipped", "Start", "End", "Reason"})
+		for _, c := range summary.Skipped {
+			var end string
+			if !c.Interval.End.IsZero() {
+				end = c.Interval.End.Local().Format(time.DateTime)
+			}
+			t.AppendRow(table.Row{c.Interval.Title, c.Interval.Start.Local().Format(time.DateTime), end, c.Reason})
+		}
+		t.Render()
+	}
+}
+
+// getTimezone returns location used for timestamps without zone, local by default
+func getTimezone(cmd *cobra.Command) *time.Location {
+	name := cmd.Flags().Lookup(flags.Timezone.Name).Value.String()
+	if name == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return loc
+}
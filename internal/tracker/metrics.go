@@ -0,0 +1,124 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+// Metrics prints metrics of all workspaces in --format prometheus or
+// openmetrics. --output replaces the file at once, so node_exporter textfile
+// collector never reads it half written
+func (a *App) Metrics(cmd *cobra.Command, args []string) {
+	format, err := metrics.ParseFormat(cmd.Flags().Lookup(flags.Format.Name).Value.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	output := cmd.Flags().Lookup(flags.Output.Name).Value.String()
+	if output == "" {
+		if err := a.WriteMetrics(os.Stdout, format); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := a.WriteMetrics(f, format); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Rename(f.Name(), output); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// WriteMetrics writes metrics of all workspaces in the format
+func (a *App) WriteMetrics(w io.Writer, format metrics.Format) error {
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		return err
+	}
+	defer a.repo.UseWorkspace(a.workspace)
+
+	var workspaces []metrics.Workspace
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			return err
+		}
+		workspaces = append(workspaces, metrics.Workspace{Name: name, List: list})
+	}
+
+	opts := metrics.Options{
+		MaxTasks: a.settings.MetricsMaxTasks,
+		MaxTags:  a.settings.MetricsMaxTags,
+		Tags:     a.settings.MetricsTags,
+	}
+
+	return metrics.Write(w, metrics.Collect(workspaces, opts, time.Now()), format)
+}
+
+// ServeMetrics serves /metrics on the address till ctx is done. Storage is
+// opened for a scrape only, so other commands can use it between scrapes
+func ServeMetrics(ctx context.Context, open func() (*App, error), addr string) error {
+	// storage can't be opened twice at once
+	var mu sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		a, err := open()
+		if err != nil {
+			log.Printf("failed to open storage: %v", err)
+			http.Error(w, "storage is unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		defer a.Close()
+
+		format := metrics.Negotiate(r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", format.ContentType())
+		if err := a.WriteMetrics(w, format); err != nil {
+			log.Printf("failed to write metrics: %v", err)
+		}
+	})
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	log.Printf("Serving metrics on http://%s/metrics", addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/worktime"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Overtime compares time worked between --from and --to with the schedule
+// of the work config section less holidays and days off. Range defaults to
+// the current month till today
+func (a *App) Overtime(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	r := getRange(cmd)
+	if r.From.IsZero() {
+		r.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	// days to come aren't owed yet
+	if r.To.IsZero() || r.To.After(tomorrow) {
+		r.To = tomorrow
+	}
+
+	absences, err := a.absences(r.From, r.To)
+	if err != nil {
+		log.Fatal("failed to load days off ", err)
+	}
+
+	days := worktime.Days(list.Lists(), absences, r.From, r.To, a.settings.Work, now)
+	if len(days) == 0 {
+		fmt.Println("No days in the range")
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Day", "Expected", "Worked", "Breaks", "Off", "Diff", "Balance", "Violations"})
+	t.AppendSeparator()
+	for _, d := range days {
+		if d.Expected == 0 && d.Worked == 0 && len(d.Off) == 0 {
+			continue
+		}
+
+		off := strings.Join(d.Off, "\n")
+		if d.Credited > 0 {
+			off += fmt.Sprintf("\n%s credited", d.Credited)
+		}
+		t.AppendRow(table.Row{
+			d.Date.Format("Mon 2006-01-02"),
+			d.Expected,
+			d.Worked.Round(time.Second),
+			d.Breaks.Round(time.Second),
+			off,
+			signed(d.Diff()),
+			signed(d.Balance),
+			strings.Join(d.Violations, "\n"),
+		})
+	}
+	t.Render()
+
+	t = table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Week", "Expected", "Worked", "Credited", "Diff", "Balance", "Violations"})
+	t.AppendSeparator()
+	for _, w := range worktime.Weeks(days, a.settings.WeekStart) {
+		t.AppendRow(table.Row{
+			w.Start.Format("2006-01-02"),
+			w.Expected,
+			w.Worked.Round(time.Second),
+			w.Credited,
+			signed(w.Diff()),
+			signed(w.Balance),
+			w.Violations,
+		})
+	}
+	t.Render()
+
+	fmt.Printf("Balance %s since %s\n", signed(days[len(days)-1].Balance), days[0].Date.Format("2006-01-02"))
+}
+
+// signed prints overtime with plus and missing time with minus
+func signed(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d > 0 {
+		return "+" + d.String()
+	}
+
+	return d.String()
+}
@@ -0,0 +1,132 @@
+package tracker
+
+import (
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/worktime"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var notesWidth = 40
+
+// Report prints tracked totals per task under their parents. Archived tasks
+// are included. Subtasks deeper than --depth are collapsed into their
+// parents. Other workspaces are reported only with --all-workspaces. The
+// report isn't limited by a range, so credited days off are all the ones
+// till today
+func (a *App) Report(cmd *cobra.Command, args []string) {
+	workspaces := []string{a.workspace}
+
+	allWorkspaces := cmd.Flags().Lookup(flags.AllWorkspaces.Name).Changed
+	if allWorkspaces {
+		ws, err := a.repo.LoadWorkspaces()
+		if err != nil {
+			log.Fatal("failed to upload workspaces from db", err)
+		}
+		workspaces = ws.Names
+	}
+
+	depth, err := cmd.Flags().GetInt(flags.Depth.Name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	header := table.Row{"Title", "Sessions", "Total Duration", "Tags", "Archived", "Notes"}
+	if allWorkspaces {
+		header = append(table.Row{"Workspace"}, header...)
+	}
+	t.AppendHeader(header)
+	t.SetColumnConfigs([]table.ColumnConfig{{Name: "Notes", WidthMax: notesWidth}})
+	t.AppendSeparator()
+
+	var total time.Duration
+	for _, ws := range workspaces {
+		a.repo.UseWorkspace(ws)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Fatal("failed to upload list from db", err)
+		}
+
+		nodes := entities.Tree(list.Filter(getTags(cmd), entities.ContainsAll))
+
+		totals := a.totals(list)
+		rolled := entities.RollUp(nodes, totals)
+		for _, node := range nodes {
+			l := node.List
+			if depth >= 0 && node.Depth > depth {
+				continue
+			}
+
+			// collapsed level carries time of the hidden subtasks
+			d := totals[l.Title]
+			if node.Depth == depth {
+				d = rolled[l.Title]
+			}
+			d = entities.RoundUp(d, a.settings.Rounding)
+
+			row := table.Row(l.AggregateReportRow(node.Depth, d))
+			if allWorkspaces {
+				row = append(table.Row{ws}, row...)
+			}
+			t.AppendRow(row)
+			t.AppendSeparator()
+			total += d
+		}
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	// credited holidays and days off till today count as worked
+	if p := a.settings.Work; p.Holidays == worktime.DaysCredited || p.TimeOff == worktime.DaysCredited {
+		now := time.Now()
+		absences, err := a.absences(time.Time{}, time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()))
+		if err != nil {
+			log.Fatal("failed to load days off ", err)
+		}
+
+		if credited := worktime.Credited(absences, p); credited > 0 {
+			row := table.Row{"Time off", "", credited.String()}
+			if allWorkspaces {
+				row = append(table.Row{""}, row...)
+			}
+			t.AppendRow(row)
+			t.AppendSeparator()
+			total += credited
+		}
+	}
+
+	footer := table.Row{"Total", "", total.Truncate(time.Second).String()}
+	if allWorkspaces {
+		footer = append(table.Row{""}, footer...)
+	}
+	t.AppendRow(footer)
+	t.Render()
+}
+
+// totals returns tracked duration per list counting parallel time as configured
+func (a *App) totals(list *entities.EntriesLists) map[entities.ListTitle]time.Duration {
+	if a.settings.Overlap == config.OverlapSplit {
+		return entities.SplitOverlaps(list.Lists())
+	}
+
+	totals := make(map[entities.ListTitle]time.Duration)
+	for _, l := range list.Lists() {
+		totals[l.Title] = l.Total()
+	}
+
+	return totals
+}
+
+func sortByTitle(lists []*entities.List) {
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Title < lists[j].Title
+	})
+}
@@ -0,0 +1,254 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/cron"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/hooks"
+	"github.com/Unheilbar/time_tracker/internal/scheduler"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// ScheduleAdd logs the task for --duration on every --cron occurrence in
+// the current workspace, --start starts it for the duration instead.
+// Occurrences are taken since now
+func (a *App) ScheduleAdd(cmd *cobra.Command, args []string) {
+	title := getTitleByArgs(args)
+	if title == "" {
+		log.Fatal("Provide task title")
+	}
+
+	spec := cmd.Flags().Lookup(flags.Cron.Name).Value.String()
+	if _, err := cron.Parse(spec); err != nil {
+		log.Fatal(err)
+	}
+
+	duration, err := time.ParseDuration(cmd.Flags().Lookup(flags.Duration.Name).Value.String())
+	if err != nil || duration <= 0 {
+		log.Fatal("Provide positive --duration like 15m")
+	}
+
+	ss, err := a.repo.LoadSchedules()
+	if err != nil {
+		log.Fatal("failed to upload schedules from db", err)
+	}
+
+	s := ss.Add(entities.Schedule{
+		Title:     title,
+		Cron:      spec,
+		Duration:  duration,
+		Tags:      getTags(cmd),
+		Workspace: a.workspace,
+		Start:     cmd.Flags().Lookup(flags.AutoStart.Name).Changed,
+		Done:      time.Now(),
+	})
+
+	if err := a.repo.DumpSchedules(ss); err != nil {
+		log.Fatal("failed to save schedules to db ", err)
+	}
+
+	fmt.Printf("Schedule %d added, next %s\n", s.Id, next(s).Format(a.settings.TimeFormat))
+}
+
+// ScheduleList prints schedules of the current workspace
+func (a *App) ScheduleList(cmd *cobra.Command, args []string) {
+	ss, err := a.repo.LoadSchedules()
+	if err != nil {
+		log.Fatal("failed to upload schedules from db", err)
+	}
+
+	schedules := ss.Of(a.workspace)
+	if len(schedules) == 0 {
+		fmt.Println("No schedules yet. Add one with schedule add [taskname] --cron --duration")
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Id", "Title", "Cron", "Duration", "Tags", "Mode", "Next"})
+	t.AppendSeparator()
+	for _, s := range schedules {
+		mode := "log"
+		if s.Start {
+			mode = "start"
+		}
+
+		var nextAt string
+		if at := next(s); !at.IsZero() {
+			nextAt = at.Format(a.settings.TimeFormat)
+		}
+
+		t.AppendRow(table.Row{s.Id, s.Title, s.Cron, s.Duration, s.Tags, mode, nextAt})
+		t.AppendSeparator()
+	}
+	t.Render()
+}
+
+// ScheduleRemove deletes schedules by their ids, tracked sessions are kept
+func (a *App) ScheduleRemove(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide ids of the schedules")
+	}
+
+	ss, err := a.repo.LoadSchedules()
+	if err != nil {
+		log.Fatal("failed to upload schedules from db", err)
+	}
+
+	var removed []*entities.Schedule
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			log.Fatalf("Wrong id %s", arg)
+		}
+
+		s, err := ss.Remove(id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		removed = append(removed, s)
+	}
+
+	if err := a.repo.DumpSchedules(ss); err != nil {
+		log.Fatal("failed to save schedules to db ", err)
+	}
+
+	for _, s := range removed {
+		fmt.Printf("Schedule %d of %s removed\n", s.Id, s.Title)
+	}
+}
+
+// RunSchedules materializes occurrences of all workspaces passed since the
+// previous run. It's run before tracking commands, so failures are only logged
+func (a *App) RunSchedules() {
+	ss, err := a.repo.LoadSchedules()
+	if err != nil {
+		log.Printf("failed to upload schedules from db: %v", err)
+		return
+	}
+	if len(ss.Schedules) == 0 {
+		return
+	}
+
+	now := time.Now()
+	since := now
+	for _, s := range ss.Schedules {
+		if s.Done.Before(since) {
+			since = s.Done
+		}
+	}
+
+	absences, err := a.absences(since, now.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("failed to load days off: %v", err)
+		return
+	}
+	off := make(map[string]bool)
+	for _, abs := range absences {
+		// part of the day off leaves room for meetings
+		if abs.Hours == 0 {
+			off[abs.Date.Format(time.DateOnly)] = true
+		}
+	}
+	isOff := func(t time.Time) bool {
+		return off[t.Format(time.DateOnly)]
+	}
+
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Printf("failed to upload workspaces from db: %v", err)
+		return
+	}
+	defer a.repo.UseWorkspace(a.workspace)
+
+	var ran bool
+	for _, name := range ws.Names {
+		schedules := ss.Of(name)
+		if len(schedules) == 0 {
+			continue
+		}
+
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Printf("failed to upload list of workspace %s: %v", name, err)
+			continue
+		}
+
+		results := scheduler.Run(list, schedules, isOff, now)
+		if len(results) == 0 {
+			continue
+		}
+		ran = true
+
+		var events []hooks.Event
+		for _, r := range results {
+			var e hooks.Event
+			switch {
+			case r.Started:
+				e = a.event(hooks.Start, list, r.Schedule.Title, "")
+			case r.Stopped:
+				e = a.event(hooks.Stop, list, r.Schedule.Title, "")
+			default:
+				continue
+			}
+			e.Workspace = name
+			events = append(events, e)
+		}
+		// the aborted workspace keeps its list, the occurrences are skipped
+		// and the stops are retried by the next run
+		if err := a.fire(events...); err != nil {
+			log.Printf("Schedules of workspace %s aren't run: %v", name, err)
+			for _, r := range results {
+				if r.Stopped {
+					r.Schedule.Started = r.Start
+				}
+			}
+			continue
+		}
+
+		if err := a.repo.DumpList(list); err != nil {
+			log.Printf("failed to save list of workspace %s: %v", name, err)
+			return
+		}
+
+		for _, r := range results {
+			switch {
+			case r.Started:
+				log.Printf("Scheduled %s started at %s", r.Schedule.Title, r.Start.Format(time.DateTime))
+			case r.Stopped:
+				log.Printf("Scheduled %s stopped at %s", r.Schedule.Title, r.End.Format(time.DateTime))
+			case r.Skipped == "":
+				log.Printf("Scheduled %s logged %s - %s", r.Schedule.Title, r.Start.Format(time.DateTime), r.End.Format(time.DateTime))
+			case r.Skipped == scheduler.SkipWrongCron:
+				log.Printf("Schedule %d has wrong cron %q", r.Schedule.Id, r.Schedule.Cron)
+			}
+		}
+	}
+
+	if !ran {
+		return
+	}
+
+	if err := a.repo.DumpSchedules(ss); err != nil {
+		log.Printf("failed to save schedules to db: %v", err)
+	}
+}
+
+// next returns the next occurrence of the schedule, zero time when there
+// is none
+func next(s *entities.Schedule) time.Time {
+	spec, err := cron.Parse(s.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return spec.Next(time.Now())
+}
@@ -0,0 +1,108 @@
+package tracker
+
+import (
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Sessions prints every session of the task or of all tasks
+func (a *App) Sessions(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	lists := list.Filter(getTags(cmd), entities.ContainsAll)
+	if title := getTitleByArgs(args); title != "" {
+		l, ok := list.EntriesListsView[title]
+		if !ok {
+			log.Fatalf("Task %s doesn't exist", title)
+		}
+		lists = []*entities.List{l}
+	}
+	sortByTitle(lists)
+
+	var sessions []entities.Session
+	for _, l := range lists {
+		sessions = append(sessions, l.Sessions()...)
+	}
+
+	renderSessions(sessions)
+}
+
+// Annotate adds note to the current session or to the past one
+func (a *App) Annotate(cmd *cobra.Command, args []string) {
+	note := getNote(cmd)
+	if note == "" {
+		log.Fatal("Provide note with --note")
+	}
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	title := getTitleByArgs(args)
+	if title == "" {
+		title = list.CurrentActive
+	}
+	if title == "" {
+		title = list.LastActive
+	}
+
+	l, ok := list.EntriesListsView[title]
+	if !ok {
+		log.Fatalf("Task %s doesn't exist", title)
+	}
+
+	// sessions are numbered from 1 as in sessions output
+	session, err := cmd.Flags().GetInt(flags.Session.Name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := l.Annotate(session-1, note); err != nil {
+		log.Fatalf("failed to annotate %s: %v", title, err)
+	}
+
+	err = a.repo.DumpList(list)
+	if err != nil {
+		log.Fatal("failed to save list to db ", err)
+	}
+}
+
+// Search prints sessions which notes contain the query
+func (a *App) Search(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide search query")
+	}
+
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	lists := list.Filter(getTags(cmd), entities.ContainsAll)
+	sortByTitle(lists)
+
+	renderSessions(entities.Search(lists, string(getTitleByArgs(args))))
+}
+
+func renderSessions(sessions []entities.Session) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Title", "Started", "Stopped", "Duration", "Notes", "Commits"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Name: "Notes", WidthMax: notesWidth}})
+	t.AppendSeparator()
+
+	for _, s := range sessions {
+		t.AppendRow(s.AggregateRow())
+		t.AppendSeparator()
+	}
+	t.Render()
+}
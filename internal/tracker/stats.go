@@ -0,0 +1,95 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/stats"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// histogramWidth is the length of the longest bar of the distribution
+const histogramWidth = 30
+
+// Stats prints productivity statistics of the sessions started within
+// --from and --to of the tasks with the --tag. --format json prints them as
+// a single object
+func (a *App) Stats(cmd *cobra.Command, args []string) {
+	list, err := a.repo.LoadList()
+	if err != nil {
+		log.Fatal("failed to upload list from db", err)
+	}
+
+	st := stats.Compute(list.Filter(getTags(cmd), entities.ContainsAll), getRange(cmd), a.settings.DeepWork, time.Now())
+
+	format := cmd.Flags().Lookup(flags.Format.Name).Value.String()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(st); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "", "table":
+	default:
+		log.Fatalf("Unknown format %q, use table or json", format)
+	}
+
+	if st.Sessions == 0 {
+		fmt.Println("No sessions in the range")
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Statistic", "Value"})
+	t.AppendSeparator()
+	t.AppendRows([]table.Row{
+		{"Sessions", st.Sessions},
+		{"Tracked", st.Tracked},
+		{"Average session", st.Average},
+		{"Longest session", st.Longest},
+		{"Longest streak", fmt.Sprintf("%d days", st.LongestStreak)},
+		{"Current streak", fmt.Sprintf("%d days", st.CurrentStreak)},
+		{"Context switches", st.Switches},
+		{"Switches per day", fmt.Sprintf("%.1f", st.SwitchesPerDay)},
+		{"Busiest hour", fmt.Sprintf("%02d:00-%02d:00, %s", st.BusiestHour, (st.BusiestHour+1)%24, st.BusiestHourTime)},
+		{"Deep work sessions", fmt.Sprintf("%d of %d, >= %s", st.DeepWorkSessions, st.Sessions, st.DeepWorkThreshold)},
+		{"Deep work share", fmt.Sprintf("%.0f%% of sessions, %.0f%% of time", 100*st.DeepWorkShare, 100*st.DeepWorkTimeShare)},
+	})
+	t.Render()
+
+	var max int
+	for _, b := range st.Distribution {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+
+	t = table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Session length", "Sessions", ""})
+	t.AppendSeparator()
+	for _, b := range st.Distribution {
+		t.AppendRow(table.Row{b.Label, b.Count, strings.Repeat("█", b.Count*histogramWidth/max)})
+	}
+	t.Render()
+
+	t = table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Day", "Tracked", "Switches"})
+	t.AppendSeparator()
+	for _, d := range st.Days {
+		t.AppendRow(table.Row{d.Date, d.Tracked, d.Switches})
+	}
+	t.Render()
+}
@@ -0,0 +1,139 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/gitsync"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Sync publishes local changes of all workspaces to the git repository and
+// merges changes of other devices. Repository is taken from the argument or
+// from sync_remote setting. Logs of encrypted storage are sealed with the
+// same secret
+func (a *App) Sync(cmd *cobra.Command, args []string) {
+	remote := a.settings.SyncRemote
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	repo, err := gitsync.Open(a.settings.SyncPath, remote)
+	if err != nil {
+		log.Fatal("failed to open sync repository ", err)
+	}
+
+	var secret []byte
+	if v, ok := a.repo.(Vault); ok {
+		encrypted, err := v.Encrypted()
+		if err != nil {
+			log.Fatal("failed to read encryption state ", err)
+		}
+		if encrypted {
+			secret = unlocked
+		}
+	}
+	if err := repo.UseKey(secret); err != nil {
+		log.Fatal("failed to open sync repository ", err)
+	}
+
+	base, known, err := repo.Base()
+	if err != nil {
+		log.Fatal("failed to read synced logs ", err)
+	}
+
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	now := time.Now()
+	lists := make(map[string]*entities.EntriesLists)
+	var events []gitsync.Event
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Fatalf("failed to upload list of workspace %s: %v", name, err)
+		}
+		lists[name] = list
+		events = append(events, gitsync.Diff(base, name, list, now)...)
+	}
+
+	if err := repo.Publish(a.settings.Device, events); err != nil {
+		log.Fatal("failed to sync repository ", err)
+	}
+
+	state, conflicts, err := repo.State()
+	if err != nil {
+		log.Fatal("failed to read logs ", err)
+	}
+	conflicts = gitsync.NewConflicts(known, conflicts)
+
+	var changes []gitsync.Change
+	for _, name := range state.Names() {
+		if !ws.Has(name) {
+			if err := ws.Create(name); err != nil {
+				log.Printf("Workspace %s isn't synced: %v", name, err)
+				continue
+			}
+			lists[name] = entities.InitEmptyElist()
+		}
+	}
+
+	for _, name := range ws.Names {
+		list := lists[name]
+		applied := gitsync.Apply(state, name, list)
+		if len(applied) == 0 {
+			continue
+		}
+		changes = append(changes, applied...)
+
+		a.repo.UseWorkspace(name)
+		if err := a.repo.DumpList(list); err != nil {
+			log.Fatal("failed to save list to db ", err)
+		}
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	if err := a.repo.DumpWorkspaces(ws); err != nil {
+		log.Fatal("failed to save workspaces to db ", err)
+	}
+
+	// logs are applied, they're the base of the next sync
+	if err := repo.SaveBase(); err != nil {
+		log.Fatal("failed to save synced logs ", err)
+	}
+
+	fmt.Printf("Published %d events as %s\n", len(events), a.settings.Device)
+
+	if len(changes) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Workspace", "Title", "Change"})
+		t.AppendSeparator()
+		for _, c := range changes {
+			t.AppendRow(c.AggregateRow())
+		}
+		t.Render()
+	}
+
+	if len(conflicts) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Workspace", "Stopped", "Device", "At", "Running", "Device"})
+		t.AppendSeparator()
+		for _, c := range conflicts {
+			t.AppendRow(table.Row{c.Workspace, c.Title, c.Device, c.Stopped.Format(a.settings.TimeFormat), c.Winner, c.WinnerDevice})
+		}
+		t.Render()
+	}
+
+	if len(changes) == 0 && len(conflicts) == 0 {
+		fmt.Println("Everything is up to date")
+	}
+}
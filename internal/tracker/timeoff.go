@@ -0,0 +1,198 @@
+package tracker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/flags"
+	"github.com/Unheilbar/time_tracker/internal/worktime"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const holidayKind = "holiday"
+
+// TimeOffAdd takes the date or every scheduled day from the first date till
+// the second one inclusive off. --hours takes a part of the day
+func (a *App) TimeOffAdd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		log.Fatal("Provide the date or the first and the last dates, 2006-01-02")
+	}
+
+	from := parseTime(args[0], false)
+	till := from
+	if len(args) == 2 {
+		till = parseTime(args[1], false)
+	}
+	if till.Before(from) {
+		log.Fatal("The last date is before the first one")
+	}
+
+	kind := entities.DayOffKind(cmd.Flags().Lookup(flags.Kind.Name).Value.String())
+	var hours time.Duration
+	if v := cmd.Flags().Lookup(flags.Hours.Name); v.Changed {
+		var err error
+		if hours, err = time.ParseDuration(v.Value.String()); err != nil {
+			log.Fatalf("Wrong hours %s", v.Value)
+		}
+	}
+	note := cmd.Flags().Lookup(flags.Note.Name).Value.String()
+
+	to, err := a.repo.LoadTimeOff()
+	if err != nil {
+		log.Fatal("failed to upload time off from db", err)
+	}
+
+	var added []entities.DayOff
+	for date := from; !date.After(till); date = date.AddDate(0, 0, 1) {
+		// weekends within the range aren't taken
+		if !date.Equal(from) && a.settings.Work.Expected(date.Weekday()) == 0 {
+			continue
+		}
+
+		day, err := to.Add(entities.DayOff{Date: date, Kind: kind, Hours: hours, Note: note})
+		if err != nil {
+			log.Fatal(err)
+		}
+		added = append(added, day)
+	}
+
+	if err := a.repo.DumpTimeOff(to); err != nil {
+		log.Fatal("failed to save time off to db ", err)
+	}
+
+	for _, day := range added {
+		fmt.Printf("Day off %d on %s added\n", day.Id, day.Date.Format(time.DateOnly))
+	}
+}
+
+// TimeOffList prints days off and holidays of the calendars between --from
+// and --to, of the current year by default
+func (a *App) TimeOffList(cmd *cobra.Command, args []string) {
+	to, err := a.repo.LoadTimeOff()
+	if err != nil {
+		log.Fatal("failed to upload time off from db", err)
+	}
+
+	r := getRange(cmd)
+	now := time.Now()
+	if r.From.IsZero() {
+		r.From = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	}
+	if r.To.IsZero() {
+		r.To = time.Date(r.From.Year()+1, time.January, 1, 0, 0, 0, 0, now.Location())
+	}
+
+	calendar, err := worktime.LoadCalendars(a.settings.Work.Calendars)
+	if err != nil {
+		log.Fatal("failed to load holidays ", err)
+	}
+
+	type row struct {
+		date time.Time
+		row  table.Row
+	}
+	var rows []row
+	for _, h := range calendar.In(r.From, r.To) {
+		rows = append(rows, row{h.Date, table.Row{"", h.Date.Format("Mon 2006-01-02"), holidayKind, dayHours(h.Hours), h.Name}})
+	}
+	for _, d := range to.In(r) {
+		rows = append(rows, row{d.Date, table.Row{d.Id, d.Date.Format("Mon 2006-01-02"), d.Kind, dayHours(d.Hours), d.Note}})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No days off in the range")
+		return
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].date.Format(time.DateOnly) < rows[j].date.Format(time.DateOnly)
+	})
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Id", "Date", "Kind", "Hours", "Note"})
+	t.AppendSeparator()
+	for _, r := range rows {
+		t.AppendRow(r.row)
+	}
+	t.Render()
+}
+
+// TimeOffRemove deletes days off by their ids
+func (a *App) TimeOffRemove(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Provide ids of the days off")
+	}
+
+	to, err := a.repo.LoadTimeOff()
+	if err != nil {
+		log.Fatal("failed to upload time off from db", err)
+	}
+
+	var removed []entities.DayOff
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			log.Fatalf("Wrong id %s", arg)
+		}
+
+		day, err := to.Remove(id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		removed = append(removed, day)
+	}
+
+	if err := a.repo.DumpTimeOff(to); err != nil {
+		log.Fatal("failed to save time off to db ", err)
+	}
+
+	for _, day := range removed {
+		fmt.Printf("Day off %d on %s removed\n", day.Id, day.Date.Format(time.DateOnly))
+	}
+}
+
+// absences returns holidays of the calendars and personal days off between
+// the dates counted as the work policy says
+func (a *App) absences(from, to time.Time) ([]worktime.Absence, error) {
+	p := a.settings.Work
+
+	calendar, err := worktime.LoadCalendars(p.Calendars)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []worktime.Absence
+	for _, h := range calendar.In(from, to) {
+		res = append(res, worktime.Absence{Date: h.Date, Name: h.Name, Hours: h.Hours, Credit: p.Holidays == worktime.DaysCredited})
+	}
+
+	timeOff, err := a.repo.LoadTimeOff()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range timeOff.In(entities.Range{From: from, To: to}) {
+		name := string(d.Kind)
+		if d.Note != "" {
+			name += " " + d.Note
+		}
+		res = append(res, worktime.Absence{Date: d.Date, Name: name, Hours: d.Hours, Credit: p.TimeOff == worktime.DaysCredited})
+	}
+
+	return res, nil
+}
+
+func dayHours(d time.Duration) string {
+	if d == 0 {
+		return "day"
+	}
+
+	return d.String()
+}
@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/notify"
+	"github.com/Unheilbar/time_tracker/internal/reminder"
+)
+
+// Watcher keeps reminders sent by previous checks
+type Watcher struct {
+	notifiers []notify.Notifier
+	state     reminder.State
+}
+
+// NewWatcher creates notifiers of the settings
+func NewWatcher(settings config.Settings) (*Watcher, error) {
+	notifiers, err := notify.New(settings.Notifiers, notify.Options{Out: os.Stdout, Command: settings.NotifyCommand})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{notifiers: notifiers}, nil
+}
+
+// Watch runs schedules, sends reminders and retries webhooks every interval
+// till ctx is done.
+// Storage is opened for a check only, so other commands can use it between
+// checks. Failed checks are logged and retried on the next tick
+func (w *Watcher) Watch(ctx context.Context, open func() (*App, error), interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if a, err := open(); err != nil {
+			log.Printf("failed to open storage: %v", err)
+		} else {
+			a.RunSchedules()
+			a.remind(ctx, w)
+			a.Deliver(ctx)
+			a.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remind sends reminders due now for tasks of all workspaces. Days off have
+// no work hours
+func (a *App) remind(ctx context.Context, w *Watcher) {
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Printf("failed to upload workspaces from db: %v", err)
+		return
+	}
+	defer a.repo.UseWorkspace(a.workspace)
+
+	lists := make(map[string]*entities.EntriesLists)
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Printf("failed to upload list of workspace %s: %v", name, err)
+			return
+		}
+		lists[name] = list
+	}
+
+	now := time.Now()
+	absences, err := a.absences(now, now.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("failed to load days off: %v", err)
+	}
+
+	opts := reminder.Options{
+		Idle:    a.settings.RemindIdle,
+		Running: a.settings.RemindRunning,
+		Hours: func(t time.Time) (time.Time, time.Time, bool) {
+			for _, abs := range absences {
+				if abs.Hours == 0 && abs.Date.Format(time.DateOnly) == t.Format(time.DateOnly) {
+					return time.Time{}, time.Time{}, false
+				}
+			}
+			return a.settings.Work.Hours(t)
+		},
+	}
+
+	for _, r := range reminder.Check(lists, &w.state, opts, now) {
+		m := notify.Message{Kind: r.Kind, Title: "time_tracker", Body: r.Message}
+		if err := notify.Send(ctx, w.notifiers, m); err != nil {
+			log.Printf("failed to send reminder: %v", err)
+		}
+	}
+}
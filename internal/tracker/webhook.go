@@ -0,0 +1,164 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/config"
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/Unheilbar/time_tracker/internal/hooks"
+	"github.com/Unheilbar/time_tracker/internal/webhook"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Deliver sends timer events of the command to webhooks along with the
+// deliveries failed before. It's run after every command, so failed
+// deliveries are queued and only logged
+func (a *App) Deliver(ctx context.Context) {
+	if len(a.settings.Webhooks) == 0 {
+		return
+	}
+
+	q, err := a.repo.LoadWebhookQueue()
+	if err != nil {
+		log.Printf("failed to upload webhook queue from db: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, e := range a.events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			log.Printf("failed to encode %s event: %v", e.Event, err)
+			continue
+		}
+		webhook.Enqueue(q, a.settings.Webhooks, e.Event, payload, now)
+	}
+	queued := len(a.events) > 0
+	a.events = nil
+
+	if len(q.Deliveries) == 0 {
+		return
+	}
+
+	res := webhook.Drain(ctx, q, a.settings.Webhooks, webhook.NewClient().Send, now)
+	for _, d := range res.Dropped {
+		log.Printf("Webhook %s event dropped after %d attempts to %s: %s", d.Event, d.Attempts, d.Target, d.LastError)
+	}
+	if res.Failed > 0 {
+		log.Printf("%d webhook deliveries failed, they are retried later", res.Failed)
+	}
+
+	if !queued && res.Sent == 0 && res.Failed == 0 && len(res.Dropped) == 0 {
+		return
+	}
+
+	if err := a.repo.DumpWebhookQueue(q); err != nil {
+		log.Printf("failed to save webhook queue to db: %v", err)
+	}
+}
+
+// WebhooksTest sends a sample event to the given webhooks or to all of them
+// right away, the queue isn't used
+func (a *App) WebhooksTest(cmd *cobra.Command, args []string) {
+	targets := a.webhooks(args)
+
+	now := time.Now()
+	e := hooks.Event{
+		Event:     "test",
+		Time:      now,
+		Workspace: a.workspace,
+		Device:    a.settings.Device,
+		Task: &hooks.Task{
+			Title:          "webhook test",
+			Tags:           []string{},
+			Running:        true,
+			SessionStart:   now.Add(-time.Minute),
+			SessionSeconds: 60,
+			TotalSeconds:   60,
+		},
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := webhook.NewClient()
+	var failed bool
+	for _, t := range targets {
+		d := &entities.Delivery{Target: t.Name, Event: e.Event, Payload: payload, Created: now}
+		if err := client.Send(context.Background(), t, d); err != nil {
+			fmt.Printf("Webhook %s failed: %v\n", t.Name, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Webhook %s delivered\n", t.Name)
+	}
+
+	if failed {
+		log.Fatal("Some webhooks failed")
+	}
+}
+
+// WebhooksQueue prints deliveries waiting for retry
+func (a *App) WebhooksQueue(cmd *cobra.Command, args []string) {
+	q, err := a.repo.LoadWebhookQueue()
+	if err != nil {
+		log.Fatal("failed to upload webhook queue from db ", err)
+	}
+
+	if len(q.Deliveries) == 0 {
+		fmt.Println("No deliveries waiting for retry")
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Id", "Webhook", "Event", "Created", "Attempts", "Next attempt", "Last error"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Name: "Last error", WidthMax: notesWidth}})
+	t.AppendSeparator()
+	for _, d := range q.Deliveries {
+		t.AppendRow(table.Row{
+			d.Id,
+			d.Target,
+			d.Event,
+			d.Created.Format(a.settings.TimeFormat),
+			d.Attempts,
+			d.NextAttempt.Format(a.settings.TimeFormat),
+			d.LastError,
+		})
+		t.AppendSeparator()
+	}
+	t.Render()
+}
+
+// webhooks returns targets by their names, all of them without names
+func (a *App) webhooks(names []string) []webhook.Target {
+	if len(a.settings.Webhooks) == 0 {
+		log.Fatalf("No webhooks yet. Add them to the %q section of the config", config.WebhooksSection)
+	}
+	if len(names) == 0 {
+		return a.settings.Webhooks
+	}
+
+	var res []webhook.Target
+	for _, name := range names {
+		var found bool
+		for _, t := range a.settings.Webhooks {
+			if t.Name == name {
+				res = append(res, t)
+				found = true
+			}
+		}
+		if !found {
+			log.Fatalf("Webhook %s doesn't exist", name)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,82 @@
+package tracker
+
+import (
+	"log"
+	"os"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// Workspaces prints all workspaces with their active tasks
+func (a *App) Workspaces(cmd *cobra.Command, args []string) {
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"", "Workspace", "Tasks", "Active"})
+	t.AppendSeparator()
+	for _, name := range ws.Names {
+		a.repo.UseWorkspace(name)
+		list, err := a.repo.LoadList()
+		if err != nil {
+			log.Fatal("failed to upload list from db", err)
+		}
+
+		var current string
+		if name == a.workspace {
+			current = "*"
+		}
+
+		t.AppendRow(table.Row{current, name, len(list.EntriesListsView), list.CurrentActive})
+	}
+	a.repo.UseWorkspace(a.workspace)
+
+	t.Render()
+}
+
+func (a *App) WorkspaceCreate(cmd *cobra.Command, args []string) {
+	a.updateWorkspaces(args, (*entities.Workspaces).Create)
+}
+
+// WorkspaceUse makes workspace current for the next commands
+func (a *App) WorkspaceUse(cmd *cobra.Command, args []string) {
+	a.updateWorkspaces(args, (*entities.Workspaces).Use)
+}
+
+// WorkspaceDelete removes workspace with all its tasks
+func (a *App) WorkspaceDelete(cmd *cobra.Command, args []string) {
+	name := a.updateWorkspaces(args, (*entities.Workspaces).Delete)
+
+	err := a.repo.RemoveWorkspace(name)
+	if err != nil {
+		log.Fatal("failed to remove workspace data ", err)
+	}
+}
+
+func (a *App) updateWorkspaces(args []string, update func(*entities.Workspaces, string) error) string {
+	if len(args) != 1 {
+		log.Fatal("Provide workspace name")
+	}
+	name := args[0]
+
+	ws, err := a.repo.LoadWorkspaces()
+	if err != nil {
+		log.Fatal("failed to upload workspaces from db", err)
+	}
+
+	if err := update(ws, name); err != nil {
+		log.Fatalf("Workspace %s: %v", name, err)
+	}
+
+	err = a.repo.DumpWorkspaces(ws)
+	if err != nil {
+		log.Fatal("failed to save workspaces to db ", err)
+	}
+
+	return name
+}
@@ -0,0 +1,162 @@
+// Package vault seals stored values with a key derived from a passphrase or
+// a key file. Parameters of the derivation are stored in plain along with the
+// data, they include a sealed check value telling a wrong key apart from
+// damaged data.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version of the sealed format
+const Version = 1
+
+var (
+	// ErrWrongKey is returned when the secret doesn't unlock the data
+	ErrWrongKey = errors.New("wrong passphrase or key file")
+
+	// ErrNotSealed is returned for values stored before encryption was enabled
+	ErrNotSealed = errors.New("value isn't encrypted")
+)
+
+// magic prefixes sealed values, it can't start plain JSON
+var magic = []byte("ttv1")
+
+// check is sealed into the parameters to verify the key
+var check = []byte("time_tracker")
+
+const (
+	keyLen  = 32
+	saltLen = 16
+)
+
+// Params are parameters of the key derivation
+type Params struct {
+	Version int
+	Salt    []byte
+	// N, R and P are scrypt costs
+	N, R, P int
+	// Check is the sealed known value
+	Check []byte
+}
+
+// Cipher seals and opens values with the derived key
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New derives a key from secret with a random salt
+func New(secret []byte) (*Params, *Cipher, error) {
+	if len(secret) == 0 {
+		return nil, nil, errors.New("empty passphrase or key file")
+	}
+
+	p := &Params{Version: Version, Salt: make([]byte, saltLen), N: 1 << 15, R: 8, P: 1}
+	if _, err := rand.Read(p.Salt); err != nil {
+		return nil, nil, err
+	}
+
+	c, err := p.derive(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.Check, err = c.Seal(check)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, c, nil
+}
+
+// Unlock derives the key from secret and verifies it with the check value
+func (p *Params) Unlock(secret []byte) (*Cipher, error) {
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported encryption version %d", p.Version)
+	}
+
+	c, err := p.derive(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := c.Open(p.Check)
+	if err != nil || !bytes.Equal(plain, check) {
+		return nil, ErrWrongKey
+	}
+
+	return c, nil
+}
+
+func (p *Params) derive(secret []byte) (*Cipher, error) {
+	key, err := scrypt.Key(secret, p.Salt, p.N, p.R, p.P, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Marshal encodes the parameters to be stored
+func (p *Params) Marshal() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// Unmarshal decodes stored parameters
+func Unmarshal(data []byte) (*Params, error) {
+	p := &Params{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to read encryption parameters: %w", err)
+	}
+
+	return p, nil
+}
+
+// Seal encrypts the value with a random nonce
+func (c *Cipher) Seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append(append([]byte{}, magic...), nonce...)
+	return c.aead.Seal(sealed, nonce, plain, magic), nil
+}
+
+// Open decrypts the value sealed by Seal
+func (c *Cipher) Open(sealed []byte) ([]byte, error) {
+	if !bytes.HasPrefix(sealed, magic) {
+		return nil, ErrNotSealed
+	}
+	sealed = sealed[len(magic):]
+
+	size := c.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("encrypted value is truncated")
+	}
+
+	plain, err := c.aead.Open(nil, sealed[:size], sealed[size:], magic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return plain, nil
+}
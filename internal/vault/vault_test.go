@@ -0,0 +1,42 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Vault(t *testing.T) {
+	params, c, err := New([]byte("secret"))
+	assert.NoError(t, err)
+
+	sealed, err := c.Seal([]byte(`{"CurrentActive":"api"}`))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), "api")
+
+	// parameters are stored, the key is derived again
+	enc, err := params.Marshal()
+	assert.NoError(t, err)
+	params, err = Unmarshal(enc)
+	assert.NoError(t, err)
+
+	_, err = params.Unlock([]byte("wrong"))
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	c, err = params.Unlock([]byte("secret"))
+	assert.NoError(t, err)
+
+	plain, err := c.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"CurrentActive":"api"}`, string(plain))
+
+	_, err = c.Open([]byte(`{"CurrentActive":"api"}`))
+	assert.ErrorIs(t, err, ErrNotSealed)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = c.Open(sealed)
+	assert.Error(t, err)
+
+	_, _, err = New(nil)
+	assert.Error(t, err)
+}
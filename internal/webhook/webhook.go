@@ -0,0 +1,211 @@
+// Package webhook sends signed timer events to HTTP endpoints and retries
+// failed deliveries
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Headers of the requests
+const (
+	SignatureHeader = "X-Time-Tracker-Signature"
+	EventHeader     = "X-Time-Tracker-Event"
+	DeliveryHeader  = "X-Time-Tracker-Delivery"
+)
+
+// Target is the endpoint from the config
+type Target struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret signs payloads with HMAC-SHA256, requests aren't signed
+	// without it
+	Secret string `json:"secret,omitempty"`
+	// Events the target receives, all of them when empty
+	Events []string `json:"events,omitempty"`
+}
+
+// Validate checks name and url of the target
+func (t Target) Validate() error {
+	if t.Name == "" {
+		return errors.New("webhook name can't be empty")
+	}
+
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", t.Name, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook %s: url must be http or https", t.Name)
+	}
+
+	return nil
+}
+
+func (t Target) receives(event string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+
+	for _, e := range t.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign returns the signature header value of the body, like GitHub does
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature header value of the body
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Client posts deliveries
+type Client struct {
+	HTTP *http.Client
+}
+
+// timeout bounds a single request, commands wait for deliveries
+const timeout = 5 * time.Second
+
+func NewClient() *Client {
+	return &Client{HTTP: &http.Client{Timeout: timeout}}
+}
+
+// Send posts the delivery to the target, responses other than 2xx are
+// errors
+func (c *Client) Send(ctx context.Context, t Target, d *entities.Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "time_tracker")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(d.Id, 10))
+	if t.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(t.Secret, d.Payload))
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %s", t.Name, resp.Status)
+	}
+
+	return nil
+}
+
+// Enqueue adds deliveries of the event to targets receiving it
+func Enqueue(q *entities.WebhookQueue, targets []Target, event string, payload []byte, now time.Time) {
+	for _, t := range targets {
+		if t.receives(event) {
+			q.Push(entities.Delivery{
+				Target:      t.Name,
+				Event:       event,
+				Payload:     payload,
+				Created:     now,
+				NextAttempt: now,
+			})
+		}
+	}
+}
+
+// MaxAttempts is the number of failed sends after which the delivery is
+// dropped
+const MaxAttempts = 10
+
+// backoff returns the delay after the failed attempt, doubling from a
+// minute up to an hour
+func backoff(attempts int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempts && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+
+	return d
+}
+
+// Result tells what happened to deliveries on drain
+type Result struct {
+	Sent    int
+	Failed  int
+	Dropped []*entities.Delivery
+}
+
+// Sender posts the delivery to the target
+type Sender func(ctx context.Context, t Target, d *entities.Delivery) error
+
+// Drain sends deliveries due at now in order. Sent ones are removed, failed
+// ones wait for the backoff and are dropped after MaxAttempts or when
+// their target is gone from the config. Deliveries to a target wait
+// behind its failed one, so events arrive in order
+func Drain(ctx context.Context, q *entities.WebhookQueue, targets []Target, send Sender, now time.Time) Result {
+	byName := make(map[string]Target)
+	for _, t := range targets {
+		byName[t.Name] = t
+	}
+
+	var res Result
+	blocked := make(map[string]bool)
+	for _, d := range append([]*entities.Delivery(nil), q.Deliveries...) {
+		t, ok := byName[d.Target]
+		if !ok {
+			q.Remove(d.Id)
+			res.Dropped = append(res.Dropped, d)
+			continue
+		}
+		if blocked[d.Target] || d.NextAttempt.After(now) {
+			blocked[d.Target] = true
+			continue
+		}
+
+		err := send(ctx, t, d)
+		if err == nil {
+			q.Remove(d.Id)
+			res.Sent++
+			continue
+		}
+
+		res.Failed++
+		blocked[d.Target] = true
+		d.Attempts++
+		d.LastError = err.Error()
+		d.NextAttempt = now.Add(backoff(d.Attempts))
+		if d.Attempts >= MaxAttempts {
+			q.Remove(d.Id)
+			res.Dropped = append(res.Dropped, d)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Drain(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+		failing  = true
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("secret", body, r.Header.Get(SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, r.Header.Get(EventHeader)+" "+string(body))
+	}))
+	defer srv.Close()
+
+	targets := []Target{
+		{Name: "dashboard", URL: srv.URL, Secret: "secret", Events: []string{"start", "stop"}},
+		{Name: "unsigned", URL: srv.URL},
+	}
+	for _, target := range targets {
+		assert.NoError(t, target.Validate())
+	}
+
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	q := entities.InitWebhookQueue()
+	Enqueue(q, targets[:1], "start", []byte(`{"n":1}`), now)
+	Enqueue(q, targets[:1], "remove", []byte(`{"n":2}`), now)
+	Enqueue(q, targets[:1], "stop", []byte(`{"n":3}`), now)
+	assert.Equal(t, 2, len(q.Deliveries))
+
+	send := NewClient().Send
+	res := Drain(context.Background(), q, targets, send, now)
+	assert.Equal(t, Result{Failed: 1}, res)
+	assert.Equal(t, 2, len(q.Deliveries))
+	assert.Equal(t, 1, q.Deliveries[0].Attempts)
+	assert.Equal(t, now.Add(time.Minute), q.Deliveries[0].NextAttempt)
+	assert.Contains(t, q.Deliveries[0].LastError, "503")
+
+	// backoff isn't over yet
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	res = Drain(context.Background(), q, targets, send, now.Add(30*time.Second))
+	assert.Equal(t, Result{}, res)
+
+	res = Drain(context.Background(), q, targets, send, now.Add(time.Minute))
+	assert.Equal(t, Result{Sent: 2}, res)
+	assert.Empty(t, q.Deliveries)
+	assert.Equal(t, []string{`start {"n":1}`, `stop {"n":3}`}, received)
+
+	// wrong signature isn't accepted
+	Enqueue(q, targets[1:], "start", []byte(`{}`), now)
+	res = Drain(context.Background(), q, targets, send, now)
+	assert.Equal(t, 1, res.Failed)
+
+	// dropped when the target is gone
+	res = Drain(context.Background(), q, targets[:1], send, now)
+	assert.Equal(t, 1, len(res.Dropped))
+	assert.Empty(t, q.Deliveries)
+}
+
+func Test_DrainAttempts(t *testing.T) {
+	targets := []Target{{Name: "down", URL: "http://localhost"}}
+	failed := func(context.Context, Target, *entities.Delivery) error { return io.ErrUnexpectedEOF }
+
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	q := entities.InitWebhookQueue()
+	Enqueue(q, targets, "start", []byte(`{}`), now)
+
+	var res Result
+	for i := 0; i < MaxAttempts; i++ {
+		res = Drain(context.Background(), q, targets, failed, now)
+		now = now.Add(time.Hour)
+	}
+	assert.Equal(t, 1, len(res.Dropped))
+	assert.Equal(t, MaxAttempts, res.Dropped[0].Attempts)
+	assert.Empty(t, q.Deliveries)
+
+	assert.Equal(t, 4*time.Minute, backoff(3))
+	assert.Equal(t, time.Hour, backoff(MaxAttempts))
+}
+
+func Test_Target(t *testing.T) {
+	assert.Error(t, Target{URL: "https://example.com"}.Validate())
+	assert.Error(t, Target{Name: "ftp", URL: "ftp://example.com"}.Validate())
+	assert.True(t, Verify("key", []byte("body"), Sign("key", []byte("body"))))
+	assert.False(t, Verify("other", []byte("body"), Sign("key", []byte("body"))))
+}
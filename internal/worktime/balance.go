@@ -0,0 +1,242 @@
+package worktime
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+)
+
+// Day compares time worked within a day with the schedule
+type Day struct {
+	Date     time.Time
+	Expected time.Duration
+	// Worked counts time of parallel tasks once
+	Worked time.Duration
+	Breaks time.Duration
+	// Stretch is the longest work without a break
+	Stretch time.Duration
+	// Off names holidays and days off of the date, Credited is their time
+	// counted as worked
+	Off      []string
+	Credited time.Duration
+	// Balance is the sum of differences since the first day
+	Balance    time.Duration
+	Violations []string
+}
+
+// Diff returns overtime of the day, negative when less is worked
+func (d Day) Diff() time.Duration {
+	return d.Worked + d.Credited - d.Expected
+}
+
+// Week sums days starting from Start
+type Week struct {
+	Start      time.Time
+	Expected   time.Duration
+	Worked     time.Duration
+	Credited   time.Duration
+	Balance    time.Duration
+	Violations int
+}
+
+func (w Week) Diff() time.Duration {
+	return w.Worked + w.Credited - w.Expected
+}
+
+// Absence is a holiday or a day off. Zero Hours means the whole scheduled
+// day, Credit counts the time as worked instead of not expecting it
+type Absence struct {
+	Date   time.Time
+	Name   string
+	Hours  time.Duration
+	Credit bool
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+// Days returns a day per date between from and to exclusive. Sessions
+// crossing midnight are split between days, running ones end now. Days
+// start in the location of now, absences are matched by their dates
+func Days(lists []*entities.List, absences []Absence, from, to time.Time, p Policy, now time.Time) []Day {
+	loc := now.Location()
+
+	var all []interval
+	for _, l := range lists {
+		for _, s := range l.Sessions() {
+			end := s.End
+			if s.Running() {
+				end = now
+			}
+			all = append(all, interval{s.Start, end})
+		}
+	}
+
+	off := make(map[string][]Absence)
+	for _, a := range absences {
+		date := a.Date.Format(time.DateOnly)
+		off[date] = append(off[date], a)
+	}
+
+	var days []Day
+	var balance time.Duration
+	for date := dayStart(from.In(loc)); date.Before(to); date = date.AddDate(0, 0, 1) {
+		day := measure(within(all, date, date.AddDate(0, 0, 1)), p)
+		day.Date = date
+		day.Expected = p.Expected(date.Weekday())
+		day.Violations = check(day, p)
+		absent(&day, off[date.Format(time.DateOnly)])
+
+		balance += day.Diff()
+		day.Balance = balance
+
+		days = append(days, day)
+	}
+
+	return days
+}
+
+// Weeks groups days by weeks starting on the weekday
+func Weeks(days []Day, weekStart time.Weekday) []Week {
+	var weeks []Week
+	for _, d := range days {
+		start := d.Date.AddDate(0, 0, -((int(d.Date.Weekday()) - int(weekStart) + 7) % 7))
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Start.Equal(start) {
+			weeks = append(weeks, Week{Start: start})
+		}
+
+		w := &weeks[len(weeks)-1]
+		w.Expected += d.Expected
+		w.Worked += d.Worked
+		w.Credited += d.Credited
+		w.Balance = d.Balance
+		if len(d.Violations) > 0 {
+			w.Violations++
+		}
+	}
+
+	return weeks
+}
+
+// absent takes hours of the absences out of the scheduled day. Absences
+// beyond the schedule take nothing
+func absent(day *Day, absences []Absence) {
+	scheduled := day.Expected
+	for _, a := range absences {
+		day.Off = append(day.Off, a.Name)
+
+		h := a.Hours
+		if h == 0 || h > scheduled {
+			h = scheduled
+		}
+		scheduled -= h
+
+		if a.Credit {
+			day.Credited += h
+		} else {
+			day.Expected -= h
+		}
+	}
+}
+
+// Credited returns time of the absences counted as worked
+func Credited(absences []Absence, p Policy) time.Duration {
+	var days []Day
+	index := make(map[string]int)
+	for _, a := range absences {
+		date := a.Date.Format(time.DateOnly)
+		i, ok := index[date]
+		if !ok {
+			i = len(days)
+			index[date] = i
+			days = append(days, Day{Expected: p.Expected(a.Date.Weekday())})
+		}
+		absent(&days[i], []Absence{a})
+	}
+
+	var total time.Duration
+	for _, d := range days {
+		total += d.Credited
+	}
+
+	return total
+}
+
+// within returns union of the intervals clipped to [from, to) in order
+func within(all []interval, from, to time.Time) []interval {
+	var res []interval
+	for _, i := range all {
+		if i.start.Before(from) {
+			i.start = from
+		}
+		if i.end.After(to) {
+			i.end = to
+		}
+		if i.end.After(i.start) {
+			res = append(res, i)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].start.Before(res[j].start) })
+
+	var union []interval
+	for _, i := range res {
+		if n := len(union); n > 0 && !i.start.After(union[n-1].end) {
+			if i.end.After(union[n-1].end) {
+				union[n-1].end = i.end
+			}
+			continue
+		}
+		union = append(union, i)
+	}
+
+	return union
+}
+
+// measure counts work, breaks and stretches of the day. Pauses shorter than
+// MinBreak aren't breaks, they don't end a stretch
+func measure(union []interval, p Policy) Day {
+	var day Day
+	var stretch time.Duration
+	for n, i := range union {
+		if n > 0 {
+			if gap := i.start.Sub(union[n-1].end); gap >= time.Duration(p.MinBreak) {
+				day.Breaks += gap
+				stretch = 0
+			}
+		}
+
+		d := i.end.Sub(i.start)
+		day.Worked += d
+		stretch += d
+		if stretch > day.Stretch {
+			day.Stretch = stretch
+		}
+	}
+
+	return day
+}
+
+// check returns broken rules of the day
+func check(day Day, p Policy) []string {
+	var res []string
+	if r, ok := p.breakRule(day.Worked); ok && day.Breaks < time.Duration(r.Min) {
+		res = append(res, fmt.Sprintf("%s of breaks, %s required after %s", day.Breaks.Round(time.Second), time.Duration(r.Min), time.Duration(r.After)))
+	}
+
+	if p.MaxStretch > 0 && day.Stretch > time.Duration(p.MaxStretch) {
+		res = append(res, fmt.Sprintf("%s without a break, %s allowed", day.Stretch.Round(time.Second), time.Duration(p.MaxStretch)))
+	}
+
+	if p.MaxDaily > 0 && day.Worked > time.Duration(p.MaxDaily) {
+		res = append(res, fmt.Sprintf("%s worked, %s allowed", day.Worked.Round(time.Second), time.Duration(p.MaxDaily)))
+	}
+
+	return res
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,205 @@
+package worktime
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Holiday is a public holiday. Yearly ones repeat on the same month and day,
+// zero Hours means the whole scheduled day
+type Holiday struct {
+	Date   time.Time
+	Name   string
+	Yearly bool
+	Hours  time.Duration
+}
+
+// Calendar is a set of holidays loaded from files
+type Calendar []Holiday
+
+// LoadCalendars reads ICS files by .ics extension and JSON files otherwise.
+// Leading ~ is the home directory
+func LoadCalendars(paths []string) (Calendar, error) {
+	var res Calendar
+	for _, path := range paths {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(home, rest)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var holidays Calendar
+		if strings.EqualFold(filepath.Ext(path), ".ics") {
+			holidays, err = ReadICS(f)
+		} else {
+			holidays, err = ReadJSON(f)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", path, err)
+		}
+
+		res = append(res, holidays...)
+	}
+
+	return res, nil
+}
+
+// jsonHoliday is an entry of JSON calendar like
+// {"date": "2024-12-24", "name": "Christmas Eve", "hours": "4h"}
+type jsonHoliday struct {
+	Date   string   `json:"date"`
+	Name   string   `json:"name"`
+	Yearly bool     `json:"yearly,omitempty"`
+	Hours  Duration `json:"hours,omitempty"`
+}
+
+// ReadJSON reads an array of holidays
+func ReadJSON(r io.Reader) (Calendar, error) {
+	var entries []jsonHoliday
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	var res Calendar
+	for _, e := range entries {
+		date, err := time.Parse(time.DateOnly, e.Date)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Holiday{Date: date, Name: e.Name, Yearly: e.Yearly, Hours: time.Duration(e.Hours)})
+	}
+
+	return res, nil
+}
+
+// ReadICS reads all day events. Events spanning several days are split into
+// a holiday per day, events with yearly RRULE repeat every year and other
+// rules are read as a single occurrence
+func ReadICS(r io.Reader) (Calendar, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// folded lines continue the previous one
+		if n := len(lines); n > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[n-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var res Calendar
+	var event map[string]string
+	for _, line := range lines {
+		switch line {
+		case "BEGIN:VEVENT":
+			event = make(map[string]string)
+			continue
+		case "END:VEVENT":
+			holidays, err := icsHolidays(event)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, holidays...)
+			event = nil
+			continue
+		}
+
+		if event == nil {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// parameters like ;VALUE=DATE aren't needed
+		name, _, _ = strings.Cut(name, ";")
+		event[strings.ToUpper(name)] = value
+	}
+
+	return res, nil
+}
+
+func icsHolidays(event map[string]string) (Calendar, error) {
+	start, err := icsDate(event["DTSTART"])
+	if err != nil {
+		return nil, err
+	}
+
+	end := start.AddDate(0, 0, 1)
+	if v, ok := event["DTEND"]; ok {
+		if end, err = icsDate(v); err != nil {
+			return nil, err
+		}
+	}
+
+	name := unescapeICS(event["SUMMARY"])
+	yearly := strings.Contains(strings.ToUpper(event["RRULE"]), "FREQ=YEARLY")
+
+	var res Calendar
+	for day := start; day.Before(end) || day.Equal(start); day = day.AddDate(0, 0, 1) {
+		res = append(res, Holiday{Date: day, Name: name, Yearly: yearly})
+	}
+
+	return res, nil
+}
+
+// icsDate takes the date of DATE or DATE-TIME value
+func icsDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("wrong date %q", value)
+	}
+
+	return time.Parse("20060102", value[:8])
+}
+
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// In returns holidays dated within from and to exclusive, yearly ones are
+// repeated for every year of the range since their first date. Dates are compared as written
+func (c Calendar) In(from, to time.Time) []Holiday {
+	first, last := from.Format(time.DateOnly), to.Format(time.DateOnly)
+
+	var res []Holiday
+	for _, h := range c {
+		dates := []time.Time{h.Date}
+		if h.Yearly {
+			dates = nil
+			for year := max(from.Year(), h.Date.Year()); year <= to.Year(); year++ {
+				// february 29 doesn't move to march
+				if date := time.Date(year, h.Date.Month(), h.Date.Day(), 0, 0, 0, 0, time.UTC); date.Month() == h.Date.Month() {
+					dates = append(dates, date)
+				}
+			}
+		}
+
+		for _, date := range dates {
+			if d := date.Format(time.DateOnly); d >= first && d < last {
+				h.Date = date
+				res = append(res, h)
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,198 @@
+// Package worktime compares tracked time with the expected working hours
+// and checks break rules
+package worktime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Duration is written in config as a Go duration string like "7h30m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+
+	return nil
+}
+
+// BreakRule requires at least Min of breaks on days with more than After of
+// work
+type BreakRule struct {
+	After Duration `json:"after"`
+	Min   Duration `json:"min"`
+}
+
+// Policy is the weekly schedule and the rules of a working day. Zero limits
+// aren't checked
+type Policy struct {
+	// Schedule maps english weekday names to expected hours, missing days
+	// are days off
+	Schedule map[string]Duration `json:"schedule"`
+	Breaks   []BreakRule         `json:"breaks,omitempty"`
+	// MinBreak is the shortest pause counted as a break
+	MinBreak Duration `json:"min_break,omitempty"`
+	// MaxStretch is the longest work allowed without a break
+	MaxStretch Duration `json:"max_stretch,omitempty"`
+	MaxDaily   Duration `json:"max_daily,omitempty"`
+	// DayStart and DayEnd are work hours of scheduled days like "09:00",
+	// reminders are sent within them
+	DayStart string `json:"day_start,omitempty"`
+	DayEnd   string `json:"day_end,omitempty"`
+
+	// Calendars are ICS or JSON files with public holidays
+	Calendars []string `json:"calendars,omitempty"`
+	// Holidays and TimeOff tell how days off count, DaysOff by default
+	Holidays string `json:"holidays,omitempty"`
+	TimeOff  string `json:"time_off,omitempty"`
+}
+
+const (
+	// DaysOff aren't expected to be worked
+	DaysOff = "off"
+	// DaysCredited are expected and counted as worked
+	DaysCredited = "credit"
+)
+
+// DefaultPolicy expects eight hours from monday to friday
+func DefaultPolicy() Policy {
+	p := Policy{
+		Schedule: make(map[string]Duration),
+		MinBreak: Duration(15 * time.Minute),
+		DayStart: "09:00",
+		DayEnd:   "17:00",
+	}
+	for d := time.Monday; d <= time.Friday; d++ {
+		p.Schedule[strings.ToLower(d.String())] = Duration(8 * time.Hour)
+	}
+
+	return p
+}
+
+// Validate checks weekday names and limits
+func (p Policy) Validate() error {
+	for name, d := range p.Schedule {
+		if _, ok := weekday(name); !ok {
+			return fmt.Errorf("unknown weekday %s", name)
+		}
+		if d < 0 || time.Duration(d) > 24*time.Hour {
+			return fmt.Errorf("%s hours should be within a day", name)
+		}
+	}
+
+	for _, r := range p.Breaks {
+		if r.After <= 0 || r.Min <= 0 {
+			return errors.New("break rule needs positive after and min")
+		}
+	}
+
+	for _, mode := range []string{p.Holidays, p.TimeOff} {
+		if mode != "" && mode != DaysOff && mode != DaysCredited {
+			return fmt.Errorf("unknown days off mode %s, use %s or %s", mode, DaysOff, DaysCredited)
+		}
+	}
+
+	start, err := clock(p.DayStart, 0)
+	if err != nil {
+		return err
+	}
+	end, err := clock(p.DayEnd, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if end < start {
+		return errors.New("work day should end after its start")
+	}
+
+	if p.MinBreak < 0 || p.MaxStretch < 0 || p.MaxDaily < 0 {
+		return errors.New("limits can't be negative")
+	}
+
+	return nil
+}
+
+// Expected returns hours expected on the weekday
+func (p Policy) Expected(day time.Weekday) time.Duration {
+	for name, d := range p.Schedule {
+		if w, _ := weekday(name); w == day {
+			return time.Duration(d)
+		}
+	}
+
+	return 0
+}
+
+// Hours returns work hours of the day of the moment, false for days without
+// scheduled work
+func (p Policy) Hours(t time.Time) (time.Time, time.Time, bool) {
+	if p.Expected(t.Weekday()) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start, err := clock(p.DayStart, 0)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := clock(p.DayEnd, 24*time.Hour)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	day := dayStart(t)
+	return day.Add(start), day.AddDate(0, 0, int(end/(24*time.Hour))).Add(end % (24 * time.Hour)), true
+}
+
+// clock parses time of the day, empty value is the given one
+func clock(value string, empty time.Duration) (time.Duration, error) {
+	if value == "" {
+		return empty, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("wrong time of the day %s, use 15:04", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// breakRule returns the strictest rule applied to the worked time
+func (p Policy) breakRule(worked time.Duration) (BreakRule, bool) {
+	rules := append([]BreakRule(nil), p.Breaks...)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].After > rules[j].After })
+
+	for _, r := range rules {
+		if worked > time.Duration(r.After) {
+			return r, true
+		}
+	}
+
+	return BreakRule{}, false
+}
+
+func weekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,140 @@
+package worktime
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Unheilbar/time_tracker/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Days(t *testing.T) {
+	var p Policy
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"schedule": {"monday": "8h", "tuesday": "8h", "wednesday": "8h", "thursday": "8h", "friday": "6h"},
+		"breaks": [{"after": "6h", "min": "30m"}, {"after": "9h", "min": "45m"}],
+		"min_break": "15m",
+		"max_stretch": "6h",
+		"max_daily": "10h"
+	}`), &p))
+	assert.NoError(t, p.Validate())
+
+	// 2024-01-01 is monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	elist := entities.InitEmptyElist()
+	// monday 8h with a 30m break and a 10m pause which isn't a break
+	assert.NoError(t, elist.AddSession("api", at(1, 8, 0), at(1, 12, 0), nil))
+	assert.NoError(t, elist.AddSession("api", at(1, 12, 30), at(1, 14, 0), nil))
+	assert.NoError(t, elist.AddSession("docs", at(1, 14, 10), at(1, 16, 40), nil))
+	// tuesday 7h without breaks
+	assert.NoError(t, elist.AddSession("api", at(2, 9, 0), at(2, 16, 0), nil))
+	// wednesday night shift crossing midnight
+	assert.NoError(t, elist.AddSession("ops", at(3, 20, 0), at(4, 2, 0), nil))
+	lists := elist.Lists()
+
+	// parallel to api on tuesday, counted once
+	review := &entities.List{Title: "review"}
+	review.SetSessions([]entities.Session{{Start: at(2, 10, 0), End: at(2, 11, 0)}})
+	lists = append(lists, review)
+
+	days := Days(lists, nil, at(1, 0, 0), at(8, 0, 0), p, at(8, 0, 0))
+	assert.Equal(t, 7, len(days))
+
+	assert.Equal(t, 8*time.Hour, days[0].Worked)
+	assert.Equal(t, 30*time.Minute, days[0].Breaks)
+	assert.Equal(t, 4*time.Hour, days[0].Stretch)
+	assert.Empty(t, days[0].Violations)
+	assert.Equal(t, time.Duration(0), days[0].Balance)
+
+	assert.Equal(t, 7*time.Hour, days[1].Worked)
+	assert.Equal(t, 2, len(days[1].Violations))
+	assert.Equal(t, -time.Hour, days[1].Balance)
+
+	assert.Equal(t, 4*time.Hour, days[2].Worked)
+	assert.Equal(t, 2*time.Hour, days[3].Worked)
+	assert.Equal(t, -17*time.Hour, days[4].Balance)
+	assert.Equal(t, time.Duration(0), days[5].Expected)
+
+	weeks := Weeks(days, time.Monday)
+	assert.Equal(t, 1, len(weeks))
+	assert.Equal(t, 38*time.Hour, weeks[0].Expected)
+	assert.Equal(t, 21*time.Hour, weeks[0].Worked)
+	assert.Equal(t, -17*time.Hour, weeks[0].Diff())
+	assert.Equal(t, 1, weeks[0].Violations)
+
+	weeks = Weeks(days, time.Sunday)
+	assert.Equal(t, 2, len(weeks))
+	assert.Equal(t, days[5].Balance, weeks[0].Balance)
+
+	p.Schedule["someday"] = Duration(time.Hour)
+	assert.Error(t, p.Validate())
+}
+
+func Test_Absences(t *testing.T) {
+	p := DefaultPolicy()
+	at := func(day int) time.Time {
+		return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	absences := []Absence{
+		{Date: at(1), Name: "New Year"},
+		{Date: at(2), Name: "pto", Credit: true},
+		{Date: at(3), Name: "pto", Hours: 4 * time.Hour},
+		// saturday isn't scheduled
+		{Date: at(6), Name: "sick", Credit: true},
+	}
+
+	elist := entities.InitEmptyElist()
+	assert.NoError(t, elist.AddSession("api", at(3).Add(9*time.Hour), at(3).Add(13*time.Hour), nil))
+
+	days := Days(elist.Lists(), absences, at(1), at(8), p, at(8))
+	assert.Equal(t, time.Duration(0), days[0].Expected)
+	assert.Equal(t, []string{"New Year"}, days[0].Off)
+	assert.Equal(t, 8*time.Hour, days[1].Expected)
+	assert.Equal(t, 8*time.Hour, days[1].Credited)
+	assert.Equal(t, 4*time.Hour, days[2].Expected)
+	assert.Equal(t, time.Duration(0), days[2].Balance)
+	assert.Equal(t, time.Duration(0), days[5].Credited)
+
+	assert.Equal(t, 8*time.Hour, Credited(absences, p))
+
+	weeks := Weeks(days, time.Monday)
+	assert.Equal(t, 8*time.Hour, weeks[0].Credited)
+}
+
+func Test_Calendar(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20240101\r\n" +
+		"DTEND;VALUE=DATE:20240102\r\n" +
+		"SUMMARY:New Year\r\n" +
+		"RRULE:FREQ=YEARLY\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20241224\r\n" +
+		"DTEND;VALUE=DATE:20241227\r\n" +
+		"SUMMARY:Christmas\\, \r\n" +
+		" holidays\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	c, err := ReadICS(strings.NewReader(ics))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(c))
+	assert.Equal(t, "Christmas, holidays", c[1].Name)
+
+	in := c.In(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 5, len(in))
+
+	js, err := ReadJSON(strings.NewReader(`[{"date": "2024-12-31", "name": "New Year's Eve", "hours": "4h"}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 4*time.Hour, js[0].Hours)
+
+	_, err = ReadJSON(strings.NewReader(`[{"date": "31.12.2024"}]`))
+	assert.Error(t, err)
+}